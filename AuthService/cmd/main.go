@@ -2,16 +2,43 @@ package main
 
 import (
 	"AuthService/internal/config"
-	"AuthService/internal/postgres"
 	"AuthService/internal/usecases"
+	"AuthService/migrations"
 	"AuthService/pkg/grpc/auth"
+	"AuthService/pkg/grpc/healthcheck"
+	"AuthService/pkg/grpc/interceptors"
+	httpAuth "AuthService/pkg/http/auth"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/migrate"
+	"AuthService/pkg/notify"
+	"AuthService/pkg/oidc"
+	"AuthService/pkg/password"
 	"AuthService/pkg/pg"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"math"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "tune-password" {
+		tunePassword(os.Args[2:])
+		return
+	}
+
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic("failed to initialize logger: " + err.Error())
@@ -29,26 +56,149 @@ func main() {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
 
-	// Подключение к базе данных
-	db, err := pg.NewDB(cfg)
-	if err != nil {
-		logger.Fatal("failed to connect to database", zap.Error(err))
+	// Хранилище данных: PostgreSQL или, для локальной разработки, память
+	// процесса (StorageDriver=memory)
+	var store *storage
+	if cfg.StorageDriver == "memory" {
+		if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "grant-admin") {
+			logger.Fatal(os.Args[1] + " subcommand requires StorageDriver=postgres")
+		}
+		logger.Warn("using in-memory storage, all data will be lost on restart")
+		store = newMemoryStorage()
+	} else {
+		// Подключение к базе данных
+		db, err := pg.NewDB(cfg)
+		if err != nil {
+			logger.Fatal("failed to connect to database", zap.Error(err))
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error("failed to close database connection", zap.Error(err))
+			}
+		}()
+
+		// Миграции схемы: subcommand migrate управляет ими вручную, при обычном
+		// запуске сервис не стартует на устаревшей схеме
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err != nil {
+			logger.Fatal("failed to load migrations", zap.Error(err))
+		}
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := runMigrate(context.Background(), migrator, os.Args[2:], logger); err != nil {
+				logger.Fatal("migration failed", zap.Error(err))
+			}
+			return
+		}
+		if cfg.MigrateOnStart {
+			if err := migrator.Up(context.Background()); err != nil {
+				logger.Fatal("failed to apply migrations", zap.Error(err))
+			}
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			logger.Fatal("database schema is out of date, run the migrate subcommand or set MigrateOnStart",
+				zap.Error(err), zap.Int("expected_version", migrator.Latest()))
+		}
+
+		store = newPostgresStorage(db, logger)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			logger.Error("failed to close database connection", zap.Error(err))
+
+	// Без страницы согласия авторизовать клиентов OAuth нельзя: если они
+	// уже зарегистрированы, OAuthConsentURL обязателен
+	if cfg.OAuthConsentURL == "" {
+		hasClients, err := store.oauth.HasClients(context.Background())
+		if err != nil {
+			logger.Fatal("failed to check oauth clients", zap.Error(err))
 		}
-	}()
+		if hasClients {
+			logger.Fatal("OAuthConsentURL is required when oauth clients are registered")
+		}
+	}
+
+	// Загрузка ключей подписи
+	keys, err := loadKeys(cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to load signing keys", zap.Error(err), zap.String("dir", cfg.JWTKeysDir))
+	}
+
+	// Загрузка политики паролей
+	passwordPolicy, err := password.NewPolicy(cfg.PasswordMinLength, cfg.PasswordBannedList)
+	if err != nil {
+		logger.Fatal("failed to load password policy", zap.Error(err), zap.String("banned_list", cfg.PasswordBannedList))
+	}
+	hasher, err := newHasher(cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to create password hasher", zap.Error(err))
+	}
+
+	// Внешние OpenID Connect провайдеры
+	providers, err := loadProviders(cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to load identity providers", zap.Error(err), zap.String("file", cfg.OIDCProvidersFile))
+	}
 
 	// Инициализация репозиториев и сервисов
-	userRepo := postgres.NewUserRepository(db, logger)
-	authService := usecases.NewAuthService(userRepo, cfg, logger)
+	authService := usecases.NewAuthService(store.users, store.tokens, store.sessions, store.attempts, store.factors, store.resets, store.suspensions, store.oauth, store.identities, store.personalTokens, store.audit, store.userEvents, store.tx, keys, passwordPolicy, hasher, newNotifier(cfg, logger), providers, cfg, logger)
+
+	if len(os.Args) > 1 && os.Args[1] == "grant-admin" {
+		if err := grantAdmin(context.Background(), authService, os.Args[2:]); err != nil {
+			logger.Fatal("failed to grant admin role", zap.Error(err))
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Потоки WatchUserEvents завершаются по сигналу остановки, иначе
+	// GracefulStop ждал бы их до ShutdownTimeout.
+	authServer := &auth.Server{
+		AuthService:    authService,
+		TrustedProxies: cfg.TrustedProxies,
+		Shutdown:       ctx.Done(),
+	}
+
+	// HTTP сервер: публичные ключи (JWKS), подтверждение почты, OAuth2 и REST шлюз к RPC
+	httpHandler := &httpAuth.Handler{
+		Keys:             keys,
+		AuthService:      authService,
+		Logger:           logger,
+		Server:           authServer,
+		RefreshTTL:       cfg.RefreshTTL,
+		SecureCookies:    cfg.AppEnv != "development",
+		TrustedProxies:   cfg.TrustedProxies,
+		OAuthConsentURL:  cfg.OAuthConsentURL,
+		ExternalStateTTL: cfg.OIDCStateTTL,
+	}
+	httpServer := &http.Server{
+		Addr:    ":" + cfg.ServerPort,
+		Handler: httpHandler.Routes(),
+	}
+	go func() {
+		logger.Info("HTTP server started", zap.String("port", cfg.ServerPort))
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("failed to serve HTTP server", zap.Error(err))
+		}
+	}()
 
 	// Создание gRPC сервера
-	grpcServer := grpc.NewServer()
-	auth.RegisterAuthServiceServer(grpcServer, &auth.Server{
-		AuthService: authService,
-	})
+	grpcServer := grpc.NewServer(interceptors.ServerOptions(logger)...)
+	auth.RegisterAuthServiceServer(grpcServer, authServer)
+
+	// Health checking: статус зависит от доступности базы данных
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	checker := healthcheck.NewChecker(healthServer, store.pinger, []string{auth.AuthService_ServiceDesc.ServiceName}, cfg.HealthCheckInterval, logger)
+
+	if cfg.GRPCReflection {
+		reflection.Register(grpcServer)
+		logger.Info("gRPC reflection enabled")
+	}
+
+	go checker.Run(ctx)
+
+	// Удаление записей журнала аудита старше AuditRetention
+	retention := usecases.NewAuditRetention(store.audit, cfg.AuditRetention, cfg.AuditPruneInterval, logger)
+	go retention.Run(ctx)
 
 	// Запуск сервера
 	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
@@ -56,8 +206,219 @@ func main() {
 		logger.Fatal("failed to listen", zap.Error(err), zap.String("port", cfg.GRPCPort))
 	}
 
-	logger.Info("gRPC server started", zap.String("port", cfg.GRPCPort))
-	if err := grpcServer.Serve(lis); err != nil {
-		logger.Fatal("failed to serve gRPC server", zap.Error(err))
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("gRPC server started", zap.String("port", cfg.GRPCPort))
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
+		logger.Error("failed to serve gRPC server", zap.Error(err))
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+	}
+
+	shutdown(grpcServer, healthServer, httpServer, cfg.ShutdownTimeout, logger)
+	// Соединение с базой закрывается отложенным вызовом выше, уже после
+	// завершения всех обработчиков.
+}
+
+// shutdown переводит health статус в NOT_SERVING, чтобы балансировщик
+// перестал направлять запросы, и дожидается завершения текущих вызовов.
+// Если они не укладываются в timeout, соединения закрываются принудительно.
+func shutdown(grpcServer *grpc.Server, healthServer *health.Server, httpServer *http.Server, timeout time.Duration, logger *zap.Logger) {
+	healthServer.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Warn("failed to shut down HTTP server gracefully", zap.Error(err))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		logger.Info("gRPC server stopped")
+	case <-ctx.Done():
+		logger.Warn("graceful shutdown timed out, forcing stop", zap.Duration("timeout", timeout))
+		grpcServer.Stop()
+		<-stopped
+	}
+}
+
+func loadKeys(cfg *config.Config, logger *zap.Logger) (*jwt.KeySet, error) {
+	policy := jwt.Policy{Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience}
+	if cfg.JWTActiveKeyID == "" && cfg.AppEnv == "development" {
+		logger.Warn("JWTActiveKeyID is not set, using an ephemeral signing key")
+		return jwt.GenerateKeySet("dev", policy)
+	}
+	return jwt.LoadKeySet(cfg.JWTKeysDir, cfg.JWTActiveKeyID, policy)
+}
+
+// newHasher выбирает алгоритм хеширования новых паролей по настройке
+// PasswordHasher: "argon2id" (по умолчанию) или "bcrypt". Хеши другого
+// алгоритма продолжают проверяться и обновляются при входе.
+func newHasher(cfg *config.Config, logger *zap.Logger) (*password.Hasher, error) {
+	argon, err := password.NewArgon2id(password.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bcrypt := password.NewBcrypt(cfg.BcryptCost)
+
+	if cfg.PasswordHasher == "bcrypt" {
+		return password.NewHasher(bcrypt, argon), nil
+	}
+	if cfg.PasswordHasher != "argon2id" {
+		logger.Warn("unknown PasswordHasher, using argon2id", zap.String("hasher", cfg.PasswordHasher))
+	}
+	return password.NewHasher(argon, bcrypt), nil
+}
+
+// tunePassword подбирает параметры Argon2id под целевое время
+// хеширования на текущей машине и печатает их в формате .env.
+func tunePassword(args []string) {
+	flags := flag.NewFlagSet("tune-password", flag.ExitOnError)
+	target := flags.Duration("target", 250*time.Millisecond, "желаемое время хеширования одного пароля")
+	maxMemory := flags.Uint("max-memory", 64*1024, "максимальный объем памяти в КиБ")
+	parallelism := flags.Uint("parallelism", 1, "число потоков")
+	_ = flags.Parse(args)
+
+	if *maxMemory > math.MaxUint32 || *parallelism > math.MaxUint8 {
+		fmt.Fprintln(os.Stderr, "max-memory or parallelism is out of range")
+		os.Exit(2)
+	}
+	params, err := password.TuneArgon2(*target, uint32(*maxMemory), uint8(*parallelism))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Printf("PasswordHasher=argon2id\nArgon2Memory=%d\nArgon2Iterations=%d\nArgon2Parallelism=%d\n",
+		params.Memory, params.Iterations, params.Parallelism)
+}
+
+// grantAdmin выполняет subcommand grant-admin <username>: назначает
+// первого администратора, которого нельзя создать через RPC GrantRole.
+func grantAdmin(ctx context.Context, authService usecases.AuthService, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: grant-admin <username>")
+	}
+	if err := authService.BootstrapAdmin(ctx, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("admin role granted to %s\n", args[0])
+	return nil
+}
+
+// runMigrate выполняет subcommand migrate:
+//
+//	migrate [up]            применить все миграции
+//	migrate down [n]        откатить n последних миграций (по умолчанию 1)
+//	migrate to <version>    привести схему к версии, 0 - откатить все
+//	migrate force <version> записать версию без выполнения скриптов
+//	migrate version         показать текущую и ожидаемую версии
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string, logger *zap.Logger) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	versionArg := func(def int) (int, error) {
+		if len(args) < 2 {
+			if def < 0 {
+				return 0, fmt.Errorf("migrate %s requires a version", command)
+			}
+			return def, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid argument %q for migrate %s", args[1], command)
+		}
+		return n, nil
+	}
+
+	var err error
+	switch command {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		var steps int
+		if steps, err = versionArg(1); err == nil {
+			err = migrator.Down(ctx, steps)
+		}
+	case "to":
+		var version int
+		if version, err = versionArg(-1); err == nil {
+			err = migrator.To(ctx, version)
+		}
+	case "force":
+		var version int
+		if version, err = versionArg(-1); err == nil {
+			err = migrator.Force(ctx, version)
+		}
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, to, force or version", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("schema version", zap.Int("version", version), zap.Int("expected_version", migrator.Latest()))
+	fmt.Printf("version %d (expected %d)\n", version, migrator.Latest())
+	return nil
+}
+
+// newNotifier выбирает способ доставки писем по настройке Notifier:
+// "smtp", "file" или "log" (по умолчанию).
+func newNotifier(cfg *config.Config, logger *zap.Logger) notify.Notifier {
+	switch cfg.Notifier {
+	case "smtp":
+		return notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	case "file":
+		return notify.NewFileNotifier(cfg.NotifierFile)
+	default:
+		return notify.NewLogNotifier(logger)
+	}
+}
+
+// loadProviders создает провайдеров из файла OIDCProvidersFile. Без файла
+// вход через внешних провайдеров отключен.
+func loadProviders(cfg *config.Config, logger *zap.Logger) ([]usecases.ExternalProvider, error) {
+	if cfg.OIDCProvidersFile == "" {
+		return nil, nil
+	}
+	configs, err := oidc.LoadConfig(cfg.OIDCProvidersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]usecases.ExternalProvider, 0, len(configs))
+	for _, providerConfig := range configs {
+		providers = append(providers, oidc.NewProvider(providerConfig, nil))
+		logger.Info("identity provider configured",
+			zap.String("provider", providerConfig.Name),
+			zap.String("issuer", providerConfig.Issuer))
 	}
+	return providers, nil
 }
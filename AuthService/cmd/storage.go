@@ -0,0 +1,69 @@
+package main
+
+import (
+	"AuthService/internal/domain/repositories"
+	"AuthService/internal/memory"
+	"AuthService/internal/postgres"
+	"AuthService/pkg/grpc/healthcheck"
+	"database/sql"
+	"go.uber.org/zap"
+)
+
+// storage - репозитории выбранного StorageDriver.
+type storage struct {
+	users          repositories.UserRepo
+	tokens         repositories.TokenRepo
+	sessions       repositories.SessionRepo
+	attempts       repositories.AttemptRepo
+	factors        repositories.SecondFactorRepo
+	resets         repositories.PasswordResetRepo
+	suspensions    repositories.SuspensionRepo
+	oauth          repositories.OAuthRepo
+	identities     repositories.ExternalIdentityRepo
+	personalTokens repositories.PersonalTokenRepo
+	audit          repositories.AuditRepo
+	userEvents     repositories.UserEventRepo
+	tx             repositories.Transactor
+
+	// pinger проверяет доступность хранилища для health checking.
+	pinger healthcheck.Pinger
+}
+
+func newPostgresStorage(db *sql.DB, logger *zap.Logger) *storage {
+	return &storage{
+		users:          postgres.NewUserRepository(db, logger),
+		tokens:         postgres.NewTokenRepository(db, logger),
+		sessions:       postgres.NewSessionRepository(db, logger),
+		attempts:       postgres.NewAttemptRepository(db, logger),
+		factors:        postgres.NewSecondFactorRepository(db, logger),
+		resets:         postgres.NewPasswordResetRepository(db, logger),
+		suspensions:    postgres.NewSuspensionRepository(db, logger),
+		oauth:          postgres.NewOAuthRepository(db, logger),
+		identities:     postgres.NewExternalIdentityRepository(db, logger),
+		personalTokens: postgres.NewPersonalTokenRepository(db, logger),
+		audit:          postgres.NewAuditRepository(db, logger),
+		userEvents:     postgres.NewUserEventRepository(db, logger),
+		tx:             postgres.NewTransactor(db),
+		pinger:         db,
+	}
+}
+
+func newMemoryStorage() *storage {
+	store := memory.NewStore()
+	return &storage{
+		users:          memory.NewUserRepository(store),
+		tokens:         memory.NewTokenRepository(store),
+		sessions:       memory.NewSessionRepository(store),
+		attempts:       memory.NewAttemptRepository(store),
+		factors:        memory.NewSecondFactorRepository(store),
+		resets:         memory.NewPasswordResetRepository(store),
+		suspensions:    memory.NewSuspensionRepository(store),
+		oauth:          memory.NewOAuthRepository(store),
+		identities:     memory.NewExternalIdentityRepository(store),
+		personalTokens: memory.NewPersonalTokenRepository(store),
+		audit:          memory.NewAuditRepository(store),
+		userEvents:     memory.NewUserEventRepository(store),
+		tx:             store,
+		pinger:         store,
+	}
+}
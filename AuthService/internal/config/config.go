@@ -1,25 +1,94 @@
 package config
 
 import (
+	"AuthService/pkg/password"
+	"AuthService/pkg/proxy"
+	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// minRefreshSecretLength - минимальная длина RefreshSecret вне разработки,
+// 32 байта соответствуют размеру выхода HMAC-SHA256.
+const minRefreshSecretLength = 32
+
 type Config struct {
-	AppEnv        string
-	ServerPort    string
-	GRPCPort      string
-	DBHost        string
-	DBPort        string
-	DBUser        string
-	DBPass        string
-	DBName        string
-	DBSSLMode     string
-	AccessSecret  string
-	RefreshSecret string
-	AccessTTL     time.Duration
-	RefreshTTL    time.Duration
+	AppEnv         string
+	ServerPort     string
+	GRPCPort       string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPass         string
+	DBName         string
+	DBSSLMode      string
+	JWTKeysDir     string
+	JWTActiveKeyID string
+	JWTIssuer      string
+	JWTAudience    string
+	RefreshSecret  string
+	AccessTTL      time.Duration
+	RefreshTTL     time.Duration
+
+	LoginMaxAttempts int
+	LoginBackoffBase time.Duration
+	LoginLockout     time.Duration
+
+	TOTPIssuer      string
+	MFAChallengeTTL time.Duration
+
+	PasswordMinLength  int
+	PasswordBannedList string
+	UsernameMinLength  int
+	UsernameMaxLength  int
+	ReservedUsernames  []string
+
+	PasswordHasher    string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+
+	Notifier     string
+	NotifierFile string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	GRPCReflection      bool
+	HealthCheckInterval time.Duration
+	ShutdownTimeout     time.Duration
+
+	MigrateOnStart bool
+
+	OAuthCodeTTL    time.Duration
+	OAuthConsentURL string
+
+	OIDCProvidersFile string
+	OIDCStateTTL      time.Duration
+
+	PersonalTokenDefaultTTL time.Duration
+	PersonalTokenMaxTTL     time.Duration
+
+	AuditRetention     time.Duration
+	AuditPruneInterval time.Duration
+
+	UserEventPollInterval time.Duration
+
+	StorageDriver string
+
+	TrustedProxies proxy.Trusted
 }
 
 func Load() (*Config, error) {
@@ -33,20 +102,232 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	loginMaxAttempts, err := strconv.Atoi(getEnv("LoginMaxAttempts", "5"))
+	if err != nil {
+		return nil, err
+	}
+
+	loginBackoffBase, err := time.ParseDuration(getEnv("LoginBackoffBase", "1s"))
+	if err != nil {
+		return nil, err
+	}
+
+	loginLockout, err := time.ParseDuration(getEnv("LoginLockout", "15m"))
+	if err != nil {
+		return nil, err
+	}
+
+	mfaChallengeTTL, err := time.ParseDuration(getEnv("MFAChallengeTTL", "5m"))
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinLength, err := strconv.Atoi(getEnv("PasswordMinLength", "8"))
+	if err != nil {
+		return nil, err
+	}
+
+	usernameMinLength, err := strconv.Atoi(getEnv("UsernameMinLength", "3"))
+	if err != nil {
+		return nil, err
+	}
+
+	usernameMaxLength, err := strconv.Atoi(getEnv("UsernameMaxLength", "32"))
+	if err != nil {
+		return nil, err
+	}
+
+	bcryptCost, err := strconv.Atoi(getEnv("BcryptCost", "10"))
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Memory, err := strconv.ParseUint(getEnv("Argon2Memory", "19456"), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Iterations, err := strconv.ParseUint(getEnv("Argon2Iterations", "2"), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Parallelism, err := strconv.ParseUint(getEnv("Argon2Parallelism", "1"), 10, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Params := password.DefaultArgon2Params
+	argon2Params.Memory = uint32(argon2Memory)
+	argon2Params.Iterations = uint32(argon2Iterations)
+	argon2Params.Parallelism = uint8(argon2Parallelism)
+	if err := argon2Params.Validate(); err != nil {
+		return nil, err
+	}
+
+	passwordResetTTL, err := time.ParseDuration(getEnv("PasswordResetTTL", "30m"))
+	if err != nil {
+		return nil, err
+	}
+
+	emailVerificationTTL, err := time.ParseDuration(getEnv("EmailVerificationTTL", "24h"))
+	if err != nil {
+		return nil, err
+	}
+
+	grpcReflection, err := strconv.ParseBool(getEnv("GRPCReflection", "false"))
+	if err != nil {
+		return nil, err
+	}
+
+	healthCheckInterval, err := time.ParseDuration(getEnv("HealthCheckInterval", "10s"))
+	if err != nil {
+		return nil, err
+	}
+	if healthCheckInterval <= 0 {
+		return nil, fmt.Errorf("HealthCheckInterval must be positive, got %s", healthCheckInterval)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("ShutdownTimeout", "15s"))
+	if err != nil {
+		return nil, err
+	}
+
+	migrateOnStart, err := strconv.ParseBool(getEnv("MigrateOnStart", "false"))
+	if err != nil {
+		return nil, err
+	}
+
+	oauthCodeTTL, err := time.ParseDuration(getEnv("OAuthCodeTTL", "1m"))
+	if err != nil {
+		return nil, err
+	}
+
+	oidcStateTTL, err := time.ParseDuration(getEnv("OIDCStateTTL", "10m"))
+	if err != nil {
+		return nil, err
+	}
+
+	personalTokenDefaultTTL, err := time.ParseDuration(getEnv("PersonalTokenDefaultTTL", "720h"))
+	if err != nil {
+		return nil, err
+	}
+
+	personalTokenMaxTTL, err := time.ParseDuration(getEnv("PersonalTokenMaxTTL", "8760h"))
+	if err != nil {
+		return nil, err
+	}
+
+	auditRetention, err := time.ParseDuration(getEnv("AuditRetention", "2160h"))
+	if err != nil {
+		return nil, err
+	}
+
+	auditPruneInterval, err := time.ParseDuration(getEnv("AuditPruneInterval", "1h"))
+	if err != nil {
+		return nil, err
+	}
+
+	userEventPollInterval, err := time.ParseDuration(getEnv("UserEventPollInterval", "2s"))
+	if err != nil {
+		return nil, err
+	}
+	if userEventPollInterval <= 0 {
+		return nil, fmt.Errorf("UserEventPollInterval must be positive, got %s", userEventPollInterval)
+	}
+
+	storageDriver := getEnv("StorageDriver", "postgres")
+	if storageDriver != "postgres" && storageDriver != "memory" {
+		return nil, fmt.Errorf("unknown StorageDriver %q, expected postgres or memory", storageDriver)
+	}
+
+	// RefreshSecret подписывает refresh-токены, токены подтверждения почты и
+	// MFA-челленджи: вне разработки пустой или короткий ключ недопустим.
+	appEnv := getEnv("AppEnv", "development")
+	refreshSecret := getEnv("RefreshSecret", "")
+	if appEnv != "development" && len(refreshSecret) < minRefreshSecretLength {
+		return nil, fmt.Errorf("RefreshSecret must be at least %d bytes outside development", minRefreshSecretLength)
+	}
+
+	trustedProxies, err := proxy.ParseTrusted(getEnv("TrustedProxies", "127.0.0.1,::1"))
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
-		AppEnv:        getEnv("AppEnv", "development"),
-		ServerPort:    getEnv("ServerPort", "8081"),
-		GRPCPort:      getEnv("GRPCPort", ""),
-		DBHost:        getEnv("DBHost", "localhost"),
-		DBPort:        getEnv("DBPort", "5432"),
-		DBUser:        getEnv("DBUser", "postgres"),
-		DBPass:        getEnv("DBPass", ""),
-		DBName:        getEnv("DBName", "userDB"),
-		DBSSLMode:     getEnv("DBSSLMode", ""),
-		AccessSecret:  getEnv("AccessSecret", ""),
-		RefreshSecret: getEnv("RefreshSecret", ""),
-		AccessTTL:     accessTTL,
-		RefreshTTL:    refreshTTL,
+		AppEnv:         appEnv,
+		ServerPort:     getEnv("ServerPort", "8081"),
+		GRPCPort:       getEnv("GRPCPort", ""),
+		DBHost:         getEnv("DBHost", "localhost"),
+		DBPort:         getEnv("DBPort", "5432"),
+		DBUser:         getEnv("DBUser", "postgres"),
+		DBPass:         getEnv("DBPass", ""),
+		DBName:         getEnv("DBName", "userDB"),
+		DBSSLMode:      getEnv("DBSSLMode", ""),
+		JWTKeysDir:     getEnv("JWTKeysDir", "keys"),
+		JWTActiveKeyID: getEnv("JWTActiveKeyID", ""),
+		JWTIssuer:      getEnv("JWTIssuer", "auth-service"),
+		JWTAudience:    getEnv("JWTAudience", "go-forum"),
+		RefreshSecret:  refreshSecret,
+		AccessTTL:      accessTTL,
+		RefreshTTL:     refreshTTL,
+
+		LoginMaxAttempts: loginMaxAttempts,
+		LoginBackoffBase: loginBackoffBase,
+		LoginLockout:     loginLockout,
+
+		TOTPIssuer:      getEnv("TOTPIssuer", "GO-FORUM"),
+		MFAChallengeTTL: mfaChallengeTTL,
+
+		PasswordMinLength:  passwordMinLength,
+		PasswordBannedList: getEnv("PasswordBannedList", ""),
+		UsernameMinLength:  usernameMinLength,
+		UsernameMaxLength:  usernameMaxLength,
+		ReservedUsernames:  strings.Split(getEnv("ReservedUsernames", "admin,administrator,root,system,moderator,support"), ","),
+
+		PasswordHasher:    getEnv("PasswordHasher", "argon2id"),
+		BcryptCost:        bcryptCost,
+		Argon2Memory:      uint32(argon2Memory),
+		Argon2Iterations:  uint32(argon2Iterations),
+		Argon2Parallelism: uint8(argon2Parallelism),
+
+		PasswordResetTTL: passwordResetTTL,
+		PasswordResetURL: getEnv("PasswordResetURL", "http://localhost:8081/reset-password"),
+
+		EmailVerificationTTL: emailVerificationTTL,
+		EmailVerificationURL: getEnv("EmailVerificationURL", "http://localhost:8081/verify-email"),
+
+		Notifier:     getEnv("Notifier", "log"),
+		NotifierFile: getEnv("NotifierFile", "mail.log"),
+		SMTPHost:     getEnv("SMTPHost", "localhost"),
+		SMTPPort:     getEnv("SMTPPort", "25"),
+		SMTPUsername: getEnv("SMTPUsername", ""),
+		SMTPPassword: getEnv("SMTPPassword", ""),
+		SMTPFrom:     getEnv("SMTPFrom", "noreply@go-forum.local"),
+
+		GRPCReflection:      grpcReflection,
+		HealthCheckInterval: healthCheckInterval,
+		ShutdownTimeout:     shutdownTimeout,
+
+		MigrateOnStart: migrateOnStart,
+
+		OAuthCodeTTL:    oauthCodeTTL,
+		OAuthConsentURL: getEnv("OAuthConsentURL", ""),
+
+		OIDCProvidersFile: getEnv("OIDCProvidersFile", ""),
+		OIDCStateTTL:      oidcStateTTL,
+
+		PersonalTokenDefaultTTL: personalTokenDefaultTTL,
+		PersonalTokenMaxTTL:     personalTokenMaxTTL,
+
+		AuditRetention:     auditRetention,
+		AuditPruneInterval: auditPruneInterval,
+
+		UserEventPollInterval: userEventPollInterval,
+
+		StorageDriver: storageDriver,
+
+		TrustedProxies: trustedProxies,
 	}, nil
 }
 func getEnv(key, defaultValue string) string {
@@ -1,10 +1,120 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	UserNotFound      = errors.New("user not found")
 	UserAlreadyExists = errors.New("user already exists")
 	InvalidToken      = errors.New("invalid token")
 	InvalidData       = errors.New("invalid data")
+	TokenNotFound     = errors.New("token not found")
+	TokenReused       = errors.New("refresh token reuse detected")
+	InvalidRole       = errors.New("invalid role")
+	TooManyAttempts   = errors.New("too many login attempts")
+	TooManyResets     = errors.New("too many password reset requests")
+
+	SecondFactorNotFound       = errors.New("second factor not enrolled")
+	SecondFactorAlreadyEnabled = errors.New("second factor already enabled")
+	InvalidSecondFactor        = errors.New("invalid second factor code")
+
+	EmailAlreadyExists   = errors.New("email already in use")
+	EmailNotSet          = errors.New("email address is not set")
+	EmailAlreadyVerified = errors.New("email address already verified")
+
+	SuspensionNotFound = errors.New("suspension not found")
+	AccountSuspended   = errors.New("account suspended")
+
+	SessionNotFound = errors.New("session not found")
+	SessionRevoked  = errors.New("session revoked")
+
+	OAuthClientNotFound  = errors.New("oauth client not found")
+	OAuthConsentNotFound = errors.New("oauth consent not found")
+	OAuthCodeNotFound    = errors.New("authorization code not found")
+	OAuthCodeUsed        = errors.New("authorization code already used")
+	OAuthConsentDisabled = errors.New("oauth consent page is not configured")
+
+	ExternalProviderNotFound   = errors.New("identity provider not found")
+	ExternalIdentityNotFound   = errors.New("external identity not found")
+	ExternalIdentityExists     = errors.New("external identity already linked")
+	ExternalLoginStateNotFound = errors.New("external login state not found")
+	ExternalLoginFailed        = errors.New("external login failed")
+
+	PersonalTokenNotFound = errors.New("personal access token not found")
 )
+
+// Коды ошибок OAuth2 (RFC 6749, разделы 4.1.2.1 и 5.2).
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
+)
+
+// OAuthError - ошибка протокола OAuth2, которую транспорт отдает клиенту
+// как есть: Code попадает в параметр error, Description - в
+// error_description. Redirect означает, что client_id и redirect_uri
+// проверены и ошибку можно вернуть клиенту через redirect_uri.
+type OAuthError struct {
+	Code        string
+	Description string
+	Redirect    bool
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// ValidationError перечисляет машиночитаемые причины, по которым
+// данные не прошли проверку. Совместима с errors.Is(err, InvalidData).
+type ValidationError struct {
+	Reasons []string
+}
+
+func (e *ValidationError) Error() string {
+	return InvalidData.Error() + ": " + strings.Join(e.Reasons, ",")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return InvalidData
+}
+
+// RetryAfterError сообщает, через сколько можно повторить запрос.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// SuspendedError сообщает, что аккаунт заблокирован. Until == nil
+// означает бессрочный бан. Совместима с errors.Is(err, AccountSuspended).
+type SuspendedError struct {
+	Reason string
+	Until  *time.Time
+}
+
+func (e *SuspendedError) Error() string {
+	if e.Until == nil {
+		return fmt.Sprintf("account banned: %s", e.Reason)
+	}
+	return fmt.Sprintf("account suspended until %s: %s", e.Until.UTC().Format(time.RFC3339), e.Reason)
+}
+
+func (e *SuspendedError) Unwrap() error {
+	return AccountSuspended
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// AuditEventType - вид события в журнале аудита.
+type AuditEventType string
+
+const (
+	AuditRegister        AuditEventType = "register"
+	AuditLoginSucceeded  AuditEventType = "login_succeeded"
+	AuditLoginFailed     AuditEventType = "login_failed"
+	AuditRefresh         AuditEventType = "refresh"
+	AuditLogout          AuditEventType = "logout"
+	AuditPasswordChanged AuditEventType = "password_changed"
+	AuditRoleGranted     AuditEventType = "role_granted"
+	AuditRoleRevoked     AuditEventType = "role_revoked"
+	AuditUserSuspended   AuditEventType = "user_suspended"
+	AuditUserUnsuspended AuditEventType = "user_unsuspended"
+)
+
+var auditEventTypes = map[AuditEventType]bool{
+	AuditRegister:        true,
+	AuditLoginSucceeded:  true,
+	AuditLoginFailed:     true,
+	AuditRefresh:         true,
+	AuditLogout:          true,
+	AuditPasswordChanged: true,
+	AuditRoleGranted:     true,
+	AuditRoleRevoked:     true,
+	AuditUserSuspended:   true,
+	AuditUserUnsuspended: true,
+}
+
+func (t AuditEventType) Valid() bool {
+	return auditEventTypes[t]
+}
+
+// AuditEvent - запись журнала аудита. Записи только добавляются и
+// удаляются по истечении срока хранения.
+type AuditEvent struct {
+	ID   int64
+	Type AuditEventType
+	// UserID - пользователь, к которому относится событие, 0 если он не
+	// определен (например, вход под несуществующим именем).
+	UserID int
+	// ActorID - администратор, выполнивший действие над UserID, 0 если
+	// действие выполнил сам пользователь.
+	ActorID   int
+	IP        string
+	UserAgent string
+	RequestID string
+	Details   map[string]string
+	CreatedAt time.Time
+}
+
+// AuditFilter отбирает записи журнала. Нулевые поля не ограничивают
+// выборку. Записи возвращаются от новых к старым, начиная с id меньше
+// BeforeID.
+type AuditFilter struct {
+	UserID   int
+	Types    []AuditEventType
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
@@ -0,0 +1,10 @@
+package models
+
+// ClientInfo описывает клиента, от имени которого пришел запрос.
+// Заполняется транспортным слоем из метаданных запроса.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	// RequestID связывает записи журнала аудита с логами запроса.
+	RequestID string
+}
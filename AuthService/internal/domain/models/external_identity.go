@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ExternalIdentity связывает пользователя с subject внешнего OpenID
+// Connect провайдера. Email - адрес, который провайдер сообщил при
+// последнем входе.
+type ExternalIdentity struct {
+	Provider    string
+	Subject     string
+	UserID      int
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
+
+// ExternalLoginState - начатый, но еще не завершенный вход через
+// провайдера. Хранится только хэш state.
+type ExternalLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// ExternalLoginStart - адрес провайдера, на который нужно направить
+// браузер, и state, который фронтенд должен сверить с пришедшим в
+// callback.
+type ExternalLoginStart struct {
+	AuthorizationURL string
+	State            string
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Scope ограничивает, что клиент OAuth может делать от имени пользователя.
+type Scope string
+
+const (
+	ScopeProfileRead   Scope = "profile:read"
+	ScopeTopicsWrite   Scope = "topics:write"
+	ScopeCommentsWrite Scope = "comments:write"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeProfileRead, ScopeTopicsWrite, ScopeCommentsWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseScopes разбирает список scope, разделенный пробелами, как в
+// параметре scope OAuth2.
+func ParseScopes(raw string) []string {
+	return strings.Fields(raw)
+}
+
+// ContainsScopes сообщает, входят ли все scopes в granted.
+func ContainsScopes(granted, scopes []string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthClient - стороннее приложение, зарегистрированное для входа
+// через OAuth2. У публичного клиента (мобильное приложение, SPA) нет
+// секрета, и он защищен только PKCE.
+type OAuthClient struct {
+	ID           string
+	SecretHash   string
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	OwnerID      int
+	CreatedAt    time.Time
+}
+
+func (c *OAuthClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthConsent - scopes, на которые пользователь уже дал согласие
+// клиенту. Повторный запрос тех же scopes не показывает экран согласия.
+type OAuthConsent struct {
+	UserID    int
+	ClientID  string
+	Scopes    []string
+	GrantedAt time.Time
+}
+
+// AuthorizationCode - одноразовый код авторизации. Хранится только
+// хеш кода; SessionID заполняется при обмене на токены.
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	SessionID     string
+	CreatedAt     time.Time
+}
+
+// AuthorizeRequest - параметры запроса к authorize endpoint.
+// Поддерживается только response_type=code с PKCE S256.
+type AuthorizeRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// ConsentDecision - ответ пользователя на экране согласия.
+type ConsentDecision int
+
+const (
+	// ConsentPending - пользователь еще не видел экран согласия.
+	ConsentPending ConsentDecision = iota
+	ConsentApproved
+	ConsentDenied
+)
+
+// AuthorizeResult содержит либо код авторизации, либо признак, что
+// пользователь должен подтвердить доступ клиента к Scopes.
+type AuthorizeResult struct {
+	Client          *OAuthClient
+	Scopes          []string
+	ConsentRequired bool
+	Code            string
+}
+
+// TokenRequest - параметры запроса к token endpoint.
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+}
+
+type OAuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+	Scopes       []string
+}
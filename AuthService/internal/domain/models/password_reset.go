@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PasswordReset хранит только хэш токена сброса, сам токен
+// известен лишь получателю письма.
+type PasswordReset struct {
+	TokenHash string
+	UserID    int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (r *PasswordReset) Active(now time.Time) bool {
+	return r.UsedAt == nil && now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PersonalAccessToken - долгоживущий токен для скриптов и ботов. Как и
+// токен клиента OAuth, он ограничен scopes. Хранится только хэш токена.
+type PersonalAccessToken struct {
+	ID         string
+	UserID     int
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (t *PersonalAccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Profile содержит публичные данные пользователя.
+type Profile struct {
+	UserID      int
+	Username    string
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	CreatedAt   time.Time
+	LastSeen    *time.Time
+}
+
+// ProfileUpdate описывает частичное обновление профиля: поля со
+// значением nil не меняются.
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	AvatarURL   *string
+}
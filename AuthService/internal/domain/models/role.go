@@ -0,0 +1,27 @@
+package models
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+func HasRole(roles []string, role Role) bool {
+	for _, r := range roles {
+		if r == string(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type TOTP struct {
+	UserID       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+func (t *TOTP) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// LoginResult содержит либо пару токенов, либо challenge токен, если
+// для входа нужен второй фактор.
+type LoginResult struct {
+	Tokens         *TokenPair
+	ChallengeToken string
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session соответствует одному входу с конкретного устройства. Все
+// refresh токены, полученные ротацией после входа, относятся к ней.
+type Session struct {
+	ID         string
+	UserID     int
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+	// ClientID задан у сессий, открытых клиентом OAuth.
+	ClientID string
+}
+
+func (s *Session) Active() bool {
+	return s.RevokedAt == nil
+}
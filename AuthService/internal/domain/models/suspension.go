@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Suspension описывает блокировку пользователя. Until == nil означает
+// бессрочный бан.
+type Suspension struct {
+	UserID      int
+	Reason      string
+	Until       *time.Time
+	SuspendedBy int
+	CreatedAt   time.Time
+}
+
+func (s *Suspension) Permanent() bool {
+	return s.Until == nil
+}
+
+func (s *Suspension) Active(now time.Time) bool {
+	return s.Permanent() || now.Before(*s.Until)
+}
@@ -1,11 +1,87 @@
 package models
 
+import "time"
+
 type TokenPair struct {
 	AccessToken  string
 	RefreshToken string
 }
 
+// CredentialKind - вид учетных данных, предъявленных в Introspect.
+type CredentialKind string
+
+const (
+	CredentialAccessToken      CredentialKind = "access_token"
+	CredentialOAuthAccessToken CredentialKind = "oauth_access_token"
+	CredentialPersonalToken    CredentialKind = "personal_access_token"
+)
+
 type TokenClaims struct {
-	UserID   int
-	Username string
+	ID            string
+	Type          string
+	UserID        int
+	Username      string
+	Roles         []string
+	Email         string
+	EmailVerified bool
+	SessionID     string
+	// ClientID и Scopes заданы у токенов, выданных клиенту OAuth. Токены
+	// первого лица (вход по паролю) не ограничены scopes.
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	// Credential заполняется Introspect.
+	Credential CredentialKind
+}
+
+// Scoped сообщает, ограничены ли права владельца токена scopes: это
+// токены клиентов OAuth и personal access токены.
+func (c *TokenClaims) Scoped() bool {
+	return c.ClientID != "" || c.Credential == CredentialPersonalToken
+}
+
+// InactiveReason - машиночитаемая причина, по которой токен не активен.
+type InactiveReason string
+
+const (
+	// TokenMalformed: токен не разобран, подпись или обязательные claims
+	// не прошли проверку, либо токен неизвестен.
+	TokenMalformed InactiveReason = "malformed"
+	TokenExpired   InactiveReason = "expired"
+	// TokenRevoked: отозвана сессия токена или сам personal access токен.
+	TokenRevoked InactiveReason = "revoked"
+	// TokenWrongType: предъявлен не access токен, например refresh.
+	TokenWrongType   InactiveReason = "wrong_type"
+	TokenOwnerBanned InactiveReason = "account_suspended"
+)
+
+// Introspection - результат проверки токена в духе RFC 7662. Claims
+// заданы только у активного токена, Reason - только у неактивного.
+// Suspension задана, если владелец токена заблокирован.
+type Introspection struct {
+	Active     bool
+	Claims     *TokenClaims
+	Reason     InactiveReason
+	Suspension *Suspension
+}
+
+// RefreshToken принадлежит сессии: FamilyID совпадает с ID сессии,
+// в рамках которой токен был выпущен и ротирован.
+type RefreshToken struct {
+	ID         string
+	FamilyID   string
+	UserID     int
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+	CreatedAt  time.Time
+}
+
+func (t *RefreshToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+func (t *RefreshToken) Rotated() bool {
+	return t.RevokedAt != nil && t.ReplacedBy != ""
 }
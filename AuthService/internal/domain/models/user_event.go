@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// UserEventType - вид события жизненного цикла пользователя, которое
+// рассылается другим сервисам.
+type UserEventType string
+
+const (
+	UserCreated     UserEventType = "user_created"
+	UserSuspended   UserEventType = "user_suspended"
+	UserUnsuspended UserEventType = "user_unsuspended"
+	ProfileUpdated  UserEventType = "profile_updated"
+	RolesChanged    UserEventType = "roles_changed"
+)
+
+var userEventTypes = map[UserEventType]bool{
+	UserCreated:     true,
+	UserSuspended:   true,
+	UserUnsuspended: true,
+	ProfileUpdated:  true,
+	RolesChanged:    true,
+}
+
+func (t UserEventType) Valid() bool {
+	return userEventTypes[t]
+}
+
+// UserEvent - запись ленты событий пользователей. ID монотонно растет и
+// служит курсором, с которого подписчик продолжает чтение.
+type UserEvent struct {
+	ID        int64
+	Type      UserEventType
+	UserID    int
+	Data      map[string]string
+	CreatedAt time.Time
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+	"time"
+)
+
+type AuditRepo interface {
+	Append(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error)
+	// DeleteBefore удаляет записи старше before и возвращает их число.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type ExternalIdentityRepo interface {
+	Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error)
+	// Create возвращает ExternalIdentityExists, если subject уже привязан.
+	Create(ctx context.Context, identity *models.ExternalIdentity) error
+	TouchLogin(ctx context.Context, provider, subject, email string) error
+
+	SaveState(ctx context.Context, state *models.ExternalLoginState) error
+	// ConsumeState удаляет state и возвращает его, так что каждый state
+	// можно использовать только один раз.
+	ConsumeState(ctx context.Context, stateHash string) (*models.ExternalLoginState, error)
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type OAuthRepo interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	FindClient(ctx context.Context, id string) (*models.OAuthClient, error)
+	// HasClients сообщает, зарегистрирован ли хотя бы один клиент.
+	HasClients(ctx context.Context) (bool, error)
+
+	FindConsent(ctx context.Context, userID int, clientID string) (*models.OAuthConsent, error)
+	// SaveConsent добавляет scopes к уже выданному согласию.
+	SaveConsent(ctx context.Context, consent *models.OAuthConsent) error
+
+	CreateCode(ctx context.Context, code *models.AuthorizationCode) error
+	// ConsumeCode помечает код использованным и привязывает к нему
+	// sessionID. Если код уже был использован, возвращает его вместе с
+	// OAuthCodeUsed, чтобы можно было отозвать выданные по нему токены.
+	ConsumeCode(ctx context.Context, codeHash, sessionID string) (*models.AuthorizationCode, error)
+}
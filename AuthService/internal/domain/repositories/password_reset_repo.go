@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type PasswordResetRepo interface {
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	Use(ctx context.Context, tokenHash string) error
+	DeleteForUser(ctx context.Context, userID int) error
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type PersonalTokenRepo interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	// ListByUser возвращает неотозванные токены пользователя, включая
+	// истекшие.
+	ListByUser(ctx context.Context, userID int) ([]*models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID int, id string) error
+	TouchLastUsed(ctx context.Context, id string) error
+}
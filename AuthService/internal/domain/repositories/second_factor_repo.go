@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+	"time"
+)
+
+type SecondFactorRepo interface {
+	FindTOTP(ctx context.Context, userID int) (*models.TOTP, error)
+	SaveTOTP(ctx context.Context, userID int, secret string) error
+	ConfirmTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	MarkTOTPUsed(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// ConsumeChallenge отмечает challenge токен id использованным и
+	// возвращает false, если он уже был использован. Запись хранится до
+	// expiresAt.
+	ConsumeChallenge(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
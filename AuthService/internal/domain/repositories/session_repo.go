@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type SessionRepo interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id string) (*models.Session, error)
+	// ListActive возвращает неотозванные сессии, у которых остался
+	// действующий refresh токен.
+	ListActive(ctx context.Context, userID int) ([]*models.Session, error)
+	Touch(ctx context.Context, id string, client models.ClientInfo) error
+	// Revoke и RevokeAll отзывают сессии вместе с их refresh токенами.
+	Revoke(ctx context.Context, userID int, id string) error
+	RevokeAll(ctx context.Context, userID int, exceptID string) (int, error)
+}
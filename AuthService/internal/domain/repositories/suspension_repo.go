@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type SuspensionRepo interface {
+	Find(ctx context.Context, userID int) (*models.Suspension, error)
+	Save(ctx context.Context, suspension *models.Suspension) error
+	Delete(ctx context.Context, userID int) error
+}
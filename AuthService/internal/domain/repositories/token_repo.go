@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type TokenRepo interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	FindByID(ctx context.Context, id string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, id string) error
+	Rotate(ctx context.Context, oldID string, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID int) error
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import "context"
+
+// Transactor выполняет операции нескольких репозиториев атомарно.
+type Transactor interface {
+	// WithinTx вызывает fn в транзакции. Репозитории, которым передан ctx
+	// из fn, выполняют запросы в ней. Если fn возвращает ошибку, все
+	// изменения откатываются. Вложенный вызов использует внешнюю
+	// транзакцию.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
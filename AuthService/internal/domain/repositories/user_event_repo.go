@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+)
+
+type UserEventRepo interface {
+	Append(ctx context.Context, event *models.UserEvent) error
+	// ListAfter возвращает до limit событий с id больше afterID по
+	// возрастанию id.
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]*models.UserEvent, error)
+	// LastID возвращает id последнего события или 0, если событий нет.
+	LastID(ctx context.Context) (int64, error)
+}
@@ -9,4 +9,14 @@ type UserRepo interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
 	FindByID(ctx context.Context, id int) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, userID int, email string) (bool, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	Roles(ctx context.Context, userID int) ([]models.Role, error)
+	GrantRole(ctx context.Context, userID int, role models.Role) error
+	RevokeRole(ctx context.Context, userID int, role models.Role) error
+	Profiles(ctx context.Context, ids []int, usernames []string) ([]*models.Profile, error)
+	SearchProfiles(ctx context.Context, query string, afterID, limit int) ([]*models.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (*models.Profile, error)
+	TouchLastSeen(ctx context.Context, userID int) error
 }
@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"AuthService/internal/domain/models"
 	"AuthService/internal/usecases"
 	"AuthService/pkg/grpc/auth"
 	"context"
@@ -20,7 +21,7 @@ func New(authService usecases.AuthService) AuthServer {
 }
 
 func (s *AuthServer) Register(ctx context.Context, req *auth.RegisterRequest) (*auth.RegisterResponse, error) {
-	err := s.authService.Register(ctx, req.Username, req.Password)
+	err := s.authService.Register(ctx, req.Username, req.Password, req.Email, models.ClientInfo{})
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -28,18 +29,24 @@ func (s *AuthServer) Register(ctx context.Context, req *auth.RegisterRequest) (*
 }
 
 func (s *AuthServer) Login(ctx context.Context, req *auth.LoginRequest) (*auth.LoginResponse, error) {
-	tokens, err := s.authService.Login(ctx, req.Username, req.Password)
+	result, err := s.authService.Login(ctx, req.Username, req.Password, models.ClientInfo{})
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
+	if result.ChallengeToken != "" {
+		return &auth.LoginResponse{
+			SecondFactorRequired: true,
+			ChallengeToken:       result.ChallengeToken,
+		}, nil
+	}
 	return &auth.LoginResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
 	}, nil
 }
 
 func (s *AuthServer) Refresh(ctx context.Context, req *auth.RefreshRequest) (*auth.RefreshResponse, error) {
-	tokens, err := s.authService.Refresh(ctx, req.RefreshToken)
+	tokens, err := s.authService.Refresh(ctx, req.RefreshToken, models.ClientInfo{})
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
@@ -50,7 +57,7 @@ func (s *AuthServer) Refresh(ctx context.Context, req *auth.RefreshRequest) (*au
 }
 
 func (s *AuthServer) VerifyToken(ctx context.Context, req *auth.VerifyTokenRequest) (*auth.VerifyTokenResponse, error) {
-	claims, err := s.authService.VerifyToken(req.Token)
+	claims, err := s.authService.VerifyToken(ctx, req.Token)
 	if err != nil {
 		return &auth.VerifyTokenResponse{
 			Valid: false,
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type attempt struct {
+	failures    int
+	updatedAt   time.Time
+	lockedUntil time.Time
+}
+
+type AttemptRepository struct {
+	store *Store
+}
+
+func NewAttemptRepository(store *Store) repositories.AttemptRepo {
+	return &AttemptRepository{store: store}
+}
+
+func (r *AttemptRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if a, ok := r.store.attempts[key]; ok {
+		return a.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+// RegisterFailure начинает счет заново, если с прошлой неудачи прошло
+// больше window.
+func (r *AttemptRepository) RegisterFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	a, ok := r.store.attempts[key]
+	if !ok {
+		a = &attempt{}
+		r.store.attempts[key] = a
+	}
+	if a.updatedAt.Before(now.Add(-window)) {
+		a.failures = 0
+	}
+	a.failures++
+	a.updatedAt = now
+	return a.failures, nil
+}
+
+func (r *AttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if a, ok := r.store.attempts[key]; ok {
+		a.lockedUntil = until
+	}
+	return nil
+}
+
+func (r *AttemptRepository) Reset(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.attempts, key)
+	return nil
+}
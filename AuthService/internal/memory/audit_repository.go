@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type AuditRepository struct {
+	store *Store
+}
+
+func NewAuditRepository(store *Store) repositories.AuditRepo {
+	return &AuditRepository{store: store}
+}
+
+func copyAuditEvent(event *models.AuditEvent) *models.AuditEvent {
+	c := *event
+	c.Details = cloneMap(event.Details)
+	return &c
+}
+
+func (r *AuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.nextAuditID++
+	event.ID = r.store.nextAuditID
+	event.CreatedAt = time.Now()
+	r.store.auditEvents = append(r.store.auditEvents, copyAuditEvent(event))
+	return nil
+}
+
+// List возвращает записи от новых к старым, как ORDER BY id DESC.
+func (r *AuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	types := make(map[models.AuditEventType]bool, len(filter.Types))
+	for _, eventType := range filter.Types {
+		types[eventType] = true
+	}
+
+	var events []*models.AuditEvent
+	for i := len(r.store.auditEvents) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		event := r.store.auditEvents[i]
+		switch {
+		case filter.UserID != 0 && event.UserID != filter.UserID,
+			len(types) > 0 && !types[event.Type],
+			!filter.Since.IsZero() && event.CreatedAt.Before(filter.Since),
+			!filter.Until.IsZero() && !event.CreatedAt.Before(filter.Until),
+			filter.BeforeID != 0 && event.ID >= filter.BeforeID:
+			continue
+		}
+		events = append(events, copyAuditEvent(event))
+	}
+	return events, nil
+}
+
+func (r *AuditRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	kept := r.store.auditEvents[:0]
+	for _, event := range r.store.auditEvents {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(r.store.auditEvents) - len(kept))
+	r.store.auditEvents = kept
+	return deleted, nil
+}
@@ -0,0 +1,125 @@
+package memory_test
+
+import (
+	"AuthService/internal/config"
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"AuthService/internal/memory"
+	"AuthService/internal/usecases"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/notify"
+	"AuthService/pkg/password"
+	"AuthService/pkg/totp"
+
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAuthService(t *testing.T) (usecases.AuthService, repositories.AuditRepo) {
+	cfg := &config.Config{
+		JWTIssuer:             "auth-service",
+		JWTAudience:           "go-forum",
+		RefreshSecret:         "refresh-secret",
+		AccessTTL:             time.Minute,
+		RefreshTTL:            time.Hour,
+		MFAChallengeTTL:       time.Minute,
+		LoginMaxAttempts:      5,
+		LoginBackoffBase:      time.Millisecond,
+		LoginLockout:          time.Minute,
+		UsernameMinLength:     3,
+		UsernameMaxLength:     32,
+		UserEventPollInterval: time.Second,
+	}
+	keys, err := jwt.GenerateKeySet("k1", jwt.Policy{Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience})
+	require.NoError(t, err)
+	policy, err := password.NewPolicy(8, "")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	audit := memory.NewAuditRepository(store)
+	s := usecases.NewAuthService(users, memory.NewTokenRepository(store), memory.NewSessionRepository(store),
+		memory.NewAttemptRepository(store), memory.NewSecondFactorRepository(store), memory.NewPasswordResetRepository(store),
+		memory.NewSuspensionRepository(store), memory.NewOAuthRepository(store), memory.NewExternalIdentityRepository(store),
+		memory.NewPersonalTokenRepository(store), audit, memory.NewUserEventRepository(store), store,
+		keys, policy, password.NewHasher(password.NewBcrypt(4)), notify.NewLogNotifier(zap.NewNop()), nil, cfg, zap.NewNop())
+
+	return s, audit
+}
+
+// TestAuthFlow проходит регистрацию, вход, проверку токена, ротацию и
+// выход целиком на хранилище в памяти, без базы данных.
+func TestAuthFlow(t *testing.T) {
+	s, audit := newAuthService(t)
+	ctx := context.Background()
+	client := models.ClientInfo{IP: "10.0.0.1", UserAgent: "test"}
+
+	require.NoError(t, s.Register(ctx, "alice", "correct-horse", "", client))
+	assert.ErrorIs(t, s.Register(ctx, "alice", "correct-horse", "", client), domain.UserAlreadyExists)
+
+	result, err := s.Login(ctx, "alice", "correct-horse", client)
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+
+	claims, err := s.VerifyToken(ctx, result.Tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+
+	rotated, err := s.Refresh(ctx, result.Tokens.RefreshToken, client)
+	require.NoError(t, err)
+	// Повторное использование ротированного токена отзывает всю сессию.
+	_, err = s.Refresh(ctx, result.Tokens.RefreshToken, client)
+	assert.Error(t, err)
+	_, err = s.Refresh(ctx, rotated.RefreshToken, client)
+	assert.Error(t, err)
+
+	result, err = s.Login(ctx, "alice", "correct-horse", client)
+	require.NoError(t, err)
+	require.NoError(t, s.Logout(ctx, result.Tokens.RefreshToken, client))
+	_, err = s.VerifyToken(ctx, result.Tokens.AccessToken)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+
+	events, err := audit.List(ctx, models.AuditFilter{Types: []models.AuditEventType{models.AuditLoginSucceeded}, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+// TestSecondFactorFlow_ChallengeIsSingleUse проверяет, что challenge
+// токен нельзя обменять на сессию второй раз, даже с другим верным кодом.
+func TestSecondFactorFlow_ChallengeIsSingleUse(t *testing.T) {
+	s, _ := newAuthService(t)
+	ctx := context.Background()
+	client := models.ClientInfo{IP: "10.0.0.1", UserAgent: "test"}
+
+	require.NoError(t, s.Register(ctx, "alice", "correct-horse", "", client))
+	result, err := s.Login(ctx, "alice", "correct-horse", client)
+	require.NoError(t, err)
+	claims, err := s.VerifyToken(ctx, result.Tokens.AccessToken)
+	require.NoError(t, err)
+
+	enrollment, err := s.EnrollTOTP(ctx, claims.UserID)
+	require.NoError(t, err)
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := s.ConfirmTOTP(ctx, claims.UserID, code)
+	require.NoError(t, err)
+
+	result, err = s.Login(ctx, "alice", "correct-horse", client)
+	require.NoError(t, err)
+	require.Nil(t, result.Tokens)
+	require.NotEmpty(t, result.ChallengeToken)
+
+	tokens, err := s.VerifySecondFactor(ctx, result.ChallengeToken, recoveryCodes[0], client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	_, err = s.VerifySecondFactor(ctx, result.ChallengeToken, recoveryCodes[1], client)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+}
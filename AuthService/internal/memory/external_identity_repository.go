@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type identityKey struct {
+	provider string
+	subject  string
+}
+
+type ExternalIdentityRepository struct {
+	store *Store
+}
+
+func NewExternalIdentityRepository(store *Store) repositories.ExternalIdentityRepo {
+	return &ExternalIdentityRepository{store: store}
+}
+
+func (r *ExternalIdentityRepository) Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	identity, ok := r.store.identities[identityKey{provider: provider, subject: subject}]
+	if !ok {
+		return nil, domain.ExternalIdentityNotFound
+	}
+	c := *identity
+	return &c, nil
+}
+
+func (r *ExternalIdentityRepository) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := identityKey{provider: identity.Provider, subject: identity.Subject}
+	if _, ok := r.store.identities[key]; ok {
+		return domain.ExternalIdentityExists
+	}
+	now := time.Now()
+	identity.CreatedAt = now
+	identity.LastLoginAt = now
+	stored := *identity
+	r.store.identities[key] = &stored
+	onRollback(ctx, func() { delete(r.store.identities, key) })
+	return nil
+}
+
+func (r *ExternalIdentityRepository) TouchLogin(ctx context.Context, provider, subject, email string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	identity, ok := r.store.identities[identityKey{provider: provider, subject: subject}]
+	if !ok {
+		return domain.ExternalIdentityNotFound
+	}
+	identity.LastLoginAt = time.Now()
+	if email != "" {
+		identity.Email = email
+	}
+	return nil
+}
+
+func (r *ExternalIdentityRepository) SaveState(ctx context.Context, state *models.ExternalLoginState) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	state.CreatedAt = time.Now()
+	stored := *state
+	r.store.loginStates[state.StateHash] = &stored
+	return nil
+}
+
+// ConsumeState заодно удаляет истекшие state брошенных попыток входа.
+func (r *ExternalIdentityRepository) ConsumeState(ctx context.Context, stateHash string) (*models.ExternalLoginState, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	state, ok := r.store.loginStates[stateHash]
+	if !ok {
+		return nil, domain.ExternalLoginStateNotFound
+	}
+	delete(r.store.loginStates, stateHash)
+
+	now := time.Now()
+	for hash, other := range r.store.loginStates {
+		if other.ExpiresAt.Before(now) {
+			delete(r.store.loginStates, hash)
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+type consentKey struct {
+	userID   int
+	clientID string
+}
+
+type OAuthRepository struct {
+	store *Store
+}
+
+func NewOAuthRepository(store *Store) repositories.OAuthRepo {
+	return &OAuthRepository{store: store}
+}
+
+func (r *OAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.oauthClients[client.ID]; ok {
+		return fmt.Errorf("oauth client %q already exists", client.ID)
+	}
+	client.CreatedAt = time.Now()
+	stored := *client
+	stored.RedirectURIs = cloneStrings(client.RedirectURIs)
+	stored.Scopes = cloneStrings(client.Scopes)
+	r.store.oauthClients[client.ID] = &stored
+	return nil
+}
+
+func (r *OAuthRepository) FindClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	client, ok := r.store.oauthClients[id]
+	if !ok {
+		return nil, domain.OAuthClientNotFound
+	}
+	c := *client
+	c.RedirectURIs = cloneStrings(client.RedirectURIs)
+	c.Scopes = cloneStrings(client.Scopes)
+	return &c, nil
+}
+
+func (r *OAuthRepository) HasClients(ctx context.Context) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return len(r.store.oauthClients) > 0, nil
+}
+
+func (r *OAuthRepository) FindConsent(ctx context.Context, userID int, clientID string) (*models.OAuthConsent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	consent, ok := r.store.oauthConsents[consentKey{userID: userID, clientID: clientID}]
+	if !ok {
+		return nil, domain.OAuthConsentNotFound
+	}
+	c := *consent
+	c.Scopes = cloneStrings(consent.Scopes)
+	return &c, nil
+}
+
+// SaveConsent добавляет scopes к уже выданному согласию и возвращает в
+// consent итоговый набор.
+func (r *OAuthRepository) SaveConsent(ctx context.Context, consent *models.OAuthConsent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := consentKey{userID: consent.UserID, clientID: consent.ClientID}
+	stored, ok := r.store.oauthConsents[key]
+	if !ok {
+		stored = &models.OAuthConsent{UserID: consent.UserID, ClientID: consent.ClientID}
+		r.store.oauthConsents[key] = stored
+	}
+	for _, scope := range consent.Scopes {
+		if !models.ContainsScopes(stored.Scopes, []string{scope}) {
+			stored.Scopes = append(stored.Scopes, scope)
+		}
+	}
+	stored.GrantedAt = time.Now()
+
+	consent.Scopes = cloneStrings(stored.Scopes)
+	consent.GrantedAt = stored.GrantedAt
+	return nil
+}
+
+func copyCode(code *models.AuthorizationCode) *models.AuthorizationCode {
+	c := *code
+	c.Scopes = cloneStrings(code.Scopes)
+	c.UsedAt = cloneTime(code.UsedAt)
+	return &c
+}
+
+// CreateCode, как и репозиторий Postgres, удаляет коды, истекшие больше
+// суток назад.
+func (r *OAuthRepository) CreateCode(ctx context.Context, code *models.AuthorizationCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	horizon := time.Now().Add(-24 * time.Hour)
+	for hash, stored := range r.store.oauthCodes {
+		if stored.ExpiresAt.Before(horizon) {
+			delete(r.store.oauthCodes, hash)
+		}
+	}
+
+	code.CreatedAt = time.Now()
+	stored := copyCode(code)
+	stored.UsedAt = nil
+	stored.SessionID = ""
+	r.store.oauthCodes[code.CodeHash] = stored
+	return nil
+}
+
+// ConsumeCode помечает код использованным. Повторно предъявленный код
+// возвращается вместе с OAuthCodeUsed.
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash, sessionID string) (*models.AuthorizationCode, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	code, ok := r.store.oauthCodes[codeHash]
+	if !ok {
+		return nil, domain.OAuthCodeNotFound
+	}
+	if code.UsedAt != nil {
+		return copyCode(code), domain.OAuthCodeUsed
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	code.SessionID = sessionID
+	return copyCode(code), nil
+}
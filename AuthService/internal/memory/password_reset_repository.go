@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type PasswordResetRepository struct {
+	store *Store
+}
+
+func NewPasswordResetRepository(store *Store) repositories.PasswordResetRepo {
+	return &PasswordResetRepository{store: store}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reset.CreatedAt = time.Now()
+	stored := *reset
+	stored.UsedAt = nil
+	r.store.passwordResets[reset.TokenHash] = &stored
+	return nil
+}
+
+func (r *PasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reset, ok := r.store.passwordResets[tokenHash]
+	if !ok {
+		return nil, domain.TokenNotFound
+	}
+	c := *reset
+	c.UsedAt = cloneTime(reset.UsedAt)
+	return &c, nil
+}
+
+// Use помечает токен использованным. Если токен уже использован или
+// истек, возвращает domain.InvalidToken.
+func (r *PasswordResetRepository) Use(ctx context.Context, tokenHash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	reset, ok := r.store.passwordResets[tokenHash]
+	if !ok || !reset.Active(now) {
+		return domain.InvalidToken
+	}
+	reset.UsedAt = &now
+	onRollback(ctx, func() { reset.UsedAt = nil })
+	return nil
+}
+
+func (r *PasswordResetRepository) DeleteForUser(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deleted := map[string]*models.PasswordReset{}
+	for hash, reset := range r.store.passwordResets {
+		if reset.UserID == userID {
+			deleted[hash] = reset
+			delete(r.store.passwordResets, hash)
+		}
+	}
+	onRollback(ctx, func() {
+		for hash, reset := range deleted {
+			r.store.passwordResets[hash] = reset
+		}
+	})
+	return nil
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"sort"
+	"time"
+)
+
+type PersonalTokenRepository struct {
+	store *Store
+}
+
+func NewPersonalTokenRepository(store *Store) repositories.PersonalTokenRepo {
+	return &PersonalTokenRepository{store: store}
+}
+
+func copyPersonalToken(token *models.PersonalAccessToken) *models.PersonalAccessToken {
+	c := *token
+	c.Scopes = cloneStrings(token.Scopes)
+	c.LastUsedAt = cloneTime(token.LastUsedAt)
+	c.RevokedAt = cloneTime(token.RevokedAt)
+	return &c
+}
+
+func (r *PersonalTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token.CreatedAt = time.Now()
+	stored := copyPersonalToken(token)
+	stored.LastUsedAt = nil
+	stored.RevokedAt = nil
+	r.store.personalTokens[token.ID] = stored
+	return nil
+}
+
+func (r *PersonalTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, token := range r.store.personalTokens {
+		if token.TokenHash == tokenHash {
+			return copyPersonalToken(token), nil
+		}
+	}
+	return nil, domain.PersonalTokenNotFound
+}
+
+func (r *PersonalTokenRepository) ListByUser(ctx context.Context, userID int) ([]*models.PersonalAccessToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var tokens []*models.PersonalAccessToken
+	for _, token := range r.store.personalTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, copyPersonalToken(token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepository) Revoke(ctx context.Context, userID int, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.personalTokens[id]
+	if !ok || token.UserID != userID || token.RevokedAt != nil {
+		return domain.PersonalTokenNotFound
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	return nil
+}
+
+// TouchLastUsed, как и в PostgreSQL, обновляет время использования не
+// чаще раза в минуту.
+func (r *PersonalTokenRepository) TouchLastUsed(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	token, ok := r.store.personalTokens[id]
+	if ok && (token.LastUsedAt == nil || token.LastUsedAt.Before(now.Add(-time.Minute))) {
+		token.LastUsedAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type SecondFactorRepository struct {
+	store *Store
+}
+
+func NewSecondFactorRepository(store *Store) repositories.SecondFactorRepo {
+	return &SecondFactorRepository{store: store}
+}
+
+func (r *SecondFactorRepository) FindTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	totp, ok := r.store.totps[userID]
+	if !ok {
+		return nil, domain.SecondFactorNotFound
+	}
+	c := *totp
+	c.ConfirmedAt = cloneTime(totp.ConfirmedAt)
+	return &c, nil
+}
+
+// SaveTOTP заменяет неподтвержденный или прежний секрет новым.
+func (r *SecondFactorRepository) SaveTOTP(ctx context.Context, userID int, secret string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.totps[userID] = &models.TOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+// ConfirmTOTP включает второй фактор и заменяет коды восстановления.
+func (r *SecondFactorRepository) ConfirmTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if totp, ok := r.store.totps[userID]; ok {
+		now := time.Now()
+		totp.ConfirmedAt = &now
+	}
+	codes := make(map[string]bool, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = false
+	}
+	r.store.recoveryCodes[userID] = codes
+	return nil
+}
+
+// MarkTOTPUsed запоминает шаг кода и возвращает false, если код этого
+// или более позднего шага уже был принят.
+func (r *SecondFactorRepository) MarkTOTPUsed(ctx context.Context, userID int, step int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	totp, ok := r.store.totps[userID]
+	if !ok || totp.LastUsedStep >= step {
+		return false, nil
+	}
+	totp.LastUsedStep = step
+	return true, nil
+}
+
+func (r *SecondFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	used, ok := r.store.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.store.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *SecondFactorRepository) ConsumeChallenge(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	for usedID, usedUntil := range r.store.usedChallenges {
+		if usedUntil.Before(now) {
+			delete(r.store.usedChallenges, usedID)
+		}
+	}
+	if _, ok := r.store.usedChallenges[id]; ok {
+		return false, nil
+	}
+	r.store.usedChallenges[id] = expiresAt
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"sort"
+	"time"
+)
+
+type SessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) repositories.SessionRepo {
+	return &SessionRepository{store: store}
+}
+
+func copySession(session *models.Session) *models.Session {
+	c := *session
+	c.RevokedAt = cloneTime(session.RevokedAt)
+	return &c
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+	stored := copySession(session)
+	stored.RevokedAt = nil
+	r.store.sessions[session.ID] = stored
+	onRollback(ctx, func() { delete(r.store.sessions, stored.ID) })
+	return nil
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, domain.SessionNotFound
+	}
+	return copySession(session), nil
+}
+
+func (r *SessionRepository) ListActive(ctx context.Context, userID int) ([]*models.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	alive := make(map[string]bool)
+	for _, token := range r.store.refreshTokens {
+		if token.UserID == userID && token.Active(now) {
+			alive[token.FamilyID] = true
+		}
+	}
+
+	var sessions []*models.Session
+	for _, session := range r.store.sessions {
+		if session.UserID == userID && session.Active() && alive[session.ID] {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// Touch обновляет время последнего использования и, если известны,
+// адрес и user agent, с которых сессия использовалась последний раз.
+func (r *SessionRepository) Touch(ctx context.Context, id string, client models.ClientInfo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil
+	}
+	session.LastUsedAt = time.Now()
+	if client.IP != "" {
+		session.IP = client.IP
+	}
+	if client.UserAgent != "" {
+		session.UserAgent = client.UserAgent
+	}
+	return nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, userID int, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok || session.UserID != userID || !session.Active() {
+		return domain.SessionNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	onRollback(ctx, func() { session.RevokedAt = nil })
+	r.store.revokeRefreshTokens(ctx, func(token *models.RefreshToken) bool {
+		return token.FamilyID == id
+	})
+	return nil
+}
+
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID int, exceptID string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	var revoked []*models.Session
+	for _, session := range r.store.sessions {
+		if session.UserID == userID && session.ID != exceptID && session.Active() {
+			revokedAt := now
+			session.RevokedAt = &revokedAt
+			revoked = append(revoked, session)
+		}
+	}
+	onRollback(ctx, func() {
+		for _, session := range revoked {
+			session.RevokedAt = nil
+		}
+	})
+	r.store.revokeRefreshTokens(ctx, func(token *models.RefreshToken) bool {
+		return token.UserID == userID && token.FamilyID != exceptID
+	})
+	return len(revoked), nil
+}
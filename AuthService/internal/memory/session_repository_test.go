@@ -0,0 +1,67 @@
+package memory_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/memory"
+
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRepository_Rotate(t *testing.T) {
+	tokens := memory.NewTokenRepository(memory.NewStore())
+	ctx := context.Background()
+
+	expires := time.Now().Add(time.Hour)
+	require.NoError(t, tokens.Create(ctx, &models.RefreshToken{ID: "t1", FamilyID: "s1", UserID: 1, ExpiresAt: expires}))
+	require.NoError(t, tokens.Rotate(ctx, "t1", &models.RefreshToken{ID: "t2", FamilyID: "s1", UserID: 1, ExpiresAt: expires}))
+
+	old, err := tokens.FindByID(ctx, "t1")
+	require.NoError(t, err)
+	assert.True(t, old.Rotated())
+	assert.Equal(t, "t2", old.ReplacedBy)
+
+	err = tokens.Rotate(ctx, "t1", &models.RefreshToken{ID: "t3", FamilyID: "s1", UserID: 1, ExpiresAt: expires})
+	assert.ErrorIs(t, err, domain.TokenReused)
+	_, err = tokens.FindByID(ctx, "t3")
+	assert.ErrorIs(t, err, domain.TokenNotFound)
+}
+
+func TestSessionRepository_RevokeWithTokens(t *testing.T) {
+	store := memory.NewStore()
+	sessions := memory.NewSessionRepository(store)
+	tokens := memory.NewTokenRepository(store)
+	ctx := context.Background()
+
+	expires := time.Now().Add(time.Hour)
+	for _, id := range []string{"s1", "s2", "s3"} {
+		require.NoError(t, sessions.Create(ctx, &models.Session{ID: id, UserID: 1}))
+		require.NoError(t, tokens.Create(ctx, &models.RefreshToken{ID: "t-" + id, FamilyID: id, UserID: 1, ExpiresAt: expires}))
+	}
+	// Сессия без действующего refresh токена не считается активной.
+	require.NoError(t, tokens.Revoke(ctx, "t-s3"))
+
+	active, err := sessions.ListActive(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	assert.ErrorIs(t, sessions.Revoke(ctx, 2, "s1"), domain.SessionNotFound)
+	require.NoError(t, sessions.Revoke(ctx, 1, "s1"))
+	assert.ErrorIs(t, sessions.Revoke(ctx, 1, "s1"), domain.SessionNotFound)
+	token, err := tokens.FindByID(ctx, "t-s1")
+	require.NoError(t, err)
+	assert.NotNil(t, token.RevokedAt)
+
+	revoked, err := sessions.RevokeAll(ctx, 1, "s2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	active, err = sessions.ListActive(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "s2", active[0].ID)
+}
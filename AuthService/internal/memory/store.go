@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"AuthService/internal/domain/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store хранит данные всех репозиториев в памяти процесса. Используется
+// вместо PostgreSQL для локальной разработки и тестов: данные теряются
+// при перезапуске. Все репозитории одного Store работают под общей
+// блокировкой, поэтому операции, затрагивающие несколько "таблиц"
+// (например, отзыв сессии вместе с ее refresh токенами), атомарны.
+//
+// Store также реализует repositories.Transactor. Транзакции выполняются
+// по одной, но без изоляции: остальные запросы видят их изменения до
+// завершения. При ошибке изменения отменяются в обратном порядке.
+type Store struct {
+	mu sync.Mutex
+	// txMu упорядочивает транзакции WithinTx.
+	txMu sync.Mutex
+
+	users      map[int]*userRecord
+	usernames  map[string]int
+	nextUserID int
+
+	refreshTokens map[string]*models.RefreshToken
+	sessions      map[string]*models.Session
+	attempts      map[string]*attempt
+
+	totps         map[int]*models.TOTP
+	recoveryCodes map[int]map[string]bool
+	// usedChallenges - id использованных challenge токенов и срок их действия.
+	usedChallenges map[string]time.Time
+
+	passwordResets map[string]*models.PasswordReset
+	suspensions    map[int]*models.Suspension
+
+	oauthClients  map[string]*models.OAuthClient
+	oauthConsents map[consentKey]*models.OAuthConsent
+	oauthCodes    map[string]*models.AuthorizationCode
+
+	identities  map[identityKey]*models.ExternalIdentity
+	loginStates map[string]*models.ExternalLoginState
+
+	personalTokens map[string]*models.PersonalAccessToken
+
+	auditEvents []*models.AuditEvent
+	nextAuditID int64
+	userEvents  []*models.UserEvent
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:          make(map[int]*userRecord),
+		usernames:      make(map[string]int),
+		refreshTokens:  make(map[string]*models.RefreshToken),
+		sessions:       make(map[string]*models.Session),
+		attempts:       make(map[string]*attempt),
+		totps:          make(map[int]*models.TOTP),
+		recoveryCodes:  make(map[int]map[string]bool),
+		usedChallenges: make(map[string]time.Time),
+		passwordResets: make(map[string]*models.PasswordReset),
+		suspensions:    make(map[int]*models.Suspension),
+		oauthClients:   make(map[string]*models.OAuthClient),
+		oauthConsents:  make(map[consentKey]*models.OAuthConsent),
+		oauthCodes:     make(map[string]*models.AuthorizationCode),
+		identities:     make(map[identityKey]*models.ExternalIdentity),
+		loginStates:    make(map[string]*models.ExternalLoginState),
+		personalTokens: make(map[string]*models.PersonalAccessToken),
+	}
+}
+
+// PingContext позволяет передать Store в health checker вместо базы
+// данных: хранилище в памяти всегда доступно.
+func (s *Store) PingContext(ctx context.Context) error {
+	return nil
+}
+
+type txKey struct{}
+
+// memoryTx накапливает действия, отменяющие изменения транзакции.
+type memoryTx struct {
+	undo []func()
+}
+
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*memoryTx); ok {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	tx := &memoryTx{}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// onRollback запоминает, как отменить изменение, если ctx получен внутри
+// WithinTx. undo вызывается под s.mu.
+func onRollback(ctx context.Context, undo func()) {
+	if tx, ok := ctx.Value(txKey{}).(*memoryTx); ok {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string(nil), values...)
+}
+
+func cloneMap(values map[string]string) map[string]string {
+	c := make(map[string]string, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
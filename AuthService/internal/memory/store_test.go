@@ -0,0 +1,85 @@
+package memory_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/memory"
+
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_WithinTx(t *testing.T) {
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	ctx := context.Background()
+
+	failed := errors.New("grant failed")
+	err := store.WithinTx(ctx, func(ctx context.Context) error {
+		user := &models.User{Username: "alice"}
+		require.NoError(t, users.Create(ctx, user))
+		require.NoError(t, users.GrantRole(ctx, user.ID, models.RoleUser))
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	_, err = users.FindByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+
+	require.NoError(t, store.WithinTx(ctx, func(ctx context.Context) error {
+		user := &models.User{Username: "alice"}
+		if err := users.Create(ctx, user); err != nil {
+			return err
+		}
+		return users.GrantRole(ctx, user.ID, models.RoleUser)
+	}))
+	user, err := users.FindByUsername(ctx, "alice")
+	require.NoError(t, err)
+	roles, err := users.Roles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleUser}, roles)
+}
+
+func TestStore_WithinTx_RollsBackSessionAndToken(t *testing.T) {
+	store := memory.NewStore()
+	sessions := memory.NewSessionRepository(store)
+	tokens := memory.NewTokenRepository(store)
+	ctx := context.Background()
+
+	failed := errors.New("token insert failed")
+	err := store.WithinTx(ctx, func(ctx context.Context) error {
+		require.NoError(t, sessions.Create(ctx, &models.Session{ID: "s1", UserID: 1}))
+		require.NoError(t, tokens.Create(ctx, &models.RefreshToken{ID: "t1", UserID: 1, FamilyID: "s1"}))
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+
+	_, err = sessions.FindByID(ctx, "s1")
+	assert.ErrorIs(t, err, domain.SessionNotFound)
+	_, err = tokens.FindByID(ctx, "t1")
+	assert.ErrorIs(t, err, domain.TokenNotFound)
+}
+
+func TestStore_WithinTx_RollsBackUserAndIdentity(t *testing.T) {
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	identities := memory.NewExternalIdentityRepository(store)
+	ctx := context.Background()
+
+	failed := errors.New("login failed")
+	err := store.WithinTx(ctx, func(ctx context.Context) error {
+		user := &models.User{Username: "alice"}
+		require.NoError(t, users.Create(ctx, user))
+		require.NoError(t, identities.Create(ctx, &models.ExternalIdentity{Provider: "corp", Subject: "alice-123", UserID: user.ID}))
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+
+	_, err = users.FindByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	_, err = identities.Find(ctx, "corp", "alice-123")
+	assert.ErrorIs(t, err, domain.ExternalIdentityNotFound)
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type SuspensionRepository struct {
+	store *Store
+}
+
+func NewSuspensionRepository(store *Store) repositories.SuspensionRepo {
+	return &SuspensionRepository{store: store}
+}
+
+func (r *SuspensionRepository) Find(ctx context.Context, userID int) (*models.Suspension, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	suspension, ok := r.store.suspensions[userID]
+	if !ok {
+		return nil, domain.SuspensionNotFound
+	}
+	c := *suspension
+	c.Until = cloneTime(suspension.Until)
+	return &c, nil
+}
+
+// Save заменяет действующую блокировку пользователя, если она есть.
+func (r *SuspensionRepository) Save(ctx context.Context, suspension *models.Suspension) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	suspension.CreatedAt = time.Now()
+	stored := *suspension
+	stored.Until = cloneTime(suspension.Until)
+	previous, existed := r.store.suspensions[suspension.UserID]
+	r.store.suspensions[suspension.UserID] = &stored
+	onRollback(ctx, func() {
+		if existed {
+			r.store.suspensions[stored.UserID] = previous
+		} else {
+			delete(r.store.suspensions, stored.UserID)
+		}
+	})
+	return nil
+}
+
+func (r *SuspensionRepository) Delete(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	previous, ok := r.store.suspensions[userID]
+	if !ok {
+		return domain.SuspensionNotFound
+	}
+	delete(r.store.suspensions, userID)
+	onRollback(ctx, func() { r.store.suspensions[userID] = previous })
+	return nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type TokenRepository struct {
+	store *Store
+}
+
+func NewTokenRepository(store *Store) repositories.TokenRepo {
+	return &TokenRepository{store: store}
+}
+
+func copyRefreshToken(token *models.RefreshToken) *models.RefreshToken {
+	c := *token
+	c.RevokedAt = cloneTime(token.RevokedAt)
+	return &c
+}
+
+func (s *Store) insertRefreshToken(token *models.RefreshToken) {
+	token.CreatedAt = time.Now()
+	stored := copyRefreshToken(token)
+	stored.RevokedAt = nil
+	stored.ReplacedBy = ""
+	s.refreshTokens[token.ID] = stored
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.insertRefreshToken(token)
+	id := token.ID
+	onRollback(ctx, func() { delete(r.store.refreshTokens, id) })
+	return nil
+}
+
+func (r *TokenRepository) FindByID(ctx context.Context, id string) (*models.RefreshToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.refreshTokens[id]
+	if !ok {
+		return nil, domain.TokenNotFound
+	}
+	return copyRefreshToken(token), nil
+}
+
+func (r *TokenRepository) Revoke(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if token, ok := r.store.refreshTokens[id]; ok && token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+	}
+	return nil
+}
+
+// Rotate возвращает TokenReused, если старый токен уже отозван или
+// ротирован, так что из двух одновременных ротаций пройдет только одна.
+func (r *TokenRepository) Rotate(ctx context.Context, oldID string, next *models.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	old, ok := r.store.refreshTokens[oldID]
+	if !ok || old.RevokedAt != nil {
+		return domain.TokenReused
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	old.ReplacedBy = next.ID
+	r.store.insertRefreshToken(next)
+	return nil
+}
+
+// revokeRefreshTokens отзывает действующие токены, для которых match
+// возвращает true.
+func (s *Store) revokeRefreshTokens(ctx context.Context, match func(*models.RefreshToken) bool) {
+	now := time.Now()
+	var revoked []*models.RefreshToken
+	for _, token := range s.refreshTokens {
+		if token.RevokedAt == nil && match(token) {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+			revoked = append(revoked, token)
+		}
+	}
+	onRollback(ctx, func() {
+		for _, token := range revoked {
+			token.RevokedAt = nil
+		}
+	})
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.revokeRefreshTokens(ctx, func(token *models.RefreshToken) bool {
+		return token.FamilyID == familyID
+	})
+	return nil
+}
+
+func (r *TokenRepository) RevokeUser(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.revokeRefreshTokens(ctx, func(token *models.RefreshToken) bool {
+		return token.UserID == userID
+	})
+	return nil
+}
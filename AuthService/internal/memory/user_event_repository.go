@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"time"
+)
+
+type UserEventRepository struct {
+	store *Store
+}
+
+func NewUserEventRepository(store *Store) repositories.UserEventRepo {
+	return &UserEventRepository{store: store}
+}
+
+func copyUserEvent(event *models.UserEvent) *models.UserEvent {
+	c := *event
+	c.Data = cloneMap(event.Data)
+	return &c
+}
+
+// Append присваивает событиям последовательные id начиная с 1, поэтому id
+// события совпадает с его позицией в ленте. Транзакции WithinTx идут по
+// одной, поэтому при откате отменяемое событие - последнее в ленте.
+func (r *UserEventRepository) Append(ctx context.Context, event *models.UserEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	event.ID = int64(len(r.store.userEvents) + 1)
+	event.CreatedAt = time.Now()
+	r.store.userEvents = append(r.store.userEvents, copyUserEvent(event))
+	id := event.ID
+	onRollback(ctx, func() { r.store.userEvents = r.store.userEvents[:id-1] })
+	return nil
+}
+
+func (r *UserEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*models.UserEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var events []*models.UserEvent
+	for i := afterID; i < int64(len(r.store.userEvents)) && len(events) < limit; i++ {
+		events = append(events, copyUserEvent(r.store.userEvents[i]))
+	}
+	return events, nil
+}
+
+func (r *UserEventRepository) LastID(ctx context.Context) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return int64(len(r.store.userEvents)), nil
+}
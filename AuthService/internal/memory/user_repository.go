@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type userRecord struct {
+	user    models.User
+	profile models.Profile
+	roles   map[models.Role]bool
+}
+
+func (r *userRecord) copyUser() *models.User {
+	user := r.user
+	return &user
+}
+
+func (r *userRecord) copyProfile() *models.Profile {
+	profile := r.profile
+	profile.LastSeen = cloneTime(r.profile.LastSeen)
+	return &profile
+}
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repositories.UserRepo {
+	return &UserRepository{store: store}
+}
+
+// Create сообщает о занятом имени или адресе доменными ошибками
+// UserAlreadyExists и EmailAlreadyExists. Адреса сравниваются без учета
+// регистра.
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.usernames[user.Username]; ok {
+		return domain.UserAlreadyExists
+	}
+	if user.Email != "" && r.store.findByEmail(user.Email) != nil {
+		return domain.EmailAlreadyExists
+	}
+
+	r.store.nextUserID++
+	user.ID = r.store.nextUserID
+	record := &userRecord{
+		user: models.User{
+			ID:       user.ID,
+			Username: user.Username,
+			Password: user.Password,
+			Email:    user.Email,
+		},
+		profile: models.Profile{
+			UserID:    user.ID,
+			Username:  user.Username,
+			CreatedAt: time.Now(),
+		},
+		roles: make(map[models.Role]bool),
+	}
+	r.store.users[user.ID] = record
+	r.store.usernames[user.Username] = user.ID
+	onRollback(ctx, func() {
+		delete(r.store.users, record.user.ID)
+		delete(r.store.usernames, record.user.Username)
+	})
+	return nil
+}
+
+func (s *Store) findByEmail(email string) *userRecord {
+	for _, record := range s.users {
+		if record.user.Email != "" && strings.EqualFold(record.user.Email, email) {
+			return record
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id, ok := r.store.usernames[username]
+	if !ok {
+		return nil, domain.UserNotFound
+	}
+	return r.store.users[id].copyUser(), nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return nil, domain.UserNotFound
+	}
+	return record.copyUser(), nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.findByEmail(email)
+	if record == nil {
+		return nil, domain.UserNotFound
+	}
+	return record.copyUser(), nil
+}
+
+// MarkEmailVerified подтверждает адрес, только если он не изменился
+// с момента выдачи токена подтверждения.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int, email string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[userID]
+	if !ok || record.user.Email == "" || !strings.EqualFold(record.user.Email, email) {
+		return false, nil
+	}
+	if !record.user.EmailVerified {
+		record.user.EmailVerified = true
+		onRollback(ctx, func() { record.user.EmailVerified = false })
+	}
+	return true, nil
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[userID]
+	if !ok {
+		return domain.UserNotFound
+	}
+	previous := record.user.Password
+	record.user.Password = passwordHash
+	onRollback(ctx, func() { record.user.Password = previous })
+	return nil
+}
+
+func (r *UserRepository) Roles(ctx context.Context, userID int) ([]models.Role, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	var roles []models.Role
+	for role := range record.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles, nil
+}
+
+func (r *UserRepository) GrantRole(ctx context.Context, userID int, role models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[userID]
+	if !ok {
+		return domain.UserNotFound
+	}
+	if !record.roles[role] {
+		record.roles[role] = true
+		onRollback(ctx, func() { delete(record.roles, role) })
+	}
+	return nil
+}
+
+func (r *UserRepository) RevokeRole(ctx context.Context, userID int, role models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if record, ok := r.store.users[userID]; ok && record.roles[role] {
+		delete(record.roles, role)
+		onRollback(ctx, func() { record.roles[role] = true })
+	}
+	return nil
+}
+
+// sortedUsers возвращает пользователей по возрастанию id, как ORDER BY id.
+func (s *Store) sortedUsers() []*userRecord {
+	records := make([]*userRecord, 0, len(s.users))
+	for _, record := range s.users {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].user.ID < records[j].user.ID })
+	return records
+}
+
+func (r *UserRepository) Profiles(ctx context.Context, ids []int, usernames []string) ([]*models.Profile, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	wanted := make(map[int]bool, len(ids)+len(usernames))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	for _, username := range usernames {
+		if id, ok := r.store.usernames[username]; ok {
+			wanted[id] = true
+		}
+	}
+
+	var profiles []*models.Profile
+	for _, record := range r.store.sortedUsers() {
+		if wanted[record.user.ID] {
+			profiles = append(profiles, record.copyProfile())
+		}
+	}
+	return profiles, nil
+}
+
+// SearchProfiles ищет по вхождению в имя пользователя или отображаемое
+// имя без учета регистра. Страницы строятся по id: следующая начинается
+// после afterID.
+func (r *UserRepository) SearchProfiles(ctx context.Context, query string, afterID, limit int) ([]*models.Profile, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	query = strings.ToLower(query)
+	var profiles []*models.Profile
+	for _, record := range r.store.sortedUsers() {
+		if len(profiles) >= limit {
+			break
+		}
+		if record.user.ID <= afterID {
+			continue
+		}
+		if strings.Contains(strings.ToLower(record.profile.Username), query) ||
+			strings.Contains(strings.ToLower(record.profile.DisplayName), query) {
+			profiles = append(profiles, record.copyProfile())
+		}
+	}
+	return profiles, nil
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (*models.Profile, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[userID]
+	if !ok {
+		return nil, domain.UserNotFound
+	}
+	previous := record.profile
+	onRollback(ctx, func() { record.profile = previous })
+	if update.DisplayName != nil {
+		record.profile.DisplayName = *update.DisplayName
+	}
+	if update.Bio != nil {
+		record.profile.Bio = *update.Bio
+	}
+	if update.AvatarURL != nil {
+		record.profile.AvatarURL = *update.AvatarURL
+	}
+	return record.copyProfile(), nil
+}
+
+func (r *UserRepository) TouchLastSeen(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if record, ok := r.store.users[userID]; ok {
+		now := time.Now()
+		record.profile.LastSeen = &now
+	}
+	return nil
+}
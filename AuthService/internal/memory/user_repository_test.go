@@ -0,0 +1,153 @@
+package memory_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/memory"
+
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_Create(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+
+	alice := &models.User{Username: "alice", Password: "hash", Email: "Alice@Example.com"}
+	require.NoError(t, repo.Create(ctx, alice))
+	assert.Equal(t, 1, alice.ID)
+
+	assert.ErrorIs(t, repo.Create(ctx, &models.User{Username: "alice"}), domain.UserAlreadyExists)
+	assert.ErrorIs(t, repo.Create(ctx, &models.User{Username: "bob", Email: "alice@example.com"}), domain.EmailAlreadyExists)
+	require.NoError(t, repo.Create(ctx, &models.User{Username: "bob"}))
+	require.NoError(t, repo.Create(ctx, &models.User{Username: "carol"}))
+
+	found, err := repo.FindByEmail(ctx, "ALICE@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, found.ID)
+
+	found, err = repo.FindByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", found.Password)
+
+	// Изменение возвращенной копии не затрагивает хранилище.
+	found.Password = "changed"
+	found, err = repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", found.Password)
+}
+
+func TestUserRepository_NotFound(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+
+	_, err := repo.FindByUsername(ctx, "ghost")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	_, err = repo.FindByID(ctx, 42)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	_, err = repo.FindByEmail(ctx, "ghost@example.com")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	assert.ErrorIs(t, repo.UpdatePassword(ctx, 42, "hash"), domain.UserNotFound)
+	_, err = repo.UpdateProfile(ctx, 42, models.ProfileUpdate{})
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
+
+func TestUserRepository_RolesAndEmail(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+
+	user := &models.User{Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	require.NoError(t, repo.GrantRole(ctx, user.ID, models.RoleUser))
+	require.NoError(t, repo.GrantRole(ctx, user.ID, models.RoleAdmin))
+	require.NoError(t, repo.GrantRole(ctx, user.ID, models.RoleAdmin))
+	roles, err := repo.Roles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleAdmin, models.RoleUser}, roles)
+
+	require.NoError(t, repo.RevokeRole(ctx, user.ID, models.RoleAdmin))
+	roles, err = repo.Roles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleUser}, roles)
+
+	verified, err := repo.MarkEmailVerified(ctx, user.ID, "old@example.com")
+	require.NoError(t, err)
+	assert.False(t, verified)
+	verified, err = repo.MarkEmailVerified(ctx, user.ID, "ALICE@example.com")
+	require.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestUserRepository_Profiles(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+
+	for _, username := range []string{"alice", "bob", "alina"} {
+		require.NoError(t, repo.Create(ctx, &models.User{Username: username}))
+	}
+	name := "Ali Baba"
+	profile, err := repo.UpdateProfile(ctx, 2, models.ProfileUpdate{DisplayName: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "Ali Baba", profile.DisplayName)
+
+	profiles, err := repo.Profiles(ctx, []int{3}, []string{"alice", "ghost"})
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "alice", profiles[0].Username)
+	assert.Equal(t, "alina", profiles[1].Username)
+
+	// Совпадения по имени и по отображаемому имени, страницами по id.
+	page, err := repo.SearchProfiles(ctx, "ALI", 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, []int{1, 2}, []int{page[0].UserID, page[1].UserID})
+	page, err = repo.SearchProfiles(ctx, "ali", 2, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, 3, page[0].UserID)
+}
+
+func TestUserRepository_ConcurrentCreate(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+
+	var created, duplicates atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Половина горутин регистрирует одно и то же имя.
+			username := "alice"
+			if i%2 == 1 {
+				username = fmt.Sprintf("user%d", i)
+			}
+			err := repo.Create(ctx, &models.User{Username: username})
+			switch {
+			case err == nil:
+				created.Add(1)
+			case assert.ErrorIs(t, err, domain.UserAlreadyExists):
+				duplicates.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(26), created.Load())
+	assert.Equal(t, int32(24), duplicates.Load())
+
+	seen := make(map[int]bool)
+	for i := 1; i <= 26; i++ {
+		user, err := repo.FindByID(ctx, i)
+		require.NoError(t, err)
+		assert.False(t, seen[user.ID])
+		seen[user.ID] = true
+	}
+}
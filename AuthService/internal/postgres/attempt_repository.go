@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type AttemptRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAttemptRepository(db *sql.DB, logger *zap.Logger) repositories.AttemptRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AttemptRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "attempt_repository")),
+	}
+}
+
+func (r *AttemptRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	query := `SELECT locked_until FROM login_attempts WHERE key = $1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, key).Scan(&lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		r.logger.Error("failed to load login lock",
+			zap.String("key", key),
+			zap.Error(err))
+		return time.Time{}, err
+	}
+	return lockedUntil.Time, nil
+}
+
+func (r *AttemptRepository) RegisterFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	query := `INSERT INTO login_attempts (key, failures, updated_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.updated_at < NOW() - make_interval(secs => $2)
+				THEN 1 ELSE login_attempts.failures + 1 END,
+			updated_at = NOW()
+		RETURNING failures`
+
+	r.logger.Debug("registering failed login",
+		zap.String("key", key),
+		zap.String("query", query))
+
+	var failures int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, key, window.Seconds()).Scan(&failures); err != nil {
+		r.logger.Error("failed to register failed login",
+			zap.String("key", key),
+			zap.Error(err))
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (r *AttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until = $2 WHERE key = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, key, until); err != nil {
+		r.logger.Error("failed to lock login",
+			zap.String("key", key),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *AttemptRepository) Reset(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE key = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, key); err != nil {
+		r.logger.Error("failed to reset login attempts",
+			zap.String("key", key),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
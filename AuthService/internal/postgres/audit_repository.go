@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"time"
+)
+
+type AuditRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logger *zap.Logger) repositories.AuditRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AuditRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "audit_repository")),
+	}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	query := `INSERT INTO audit_events (event_type, user_id, actor_id, ip, user_agent, request_id, details)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	details := event.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, string(event.Type), event.UserID, event.ActorID,
+		event.IP, event.UserAgent, event.RequestID, encoded).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to append audit event",
+			zap.String("event_type", string(event.Type)),
+			zap.Int("user_id", event.UserID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	query := `SELECT id, event_type, user_id, actor_id, ip, user_agent, request_id, details, created_at
+		FROM audit_events
+		WHERE ($1 = 0 OR user_id = $1)
+			AND (cardinality($2::text[]) = 0 OR event_type = ANY($2))
+			AND ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+			AND ($5 = 0 OR id < $5)
+		ORDER BY id DESC
+		LIMIT $6`
+
+	types := make([]string, 0, len(filter.Types))
+	for _, eventType := range filter.Types {
+		types = append(types, string(eventType))
+	}
+
+	r.logger.Debug("listing audit events",
+		zap.Int("user_id", filter.UserID),
+		zap.Strings("event_types", types),
+		zap.Int64("before_id", filter.BeforeID),
+		zap.String("query", query))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, filter.UserID, pq.Array(types),
+		nullTime(filter.Since), nullTime(filter.Until), filter.BeforeID, filter.Limit)
+	if err != nil {
+		r.logger.Error("failed to list audit events", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		var eventType string
+		var userID, actorID sql.NullInt64
+		var details []byte
+		err := rows.Scan(&event.ID, &eventType, &userID, &actorID, &event.IP, &event.UserAgent,
+			&event.RequestID, &details, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, err
+		}
+		event.Type = models.AuditEventType(eventType)
+		event.UserID = int(userID.Int64)
+		event.ActorID = int(actorID.Int64)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *AuditRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM audit_events WHERE created_at < $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		r.logger.Error("failed to prune audit events",
+			zap.Time("before", before),
+			zap.Error(err))
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
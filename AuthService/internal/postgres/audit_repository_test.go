@@ -0,0 +1,92 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepository_Append(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO audit_events").
+		WithArgs("login_failed", 0, 0, "10.0.0.1", "curl/8.0", "req-1", []byte(`{"reason":"user_not_found"}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, now))
+
+	repo := postgres.NewAuditRepository(db, nil)
+	event := &models.AuditEvent{
+		Type:      models.AuditLoginFailed,
+		IP:        "10.0.0.1",
+		UserAgent: "curl/8.0",
+		RequestID: "req-1",
+		Details:   map[string]string{"reason": "user_not_found"},
+	}
+	require.NoError(t, repo.Append(context.Background(), event))
+	assert.Equal(t, int64(7), event.ID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAuditRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM audit_events").
+		WithArgs(1, sqlmock.AnyArg(), now, nil, int64(10), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "user_id", "actor_id", "ip", "user_agent", "request_id", "details", "created_at"}).
+			AddRow(9, "role_granted", 1, 2, "10.0.0.1", "", "req-2", []byte(`{"role":"admin"}`), now).
+			AddRow(8, "login_succeeded", 1, nil, "10.0.0.1", "", "req-1", []byte(`{}`), now))
+
+	repo := postgres.NewAuditRepository(db, nil)
+	events, err := repo.List(context.Background(), models.AuditFilter{
+		UserID:   1,
+		Types:    []models.AuditEventType{models.AuditRoleGranted, models.AuditLoginSucceeded},
+		Since:    now,
+		BeforeID: 10,
+		Limit:    3,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, 2, events[0].ActorID)
+	assert.Equal(t, "admin", events[0].Details["role"])
+	assert.Equal(t, 0, events[1].ActorID)
+	assert.Equal(t, models.AuditLoginSucceeded, events[1].Type)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAuditRepository_DeleteBefore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	before := time.Now()
+	mock.ExpectExec("DELETE FROM audit_events WHERE created_at < \\$1").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	repo := postgres.NewAuditRepository(db, nil)
+	deleted, err := repo.DeleteBefore(context.Background(), before)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
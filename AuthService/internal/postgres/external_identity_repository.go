@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type ExternalIdentityRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewExternalIdentityRepository(db *sql.DB, logger *zap.Logger) repositories.ExternalIdentityRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ExternalIdentityRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "external_identity_repository")),
+	}
+}
+
+func (r *ExternalIdentityRepository) Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	query := `SELECT provider, subject, user_id, COALESCE(email, ''), created_at, last_login_at
+		FROM external_identities WHERE provider = $1 AND subject = $2`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, provider, subject).
+		Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email,
+			&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ExternalIdentityNotFound
+		}
+		r.logger.Error("failed to find external identity",
+			zap.String("provider", provider),
+			zap.Error(err))
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *ExternalIdentityRepository) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	query := `INSERT INTO external_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (provider, subject) DO NOTHING
+		RETURNING created_at, last_login_at`
+
+	r.logger.Debug("linking external identity",
+		zap.String("provider", identity.Provider),
+		zap.Int("user_id", identity.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email).
+		Scan(&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("external identity already linked",
+				zap.String("provider", identity.Provider))
+			return domain.ExternalIdentityExists
+		}
+		r.logger.Error("failed to link external identity",
+			zap.String("provider", identity.Provider),
+			zap.Int("user_id", identity.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("external identity linked",
+		zap.String("provider", identity.Provider),
+		zap.Int("user_id", identity.UserID))
+	return nil
+}
+
+func (r *ExternalIdentityRepository) TouchLogin(ctx context.Context, provider, subject, email string) error {
+	query := `UPDATE external_identities SET last_login_at = NOW(), email = COALESCE(NULLIF($3, ''), email)
+		WHERE provider = $1 AND subject = $2`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, provider, subject, email)
+	if err != nil {
+		r.logger.Error("failed to update external identity",
+			zap.String("provider", provider),
+			zap.Error(err))
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ExternalIdentityNotFound
+	}
+	return nil
+}
+
+func (r *ExternalIdentityRepository) SaveState(ctx context.Context, state *models.ExternalLoginState) error {
+	query := `INSERT INTO external_login_states (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	r.logger.Debug("storing external login state",
+		zap.String("provider", state.Provider),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt).
+		Scan(&state.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to store external login state",
+			zap.String("provider", state.Provider),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ConsumeState заодно удаляет истекшие state, чтобы таблица не росла от
+// брошенных попыток входа.
+func (r *ExternalIdentityRepository) ConsumeState(ctx context.Context, stateHash string) (*models.ExternalLoginState, error) {
+	var state models.ExternalLoginState
+	query := `DELETE FROM external_login_states WHERE state_hash = $1
+		RETURNING state_hash, provider, nonce, code_verifier, expires_at, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, stateHash).
+		Scan(&state.StateHash, &state.Provider, &state.Nonce, &state.CodeVerifier, &state.ExpiresAt, &state.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("external login state not found")
+			return nil, domain.ExternalLoginStateNotFound
+		}
+		r.logger.Error("failed to consume external login state", zap.Error(err))
+		return nil, err
+	}
+
+	cleanup := `DELETE FROM external_login_states WHERE expires_at < NOW()`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, cleanup); err != nil {
+		r.logger.Warn("failed to delete expired external login states", zap.Error(err))
+	}
+	return &state, nil
+}
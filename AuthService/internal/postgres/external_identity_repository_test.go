@@ -0,0 +1,150 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalIdentityRepository_Create(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		expectErr error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO external_identities \\(provider, subject, user_id, email\\)").
+					WithArgs("corp", "alice-123", 1, "alice@corp.example").
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "last_login_at"}).AddRow(time.Now(), time.Now()))
+			},
+		},
+		{
+			name: "Already Linked",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO external_identities (.+) ON CONFLICT \\(provider, subject\\) DO NOTHING").
+					WithArgs("corp", "alice-123", 1, "alice@corp.example").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.ExternalIdentityExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewExternalIdentityRepository(db, nil)
+			err = repo.Create(context.Background(), &models.ExternalIdentity{
+				Provider: "corp",
+				Subject:  "alice-123",
+				UserID:   1,
+				Email:    "alice@corp.example",
+			})
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestExternalIdentityRepository_Find(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM external_identities WHERE provider = \\$1 AND subject = \\$2").
+		WithArgs("corp", "alice-123").
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "subject", "user_id", "email", "created_at", "last_login_at"}).
+			AddRow("corp", "alice-123", 1, "", time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT (.+) FROM external_identities").
+		WithArgs("corp", "bob-456").
+		WillReturnError(sql.ErrNoRows)
+
+	repo := postgres.NewExternalIdentityRepository(db, nil)
+	identity, err := repo.Find(context.Background(), "corp", "alice-123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, identity.UserID)
+
+	_, err = repo.Find(context.Background(), "corp", "bob-456")
+	assert.ErrorIs(t, err, domain.ExternalIdentityNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExternalIdentityRepository_ConsumeState(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		expectErr error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM external_login_states WHERE state_hash = \\$1\\s+RETURNING").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"state_hash", "provider", "nonce", "code_verifier", "expires_at", "created_at"}).
+						AddRow("hash", "corp", "nonce", "verifier", time.Now().Add(time.Minute), time.Now()))
+				mock.ExpectExec("DELETE FROM external_login_states WHERE expires_at < NOW\\(\\)").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM external_login_states WHERE state_hash = \\$1").
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.ExternalLoginStateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewExternalIdentityRepository(db, nil)
+			state, err := repo.ConsumeState(context.Background(), "hash")
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "corp", state.Provider)
+				assert.Equal(t, "verifier", state.CodeVerifier)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
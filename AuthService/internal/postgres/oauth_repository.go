@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type OAuthRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewOAuthRepository(db *sql.DB, logger *zap.Logger) repositories.OAuthRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &OAuthRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "oauth_repository")),
+	}
+}
+
+func (r *OAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	query := `INSERT INTO oauth_clients (id, secret_hash, name, redirect_uris, scopes, owner_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, 0))
+		RETURNING created_at`
+
+	r.logger.Debug("creating oauth client",
+		zap.String("client_id", client.ID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, client.ID, client.SecretHash, client.Name,
+		pq.Array(client.RedirectURIs), pq.Array(client.Scopes), client.OwnerID).
+		Scan(&client.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to create oauth client",
+			zap.String("client_id", client.ID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("oauth client created",
+		zap.String("client_id", client.ID),
+		zap.String("name", client.Name),
+		zap.Int("owner_id", client.OwnerID))
+	return nil
+}
+
+func (r *OAuthRepository) FindClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	var secretHash sql.NullString
+	var ownerID sql.NullInt64
+	query := `SELECT id, secret_hash, name, redirect_uris, scopes, owner_id, created_at FROM oauth_clients WHERE id = $1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
+		Scan(&client.ID, &secretHash, &client.Name, pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes), &ownerID, &client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.OAuthClientNotFound
+		}
+		r.logger.Error("failed to find oauth client",
+			zap.String("client_id", id),
+			zap.Error(err))
+		return nil, err
+	}
+
+	client.SecretHash = secretHash.String
+	client.OwnerID = int(ownerID.Int64)
+	return &client, nil
+}
+
+func (r *OAuthRepository) HasClients(ctx context.Context) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM oauth_clients)`
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		r.logger.Error("failed to check oauth clients", zap.Error(err))
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *OAuthRepository) FindConsent(ctx context.Context, userID int, clientID string) (*models.OAuthConsent, error) {
+	var consent models.OAuthConsent
+	query := `SELECT user_id, client_id, scopes, granted_at FROM oauth_consents WHERE user_id = $1 AND client_id = $2`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID, clientID).
+		Scan(&consent.UserID, &consent.ClientID, pq.Array(&consent.Scopes), &consent.GrantedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.OAuthConsentNotFound
+		}
+		r.logger.Error("failed to find oauth consent",
+			zap.Int("user_id", userID),
+			zap.String("client_id", clientID),
+			zap.Error(err))
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *OAuthRepository) SaveConsent(ctx context.Context, consent *models.OAuthConsent) error {
+	query := `INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+			granted_at = NOW()
+		RETURNING scopes, granted_at`
+
+	r.logger.Debug("saving oauth consent",
+		zap.Int("user_id", consent.UserID),
+		zap.String("client_id", consent.ClientID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, consent.UserID, consent.ClientID, pq.Array(consent.Scopes)).
+		Scan(pq.Array(&consent.Scopes), &consent.GrantedAt)
+	if err != nil {
+		r.logger.Error("failed to save oauth consent",
+			zap.Int("user_id", consent.UserID),
+			zap.String("client_id", consent.ClientID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("oauth consent granted",
+		zap.Int("user_id", consent.UserID),
+		zap.String("client_id", consent.ClientID),
+		zap.Strings("scopes", consent.Scopes))
+	return nil
+}
+
+// CreateCode заодно удаляет коды, истекшие больше суток назад. Более
+// свежие использованные коды остаются, чтобы повторное предъявление
+// кода отзывало выданные по нему токены.
+func (r *OAuthRepository) CreateCode(ctx context.Context, code *models.AuthorizationCode) error {
+	query := `INSERT INTO oauth_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`
+
+	r.logger.Debug("creating authorization code",
+		zap.String("client_id", code.ClientID),
+		zap.Int("user_id", code.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI,
+		pq.Array(code.Scopes), code.CodeChallenge, code.ExpiresAt).
+		Scan(&code.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to create authorization code",
+			zap.String("client_id", code.ClientID),
+			zap.Int("user_id", code.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	cleanup := `DELETE FROM oauth_codes WHERE expires_at < NOW() - INTERVAL '1 day'`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, cleanup); err != nil {
+		r.logger.Warn("failed to delete expired authorization codes", zap.Error(err))
+	}
+	return nil
+}
+
+const codeColumns = `code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, session_id, created_at`
+
+func scanCode(row rowScanner) (*models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	var usedAt sql.NullTime
+	var sessionID sql.NullString
+	err := row.Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, pq.Array(&code.Scopes),
+		&code.CodeChallenge, &code.ExpiresAt, &usedAt, &sessionID, &code.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+	code.SessionID = sessionID.String
+	return &code, nil
+}
+
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash, sessionID string) (*models.AuthorizationCode, error) {
+	query := `UPDATE oauth_codes SET used_at = NOW(), session_id = $2
+		WHERE code_hash = $1 AND used_at IS NULL
+		RETURNING ` + codeColumns
+
+	code, err := scanCode(conn(ctx, r.db).QueryRowContext(ctx, query, codeHash, sessionID))
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("failed to consume authorization code", zap.Error(err))
+		return nil, err
+	}
+
+	// Код не найден или уже использован: различаем эти случаи, чтобы
+	// при повторном предъявлении отозвать выданные по коду токены.
+	code, err = scanCode(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+codeColumns+` FROM oauth_codes WHERE code_hash = $1`, codeHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.OAuthCodeNotFound
+		}
+		r.logger.Error("failed to find authorization code", zap.Error(err))
+		return nil, err
+	}
+
+	r.logger.Warn("authorization code reuse detected",
+		zap.String("client_id", code.ClientID),
+		zap.Int("user_id", code.UserID),
+		zap.String("session_id", code.SessionID))
+	return code, domain.OAuthCodeUsed
+}
@@ -0,0 +1,229 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var codeRowColumns = []string{"code_hash", "client_id", "user_id", "redirect_uri", "scopes", "code_challenge",
+	"expires_at", "used_at", "session_id", "created_at"}
+
+func TestOAuthRepository_FindClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockSetup  func(mock sqlmock.Sqlmock)
+		expectErr  error
+		wantPublic bool
+	}{
+		{
+			name: "Confidential",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "secret_hash", "name", "redirect_uris", "scopes", "owner_id", "created_at"}).
+					AddRow("forum-bot", "$argon2id$hash", "Forum bot", "{https://bot.example/cb}", "{topics:write,profile:read}", 1, time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM oauth_clients WHERE id = \\$1").
+					WithArgs("forum-bot").
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Public",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "secret_hash", "name", "redirect_uris", "scopes", "owner_id", "created_at"}).
+					AddRow("forum-bot", nil, "Forum bot", "{https://bot.example/cb}", "{topics:write,profile:read}", nil, time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM oauth_clients WHERE id = \\$1").
+					WithArgs("forum-bot").
+					WillReturnRows(rows)
+			},
+			wantPublic: true,
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM oauth_clients WHERE id = \\$1").
+					WithArgs("forum-bot").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.OAuthClientNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewOAuthRepository(db, nil)
+			client, err := repo.FindClient(context.Background(), "forum-bot")
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPublic, client.Public())
+				assert.Equal(t, []string{"https://bot.example/cb"}, client.RedirectURIs)
+				assert.Equal(t, []string{"topics:write", "profile:read"}, client.Scopes)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestOAuthRepository_HasClients(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM oauth_clients\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	repo := postgres.NewOAuthRepository(db, nil)
+	exists, err := repo.HasClients(context.Background())
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestOAuthRepository_ConsumeCode(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		mockSetup     func(mock sqlmock.Sqlmock)
+		expectErr     error
+		wantSessionID string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE oauth_codes SET used_at = NOW\\(\\), session_id = \\$2\\s+WHERE code_hash = \\$1 AND used_at IS NULL").
+					WithArgs("hash", "s1").
+					WillReturnRows(sqlmock.NewRows(codeRowColumns).
+						AddRow("hash", "forum-bot", 1, "https://bot.example/cb", "{topics:write}", "challenge", now.Add(time.Minute), now, "s1", now))
+			},
+			wantSessionID: "s1",
+		},
+		{
+			name: "Reused",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE oauth_codes").
+					WithArgs("hash", "s2").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM oauth_codes WHERE code_hash = \\$1").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(codeRowColumns).
+						AddRow("hash", "forum-bot", 1, "https://bot.example/cb", "{topics:write}", "challenge", now.Add(time.Minute), now, "s1", now))
+			},
+			expectErr:     domain.OAuthCodeUsed,
+			wantSessionID: "s1",
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE oauth_codes").
+					WithArgs("hash", "s2").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM oauth_codes WHERE code_hash = \\$1").
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.OAuthCodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			sessionID := "s2"
+			if tt.expectErr == nil {
+				sessionID = "s1"
+			}
+			repo := postgres.NewOAuthRepository(db, nil)
+			code, err := repo.ConsumeCode(context.Background(), "hash", sessionID)
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"topics:write"}, code.Scopes)
+			}
+			if tt.wantSessionID != "" {
+				require.NotNil(t, code)
+				assert.Equal(t, tt.wantSessionID, code.SessionID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestOAuthRepository_CreateCode_DeletesExpiredCodes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	code := &models.AuthorizationCode{CodeHash: "hash", ClientID: "forum-bot", UserID: 1,
+		RedirectURI: "https://bot.example/cb", Scopes: []string{"topics:write"}, CodeChallenge: "challenge",
+		ExpiresAt: now.Add(time.Minute)}
+
+	mock.ExpectQuery("INSERT INTO oauth_codes").
+		WithArgs("hash", "forum-bot", 1, "https://bot.example/cb", "{\"topics:write\"}", "challenge", code.ExpiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectExec("DELETE FROM oauth_codes WHERE expires_at < NOW\\(\\) - INTERVAL '1 day'").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	repo := postgres.NewOAuthRepository(db, nil)
+	require.NoError(t, repo.CreateCode(context.Background(), code))
+	assert.Equal(t, now, code.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestOAuthRepository_SaveConsent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO oauth_consents \\(user_id, client_id, scopes\\) VALUES \\(\\$1, \\$2, \\$3\\)\\s+ON CONFLICT").
+		WithArgs(1, "forum-bot", "{\"profile:read\"}").
+		WillReturnRows(sqlmock.NewRows([]string{"scopes", "granted_at"}).AddRow("{topics:write,profile:read}", time.Now()))
+
+	repo := postgres.NewOAuthRepository(db, nil)
+	consent := &models.OAuthConsent{UserID: 1, ClientID: "forum-bot", Scopes: []string{"profile:read"}}
+	err = repo.SaveConsent(context.Background(), consent)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"topics:write", "profile:read"}, consent.Scopes)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type PasswordResetRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPasswordResetRepository(db *sql.DB, logger *zap.Logger) repositories.PasswordResetRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &PasswordResetRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "password_reset_repository")),
+	}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	query := `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($1, $2, $3) RETURNING created_at`
+
+	r.logger.Debug("storing password reset token",
+		zap.Int("user_id", reset.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, reset.TokenHash, reset.UserID, reset.ExpiresAt).Scan(&reset.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to store password reset token",
+			zap.Int("user_id", reset.UserID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	var usedAt sql.NullTime
+	query := `SELECT token_hash, user_id, expires_at, used_at, created_at FROM password_resets WHERE token_hash = $1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash).
+		Scan(&reset.TokenHash, &reset.UserID, &reset.ExpiresAt, &usedAt, &reset.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("password reset token not found")
+			return nil, domain.TokenNotFound
+		}
+		r.logger.Error("failed to find password reset token", zap.Error(err))
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		reset.UsedAt = &usedAt.Time
+	}
+	return &reset, nil
+}
+
+// Use помечает токен использованным. Если токен уже использован или
+// истек, возвращает domain.InvalidToken, так что из двух
+// одновременных запросов с одним токеном пройдет только один.
+func (r *PasswordResetRepository) Use(ctx context.Context, tokenHash string) error {
+	query := `UPDATE password_resets SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		r.logger.Error("failed to use password reset token", zap.Error(err))
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		r.logger.Warn("password reset token already used or expired")
+		return domain.InvalidToken
+	}
+	return nil
+}
+
+func (r *PasswordResetRepository) DeleteForUser(ctx context.Context, userID int) error {
+	query := `DELETE FROM password_resets WHERE user_id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		r.logger.Error("failed to delete password reset tokens",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_FindByHash(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	createdAt := time.Now()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		expectErr error
+	}{
+		{
+			name: "Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"token_hash", "user_id", "expires_at", "used_at", "created_at"}).
+					AddRow("hash", 1, expiresAt, nil, createdAt)
+				mock.ExpectQuery("SELECT token_hash, user_id, expires_at, used_at, created_at FROM password_resets WHERE token_hash = \\$1").
+					WithArgs("hash").
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT token_hash, user_id, expires_at, used_at, created_at FROM password_resets WHERE token_hash = \\$1").
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.TokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewPasswordResetRepository(db, nil)
+			reset, err := repo.FindByHash(context.Background(), "hash")
+
+			if tt.expectErr != nil {
+				assert.Equal(t, tt.expectErr, err)
+				assert.Nil(t, reset)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, reset.UserID)
+				assert.True(t, reset.Active(time.Now()))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestPasswordResetRepository_Use(t *testing.T) {
+	tests := []struct {
+		name      string
+		affected  int64
+		expectErr error
+	}{
+		{name: "Fresh Token", affected: 1},
+		{name: "Used Or Expired Token", affected: 0, expectErr: domain.InvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectExec("UPDATE password_resets SET used_at = NOW\\(\\) WHERE token_hash = \\$1 AND used_at IS NULL AND expires_at > NOW\\(\\)").
+				WithArgs("hash").
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			repo := postgres.NewPasswordResetRepository(db, nil)
+			err = repo.Use(context.Background(), "hash")
+
+			assert.Equal(t, tt.expectErr, err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type PersonalTokenRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPersonalTokenRepository(db *sql.DB, logger *zap.Logger) repositories.PersonalTokenRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &PersonalTokenRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "personal_token_repository")),
+	}
+}
+
+const personalTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func scanPersonalToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, pq.Array(&token.Scopes),
+		&token.ExpiresAt, &lastUsedAt, &revokedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
+
+func (r *PersonalTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`
+
+	r.logger.Debug("creating personal access token",
+		zap.String("token_id", token.ID),
+		zap.Int("user_id", token.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, token.ID, token.UserID, token.Name, token.TokenHash,
+		pq.Array(token.Scopes), token.ExpiresAt).
+		Scan(&token.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to create personal access token",
+			zap.Int("user_id", token.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("personal access token created",
+		zap.String("token_id", token.ID),
+		zap.Int("user_id", token.UserID),
+		zap.Strings("scopes", token.Scopes))
+	return nil
+}
+
+func (r *PersonalTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `SELECT ` + personalTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+
+	token, err := scanPersonalToken(conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.PersonalTokenNotFound
+		}
+		r.logger.Error("failed to find personal access token", zap.Error(err))
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *PersonalTokenRepository) ListByUser(ctx context.Context, userID int) ([]*models.PersonalAccessToken, error) {
+	query := `SELECT ` + personalTokenColumns + ` FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	r.logger.Debug("listing personal access tokens",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list personal access tokens",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepository) Revoke(ctx context.Context, userID int, id string) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		r.logger.Error("failed to revoke personal access token",
+			zap.String("token_id", id),
+			zap.Error(err))
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.PersonalTokenNotFound
+	}
+
+	r.logger.Info("personal access token revoked",
+		zap.String("token_id", id),
+		zap.Int("user_id", userID))
+	return nil
+}
+
+// TouchLastUsed обновляет время использования не чаще раза в минуту,
+// чтобы скрипт, часто вызывающий API, не писал в базу на каждый запрос.
+func (r *PersonalTokenRepository) TouchLastUsed(ctx context.Context, id string) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.logger.Error("failed to touch personal access token",
+			zap.String("token_id", id),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var personalTokenRowColumns = []string{"id", "user_id", "name", "token_hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}
+
+func TestPersonalTokenRepository_FindByHash(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		expectErr error
+		wantUsed  bool
+	}{
+		{
+			name: "Never Used",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM personal_access_tokens WHERE token_hash = \\$1").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(personalTokenRowColumns).
+						AddRow("t1", 1, "release bot", "hash", "{topics:write}", now.Add(time.Hour), nil, nil, now))
+			},
+		},
+		{
+			name: "Used",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM personal_access_tokens WHERE token_hash = \\$1").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(personalTokenRowColumns).
+						AddRow("t1", 1, "release bot", "hash", "{topics:write}", now.Add(time.Hour), now, nil, now))
+			},
+			wantUsed: true,
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM personal_access_tokens WHERE token_hash = \\$1").
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.PersonalTokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewPersonalTokenRepository(db, nil)
+			token, err := repo.FindByHash(context.Background(), "hash")
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"topics:write"}, token.Scopes)
+				assert.Equal(t, tt.wantUsed, token.LastUsedAt != nil)
+				assert.True(t, token.Active(now))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestPersonalTokenRepository_Revoke(t *testing.T) {
+	tests := []struct {
+		name      string
+		affected  int64
+		expectErr error
+	}{
+		{name: "Success", affected: 1},
+		{name: "Not Found", affected: 0, expectErr: domain.PersonalTokenNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectExec("UPDATE personal_access_tokens SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+				WithArgs("t1", 1).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			repo := postgres.NewPersonalTokenRepository(db, nil)
+			err = repo.Revoke(context.Background(), 1, "t1")
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type SecondFactorRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSecondFactorRepository(db *sql.DB, logger *zap.Logger) repositories.SecondFactorRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SecondFactorRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "second_factor_repository")),
+	}
+}
+
+func (r *SecondFactorRepository) FindTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	var totp models.TOTP
+	var confirmedAt sql.NullTime
+	query := `SELECT user_id, secret, confirmed_at, last_used_step FROM user_totp WHERE user_id = $1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).
+		Scan(&totp.UserID, &totp.Secret, &confirmedAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.SecondFactorNotFound
+		}
+		r.logger.Error("failed to find totp",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	if confirmedAt.Valid {
+		totp.ConfirmedAt = &confirmedAt.Time
+	}
+	return &totp, nil
+}
+
+func (r *SecondFactorRepository) SaveTOTP(ctx context.Context, userID int, secret string) error {
+	query := `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = NOW()`
+
+	r.logger.Debug("saving pending totp",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, secret); err != nil {
+		r.logger.Error("failed to save totp",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *SecondFactorRepository) ConfirmTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE user_totp SET confirmed_at = NOW() WHERE user_id = $1`, userID); err != nil {
+		r.logger.Error("failed to confirm totp",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		r.logger.Error("failed to delete old recovery codes",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			r.logger.Error("failed to store recovery code",
+				zap.Int("user_id", userID),
+				zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("failed to commit totp confirmation", zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("totp enabled", zap.Int("user_id", userID))
+	return nil
+}
+
+func (r *SecondFactorRepository) MarkTOTPUsed(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, step)
+	if err != nil {
+		r.logger.Error("failed to mark totp code as used",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *SecondFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		r.logger.Error("failed to use recovery code",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		r.logger.Info("recovery code used", zap.Int("user_id", userID))
+	}
+	return affected > 0, nil
+}
+
+// ConsumeChallenge заодно удаляет истекшие записи: повторно предъявить
+// истекший токен все равно нельзя.
+func (r *SecondFactorRepository) ConsumeChallenge(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	query := `INSERT INTO used_mfa_challenges (id, expires_at) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, expiresAt)
+	if err != nil {
+		r.logger.Error("failed to consume mfa challenge", zap.Error(err))
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	cleanup := `DELETE FROM used_mfa_challenges WHERE expires_at < NOW()`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, cleanup); err != nil {
+		r.logger.Warn("failed to delete expired mfa challenges", zap.Error(err))
+	}
+	return affected == 1, nil
+}
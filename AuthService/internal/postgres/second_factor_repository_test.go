@@ -0,0 +1,139 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecondFactorRepository_FindTOTP_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, secret, confirmed_at, last_used_step FROM user_totp WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	repo := postgres.NewSecondFactorRepository(db, nil)
+	totp, err := repo.FindTOTP(context.Background(), 1)
+
+	assert.Nil(t, totp)
+	assert.Equal(t, domain.SecondFactorNotFound, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSecondFactorRepository_MarkTOTPUsed(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{name: "Fresh Code", affected: 1, expected: true},
+		{name: "Replayed Code", affected: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectExec("UPDATE user_totp SET last_used_step = \\$2 WHERE user_id = \\$1 AND last_used_step < \\$2").
+				WithArgs(1, int64(100)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			repo := postgres.NewSecondFactorRepository(db, nil)
+			ok, err := repo.MarkTOTPUsed(context.Background(), 1, 100)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSecondFactorRepository_ConsumeChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{name: "Fresh Challenge", affected: 1, expected: true},
+		{name: "Replayed Challenge", affected: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			expiresAt := time.Now().Add(5 * time.Minute)
+			mock.ExpectExec("INSERT INTO used_mfa_challenges \\(id, expires_at\\) VALUES \\(\\$1, \\$2\\) ON CONFLICT \\(id\\) DO NOTHING").
+				WithArgs("challenge-id", expiresAt).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			mock.ExpectExec("DELETE FROM used_mfa_challenges WHERE expires_at < NOW\\(\\)").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			repo := postgres.NewSecondFactorRepository(db, nil)
+			ok, err := repo.ConsumeChallenge(context.Background(), "challenge-id", expiresAt)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSecondFactorRepository_ConfirmTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE user_totp SET confirmed_at = NOW\\(\\)").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recovery_codes").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO recovery_codes").
+		WithArgs(1, "h1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO recovery_codes").
+		WithArgs(1, "h2").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	repo := postgres.NewSecondFactorRepository(db, nil)
+	assert.NoError(t, repo.ConfirmTOTP(context.Background(), 1, []string{"h1", "h2"}))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type SessionRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSessionRepository(db *sql.DB, logger *zap.Logger) repositories.SessionRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SessionRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "session_repository")),
+	}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, revoked_at, client_id`
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+	var revokedAt sql.NullTime
+	var clientID sql.NullString
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &revokedAt, &clientID)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	session.ClientID = clientID.String
+	return &session, nil
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip, client_id) VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING created_at, last_used_at`
+
+	r.logger.Debug("creating session",
+		zap.String("session_id", session.ID),
+		zap.Int("user_id", session.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, session.ID, session.UserID, session.UserAgent, session.IP, session.ClientID).
+		Scan(&session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		r.logger.Error("failed to create session",
+			zap.String("session_id", session.ID),
+			zap.Int("user_id", session.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("session created",
+		zap.String("session_id", session.ID),
+		zap.Int("user_id", session.UserID),
+		zap.String("ip", session.IP))
+	return nil
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	session, err := scanSession(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.SessionNotFound
+		}
+		r.logger.Error("failed to find session",
+			zap.String("session_id", id),
+			zap.Error(err))
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *SessionRepository) ListActive(ctx context.Context, userID int) ([]*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL
+		AND EXISTS (
+			SELECT 1 FROM refresh_tokens t
+			WHERE t.family_id = s.id AND t.revoked_at IS NULL AND t.expires_at > NOW()
+		)
+		ORDER BY s.last_used_at DESC`
+
+	r.logger.Debug("listing active sessions",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list sessions",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch обновляет время последнего использования и, если известны,
+// адрес и user agent, с которых сессия использовалась последний раз.
+func (r *SessionRepository) Touch(ctx context.Context, id string, client models.ClientInfo) error {
+	query := `UPDATE sessions SET last_used_at = NOW(),
+		ip = COALESCE(NULLIF($2, ''), ip),
+		user_agent = COALESCE(NULLIF($3, ''), user_agent)
+		WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, client.IP, client.UserAgent); err != nil {
+		r.logger.Error("failed to touch session",
+			zap.String("session_id", id),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, userID int, id string) error {
+	sessionQuery := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	tokensQuery := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	r.logger.Debug("revoking session",
+		zap.String("session_id", id),
+		zap.Int("user_id", userID))
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		res, err := conn(ctx, r.db).ExecContext(ctx, sessionQuery, id, userID)
+		if err != nil {
+			r.logger.Error("failed to revoke session",
+				zap.String("session_id", id),
+				zap.Error(err))
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return domain.SessionNotFound
+		}
+
+		if _, err := conn(ctx, r.db).ExecContext(ctx, tokensQuery, id); err != nil {
+			r.logger.Error("failed to revoke refresh tokens of session",
+				zap.String("session_id", id),
+				zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("session revoked",
+		zap.String("session_id", id),
+		zap.Int("user_id", userID))
+	return nil
+}
+
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID int, exceptID string) (int, error) {
+	sessionsQuery := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+	tokensQuery := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`
+
+	r.logger.Debug("revoking all sessions of user",
+		zap.Int("user_id", userID),
+		zap.String("except_session_id", exceptID))
+
+	var affected int64
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		res, err := conn(ctx, r.db).ExecContext(ctx, sessionsQuery, userID, exceptID)
+		if err != nil {
+			r.logger.Error("failed to revoke sessions of user",
+				zap.Int("user_id", userID),
+				zap.Error(err))
+			return err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return err
+		}
+
+		if _, err := conn(ctx, r.db).ExecContext(ctx, tokensQuery, userID, exceptID); err != nil {
+			r.logger.Error("failed to revoke refresh tokens of user",
+				zap.Int("user_id", userID),
+				zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("sessions of user revoked",
+		zap.Int("user_id", userID),
+		zap.Int64("revoked", affected))
+	return int(affected), nil
+}
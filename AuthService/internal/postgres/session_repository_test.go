@@ -0,0 +1,145 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionRowColumns = []string{"id", "user_id", "user_agent", "ip", "created_at", "last_used_at", "revoked_at", "client_id"}
+
+func TestSessionRepository_ListActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(sessionRowColumns).
+		AddRow("s2", 1, "Firefox", "10.0.0.2", now.Add(-time.Hour), now, nil, nil).
+		AddRow("s1", 1, "curl/8.0", "10.0.0.1", now.Add(-2*time.Hour), now.Add(-time.Hour), nil, "forum-bot")
+	mock.ExpectQuery("SELECT (.+) FROM sessions s\\s+WHERE s.user_id = \\$1 AND s.revoked_at IS NULL").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	repo := postgres.NewSessionRepository(db, nil)
+	sessions, err := repo.ListActive(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "s2", sessions[0].ID)
+	assert.Equal(t, "Firefox", sessions[0].UserAgent)
+	assert.True(t, sessions[1].Active())
+	assert.Empty(t, sessions[0].ClientID)
+	assert.Equal(t, "forum-bot", sessions[1].ClientID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSessionRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO sessions \\(id, user_id, user_agent, ip, client_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, NULLIF\\(\\$5, ''\\)\\)").
+		WithArgs("s1", 1, "Firefox", "10.0.0.1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "last_used_at"}).AddRow(now, now))
+
+	repo := postgres.NewSessionRepository(db, nil)
+	session := &models.Session{ID: "s1", UserID: 1, UserAgent: "Firefox", IP: "10.0.0.1"}
+	err = repo.Create(context.Background(), session)
+
+	require.NoError(t, err)
+	assert.Equal(t, now, session.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		expectErr error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+					WithArgs("s1", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE family_id = \\$1 AND revoked_at IS NULL").
+					WithArgs("s1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Foreign Or Revoked Session",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\)").
+					WithArgs("s1", 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectErr: domain.SessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewSessionRepository(db, nil)
+			err = repo.Revoke(context.Background(), 1, "s1")
+
+			assert.Equal(t, tt.expectErr, err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSessionRepository_RevokeAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\) WHERE user_id = \\$1 AND id <> \\$2 AND revoked_at IS NULL").
+		WithArgs(1, "current").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE user_id = \\$1 AND family_id <> \\$2 AND revoked_at IS NULL").
+		WithArgs(1, "current").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	repo := postgres.NewSessionRepository(db, nil)
+	revoked, err := repo.RevokeAll(context.Background(), 1, "current")
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, revoked)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
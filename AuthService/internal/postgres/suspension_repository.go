@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type SuspensionRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSuspensionRepository(db *sql.DB, logger *zap.Logger) repositories.SuspensionRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SuspensionRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "suspension_repository")),
+	}
+}
+
+func (r *SuspensionRepository) Find(ctx context.Context, userID int) (*models.Suspension, error) {
+	var suspension models.Suspension
+	var until sql.NullTime
+	var suspendedBy sql.NullInt64
+	query := `SELECT user_id, reason, suspended_until, suspended_by, created_at FROM user_suspensions WHERE user_id = $1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).
+		Scan(&suspension.UserID, &suspension.Reason, &until, &suspendedBy, &suspension.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.SuspensionNotFound
+		}
+		r.logger.Error("failed to find suspension",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	if until.Valid {
+		suspension.Until = &until.Time
+	}
+	suspension.SuspendedBy = int(suspendedBy.Int64)
+	return &suspension, nil
+}
+
+func (r *SuspensionRepository) Save(ctx context.Context, suspension *models.Suspension) error {
+	query := `INSERT INTO user_suspensions (user_id, reason, suspended_until, suspended_by) VALUES ($1, $2, $3, NULLIF($4, 0))
+		ON CONFLICT (user_id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			suspended_until = EXCLUDED.suspended_until,
+			suspended_by = EXCLUDED.suspended_by,
+			created_at = NOW()
+		RETURNING created_at`
+
+	r.logger.Debug("saving suspension",
+		zap.Int("user_id", suspension.UserID),
+		zap.String("query", query))
+
+	var until sql.NullTime
+	if suspension.Until != nil {
+		until = sql.NullTime{Time: *suspension.Until, Valid: true}
+	}
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, suspension.UserID, suspension.Reason, until, suspension.SuspendedBy).
+		Scan(&suspension.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to save suspension",
+			zap.Int("user_id", suspension.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("user suspended",
+		zap.Int("user_id", suspension.UserID),
+		zap.Int("suspended_by", suspension.SuspendedBy),
+		zap.Bool("permanent", suspension.Permanent()))
+	return nil
+}
+
+func (r *SuspensionRepository) Delete(ctx context.Context, userID int) error {
+	query := `DELETE FROM user_suspensions WHERE user_id = $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to delete suspension",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.SuspensionNotFound
+	}
+
+	r.logger.Info("user suspension lifted", zap.Int("user_id", userID))
+	return nil
+}
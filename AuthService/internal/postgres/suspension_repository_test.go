@@ -0,0 +1,117 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuspensionRepository_Find(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	createdAt := time.Now()
+
+	tests := []struct {
+		name          string
+		mockSetup     func(mock sqlmock.Sqlmock)
+		expectErr     error
+		wantPermanent bool
+	}{
+		{
+			name: "Temporary",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"user_id", "reason", "suspended_until", "suspended_by", "created_at"}).
+					AddRow(1, "spam", until, 2, createdAt)
+				mock.ExpectQuery("SELECT user_id, reason, suspended_until, suspended_by, created_at FROM user_suspensions WHERE user_id = \\$1").
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Permanent",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"user_id", "reason", "suspended_until", "suspended_by", "created_at"}).
+					AddRow(1, "spam", nil, nil, createdAt)
+				mock.ExpectQuery("SELECT user_id, reason, suspended_until, suspended_by, created_at FROM user_suspensions WHERE user_id = \\$1").
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+			wantPermanent: true,
+		},
+		{
+			name: "Not Found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT user_id, reason, suspended_until, suspended_by, created_at FROM user_suspensions WHERE user_id = \\$1").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectErr: domain.SuspensionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			repo := postgres.NewSuspensionRepository(db, nil)
+			suspension, err := repo.Find(context.Background(), 1)
+
+			if tt.expectErr != nil {
+				assert.Equal(t, tt.expectErr, err)
+				assert.Nil(t, suspension)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "spam", suspension.Reason)
+				assert.Equal(t, tt.wantPermanent, suspension.Permanent())
+				assert.True(t, suspension.Active(time.Now()))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestSuspensionRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name      string
+		affected  int64
+		expectErr error
+	}{
+		{name: "Suspended", affected: 1},
+		{name: "Not Suspended", affected: 0, expectErr: domain.SuspensionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectExec("DELETE FROM user_suspensions WHERE user_id = \\$1").
+				WithArgs(1).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			repo := postgres.NewSuspensionRepository(db, nil)
+			err = repo.Delete(context.Background(), 1)
+
+			assert.Equal(t, tt.expectErr, err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+type TokenRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTokenRepository(db *sql.DB, logger *zap.Logger) repositories.TokenRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TokenRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "token_repository")),
+	}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, family_id, user_id, expires_at) VALUES ($1, $2, $3, $4) RETURNING created_at`
+
+	r.logger.Debug("storing refresh token",
+		zap.String("token_id", token.ID),
+		zap.String("family_id", token.FamilyID),
+		zap.Int("user_id", token.UserID),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, token.ID, token.FamilyID, token.UserID, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to store refresh token",
+			zap.String("token_id", token.ID),
+			zap.Int("user_id", token.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Debug("refresh token stored",
+		zap.String("token_id", token.ID),
+		zap.Int("user_id", token.UserID))
+	return nil
+}
+
+func (r *TokenRepository) FindByID(ctx context.Context, id string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	var replacedBy sql.NullString
+	query := `SELECT id, family_id, user_id, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens WHERE id = $1`
+
+	r.logger.Debug("searching refresh token by ID",
+		zap.String("token_id", id),
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
+		Scan(&token.ID, &token.FamilyID, &token.UserID, &token.ExpiresAt, &revokedAt, &replacedBy, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("refresh token not found",
+				zap.String("token_id", id))
+			return nil, domain.TokenNotFound
+		}
+
+		r.logger.Error("failed to find refresh token",
+			zap.String("token_id", id),
+			zap.Error(err))
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	token.ReplacedBy = replacedBy.String
+	return &token, nil
+}
+
+func (r *TokenRepository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	r.logger.Debug("revoking refresh token",
+		zap.String("token_id", id),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.logger.Error("failed to revoke refresh token",
+			zap.String("token_id", id),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("refresh token revoked",
+		zap.String("token_id", id))
+	return nil
+}
+
+func (r *TokenRepository) Rotate(ctx context.Context, oldID string, next *models.RefreshToken) error {
+	revokeQuery := `UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2 WHERE id = $1 AND revoked_at IS NULL`
+	insertQuery := `INSERT INTO refresh_tokens (id, family_id, user_id, expires_at) VALUES ($1, $2, $3, $4) RETURNING created_at`
+
+	r.logger.Debug("rotating refresh token",
+		zap.String("token_id", oldID),
+		zap.String("next_token_id", next.ID),
+		zap.String("family_id", next.FamilyID))
+
+	// Если ctx уже несет транзакцию вызывающего, ротация выполняется в ней.
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		res, err := conn(ctx, r.db).ExecContext(ctx, revokeQuery, oldID, next.ID)
+		if err != nil {
+			r.logger.Error("failed to revoke rotated refresh token",
+				zap.String("token_id", oldID),
+				zap.Error(err))
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			r.logger.Warn("refresh token was already rotated",
+				zap.String("token_id", oldID))
+			return domain.TokenReused
+		}
+
+		err = conn(ctx, r.db).QueryRowContext(ctx, insertQuery, next.ID, next.FamilyID, next.UserID, next.ExpiresAt).Scan(&next.CreatedAt)
+		if err != nil {
+			r.logger.Error("failed to store rotated refresh token",
+				zap.String("token_id", next.ID),
+				zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.logger.Debug("refresh token rotated",
+		zap.String("token_id", oldID),
+		zap.String("next_token_id", next.ID))
+	return nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	r.logger.Debug("revoking refresh token family",
+		zap.String("family_id", familyID),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, familyID); err != nil {
+		r.logger.Error("failed to revoke refresh token family",
+			zap.String("family_id", familyID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("refresh token family revoked",
+		zap.String("family_id", familyID))
+	return nil
+}
+
+func (r *TokenRepository) RevokeUser(ctx context.Context, userID int) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	r.logger.Debug("revoking all refresh tokens of user",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		r.logger.Error("failed to revoke refresh tokens of user",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("all refresh tokens of user revoked",
+		zap.Int("user_id", userID))
+	return nil
+}
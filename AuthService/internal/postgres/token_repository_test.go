@@ -0,0 +1,225 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRepository_Create(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		token       *models.RefreshToken
+		mock        func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name:  "Success",
+			token: &models.RefreshToken{ID: "abc", FamilyID: "fam", UserID: 1, ExpiresAt: expiresAt},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO refresh_tokens").
+					WithArgs("abc", "fam", 1, expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "Database Error",
+			token: &models.RefreshToken{ID: "abc", FamilyID: "fam", UserID: 1, ExpiresAt: expiresAt},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO refresh_tokens").
+					WithArgs("abc", "fam", 1, expiresAt).
+					WillReturnError(errors.New("database error"))
+			},
+			expectedErr: errors.New("database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			tt.mock(mock)
+
+			repo := postgres.NewTokenRepository(db, nil)
+			err = repo.Create(context.Background(), tt.token)
+
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, createdAt, tt.token.CreatedAt)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestTokenRepository_FindByID(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC)
+	revokedAt := time.Date(2029, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		id          string
+		mock        func(mock sqlmock.Sqlmock)
+		expected    *models.RefreshToken
+		expectedErr error
+	}{
+		{
+			name: "Active",
+			id:   "abc",
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "family_id", "user_id", "expires_at", "revoked_at", "replaced_by", "created_at"}).
+					AddRow("abc", "fam", 1, expiresAt, nil, nil, createdAt)
+				mock.ExpectQuery("SELECT id, family_id, user_id, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens WHERE id = \\$1").
+					WithArgs("abc").
+					WillReturnRows(rows)
+			},
+			expected:    &models.RefreshToken{ID: "abc", FamilyID: "fam", UserID: 1, ExpiresAt: expiresAt, CreatedAt: createdAt},
+			expectedErr: nil,
+		},
+		{
+			name: "Revoked",
+			id:   "abc",
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "family_id", "user_id", "expires_at", "revoked_at", "replaced_by", "created_at"}).
+					AddRow("abc", "fam", 1, expiresAt, revokedAt, "def", createdAt)
+				mock.ExpectQuery("SELECT id, family_id, user_id, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens WHERE id = \\$1").
+					WithArgs("abc").
+					WillReturnRows(rows)
+			},
+			expected:    &models.RefreshToken{ID: "abc", FamilyID: "fam", UserID: 1, ExpiresAt: expiresAt, RevokedAt: &revokedAt, ReplacedBy: "def", CreatedAt: createdAt},
+			expectedErr: nil,
+		},
+		{
+			name: "Token Not Found",
+			id:   "missing",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT id, family_id, user_id, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens WHERE id = \\$1").
+					WithArgs("missing").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expected:    nil,
+			expectedErr: domain.TokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			tt.mock(mock)
+
+			repo := postgres.NewTokenRepository(db, nil)
+			token, err := repo.FindByID(context.Background(), tt.id)
+
+			assert.Equal(t, tt.expected, token)
+			assert.Equal(t, tt.expectedErr, err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestTokenRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND revoked_at IS NULL").
+		WithArgs("abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := postgres.NewTokenRepository(db, nil)
+	assert.NoError(t, repo.Revoke(context.Background(), "abc"))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTokenRepository_Rotate(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		mock        func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\), replaced_by = \\$2").
+					WithArgs("old", "new").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO refresh_tokens").
+					WithArgs("new", "fam", 1, expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+				mock.ExpectCommit()
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "Already Rotated",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\), replaced_by = \\$2").
+					WithArgs("old", "new").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: domain.TokenReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			tt.mock(mock)
+
+			repo := postgres.NewTokenRepository(db, nil)
+			next := &models.RefreshToken{ID: "new", FamilyID: "fam", UserID: 1, ExpiresAt: expiresAt}
+			err = repo.Rotate(context.Background(), "old", next)
+
+			assert.Equal(t, tt.expectedErr, err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
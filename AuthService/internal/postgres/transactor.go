@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type txKey struct{}
+
+// querier - общие методы *sql.DB и *sql.Tx, которыми пользуются
+// репозитории.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn возвращает транзакцию, открытую WithinTx, если ctx получен внутри
+// нее, и db в остальных случаях.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type Transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor возвращает Transactor для репозиториев этого пакета,
+// созданных с тем же db. Методы, которые сами открывают транзакцию
+// (SecondFactorRepository.ConfirmTOTP), выполняются отдельно от нее и
+// внутри WithinTx не вызываются. Остальные, которым нужно несколько
+// запросов (TokenRepository.Rotate, SessionRepository.Revoke и RevokeAll),
+// открывают транзакцию через withinTx и присоединяются к транзакции ctx.
+func NewTransactor(db *sql.DB) repositories.Transactor {
+	return &Transactor{db: db}
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.db, fn)
+}
+
+func withinTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
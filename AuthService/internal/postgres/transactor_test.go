@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor_WithinTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	users := postgres.NewUserRepository(db, nil)
+	tx := postgres.NewTransactor(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs("alice", "hash", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO user_roles").
+		WithArgs(1, "user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		user := &models.User{Username: "alice", Password: "hash"}
+		if err := users.Create(ctx, user); err != nil {
+			return err
+		}
+		return users.GrantRole(ctx, user.ID, models.RoleUser)
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithinTxRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	users := postgres.NewUserRepository(db, nil)
+	tx := postgres.NewTransactor(db)
+
+	failed := errors.New("connection reset")
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs("alice", "hash", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO user_roles").
+		WithArgs(1, "user").
+		WillReturnError(failed)
+	mock.ExpectRollback()
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		user := &models.User{Username: "alice", Password: "hash"}
+		if err := users.Create(ctx, user); err != nil {
+			return err
+		}
+		// Вложенный вызов выполняется в той же транзакции.
+		return tx.WithinTx(ctx, func(ctx context.Context) error {
+			return users.GrantRole(ctx, user.ID, models.RoleUser)
+		})
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithinTxJoinsRotateAndRevokeAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	tokens := postgres.NewTokenRepository(db, nil)
+	sessions := postgres.NewSessionRepository(db, nil)
+	tx := postgres.NewTransactor(db)
+
+	// Одна транзакция на все запросы: Rotate и RevokeAll не открывают свою.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\), replaced_by = \\$2").
+		WithArgs("old", "new").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO refresh_tokens").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\)").
+		WithArgs(1, "fam").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE user_id").
+		WithArgs(1, "fam").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		next := &models.RefreshToken{ID: "new", FamilyID: "fam", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := tokens.Rotate(ctx, "old", next); err != nil {
+			return err
+		}
+		_, err := sessions.RevokeAll(ctx, 1, "fam")
+		return err
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go.uber.org/zap"
+)
+
+type UserEventRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewUserEventRepository(db *sql.DB, logger *zap.Logger) repositories.UserEventRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &UserEventRepository{
+		db:     db,
+		logger: logger.With(zap.String("component", "user_event_repository")),
+	}
+}
+
+// Append берет транзакционную advisory блокировку ленты перед вставкой.
+// Без нее транзакции могли бы зафиксировать события не в порядке id, и
+// подписчик, уже прочитавший id N+1, пропустил бы позже
+// зафиксированный id N. Блокировка держится до конца транзакции, поэтому
+// событие записывается последним действием транзакции.
+func (r *UserEventRepository) Append(ctx context.Context, event *models.UserEvent) error {
+	lock := `SELECT pg_advisory_xact_lock(hashtext('user_events'))`
+	query := `INSERT INTO user_events (event_type, user_id, data) VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	data := event.Data
+	if data == nil {
+		data = map[string]string{}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	err = withinTx(ctx, r.db, func(ctx context.Context) error {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, lock); err != nil {
+			return err
+		}
+		return conn(ctx, r.db).QueryRowContext(ctx, query, string(event.Type), event.UserID, encoded).
+			Scan(&event.ID, &event.CreatedAt)
+	})
+	if err != nil {
+		r.logger.Error("failed to append user event",
+			zap.String("event_type", string(event.Type)),
+			zap.Int("user_id", event.UserID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *UserEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*models.UserEvent, error) {
+	query := `SELECT id, event_type, user_id, data, created_at FROM user_events WHERE id > $1 ORDER BY id LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		r.logger.Error("failed to list user events",
+			zap.Int64("after_id", afterID),
+			zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.UserEvent
+	for rows.Next() {
+		var event models.UserEvent
+		var eventType string
+		var data []byte
+		if err := rows.Scan(&event.ID, &eventType, &event.UserID, &data, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &event.Data); err != nil {
+			return nil, err
+		}
+		event.Type = models.UserEventType(eventType)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *UserEventRepository) LastID(ctx context.Context) (int64, error) {
+	query := `SELECT COALESCE(MAX(id), 0) FROM user_events`
+
+	var id int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query).Scan(&id); err != nil {
+		r.logger.Error("failed to find last user event", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,86 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEventRepository_Append(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock\\(hashtext\\('user_events'\\)\\)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO user_events").
+		WithArgs("roles_changed", 3, []byte(`{"action":"granted","role":"moderator"}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(12, now))
+	mock.ExpectCommit()
+
+	repo := postgres.NewUserEventRepository(db, nil)
+	event := &models.UserEvent{
+		Type:   models.RolesChanged,
+		UserID: 3,
+		Data:   map[string]string{"role": "moderator", "action": "granted"},
+	}
+	require.NoError(t, repo.Append(context.Background(), event))
+	assert.Equal(t, int64(12), event.ID)
+	assert.Equal(t, now, event.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserEventRepository_ListAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM user_events WHERE id > \\$1 ORDER BY id LIMIT \\$2").
+		WithArgs(int64(5), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "user_id", "data", "created_at"}).
+			AddRow(6, "user_created", 1, []byte(`{"username":"alice"}`), now).
+			AddRow(7, "user_suspended", 1, []byte(`{"reason":"spam"}`), now))
+
+	repo := postgres.NewUserEventRepository(db, nil)
+	events, err := repo.ListAfter(context.Background(), 5, 2)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(6), events[0].ID)
+	assert.Equal(t, "alice", events[0].Data["username"])
+	assert.Equal(t, models.UserSuspended, events[1].Type)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserEventRepository_LastID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), 0\\) FROM user_events").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(42))
+
+	repo := postgres.NewUserEventRepository(db, nil)
+	id, err := repo.LastID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const profileColumns = `id, username, display_name, bio, avatar_url, created_at, last_seen`
+
+// likeEscaper экранирует спецсимволы шаблона LIKE в поисковом запросе.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProfile(row rowScanner) (*models.Profile, error) {
+	var profile models.Profile
+	var lastSeen sql.NullTime
+	err := row.Scan(&profile.UserID, &profile.Username, &profile.DisplayName, &profile.Bio,
+		&profile.AvatarURL, &profile.CreatedAt, &lastSeen)
+	if err != nil {
+		return nil, err
+	}
+	if lastSeen.Valid {
+		profile.LastSeen = &lastSeen.Time
+	}
+	return &profile, nil
+}
+
+func (r *UserRepository) queryProfiles(ctx context.Context, query string, args ...interface{}) ([]*models.Profile, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to load profiles", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []*models.Profile
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+func (r *UserRepository) Profiles(ctx context.Context, ids []int, usernames []string) ([]*models.Profile, error) {
+	query := `SELECT ` + profileColumns + ` FROM users WHERE id = ANY($1) OR username = ANY($2) ORDER BY id`
+
+	r.logger.Debug("loading profiles",
+		zap.Ints("user_ids", ids),
+		zap.Strings("usernames", usernames),
+		zap.String("query", query))
+
+	ids64 := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		ids64 = append(ids64, int64(id))
+	}
+	return r.queryProfiles(ctx, query, pq.Array(ids64), pq.Array(usernames))
+}
+
+// SearchProfiles ищет по вхождению в имя пользователя или отображаемое
+// имя. Страницы строятся по id: следующая начинается после afterID.
+func (r *UserRepository) SearchProfiles(ctx context.Context, query string, afterID, limit int) ([]*models.Profile, error) {
+	sqlQuery := `SELECT ` + profileColumns + ` FROM users
+		WHERE (username ILIKE $1 OR display_name ILIKE $1) AND id > $2
+		ORDER BY id LIMIT $3`
+
+	r.logger.Debug("searching profiles",
+		zap.String("search", query),
+		zap.Int("after_id", afterID),
+		zap.String("query", sqlQuery))
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	return r.queryProfiles(ctx, sqlQuery, pattern, afterID, limit)
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (*models.Profile, error) {
+	query := `UPDATE users SET
+			display_name = COALESCE($2, display_name),
+			bio = COALESCE($3, bio),
+			avatar_url = COALESCE($4, avatar_url)
+		WHERE id = $1
+		RETURNING ` + profileColumns
+
+	r.logger.Debug("updating profile",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	profile, err := scanProfile(conn(ctx, r.db).QueryRowContext(ctx, query, userID,
+		nullString(update.DisplayName), nullString(update.Bio), nullString(update.AvatarURL)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.UserNotFound
+		}
+		r.logger.Error("failed to update profile",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	r.logger.Info("profile updated", zap.Int("user_id", userID))
+	return profile, nil
+}
+
+func (r *UserRepository) TouchLastSeen(ctx context.Context, userID int) error {
+	query := `UPDATE users SET last_seen = NOW() WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		r.logger.Error("failed to update last seen",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
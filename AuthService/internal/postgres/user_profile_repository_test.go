@@ -0,0 +1,123 @@
+package postgres_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/postgres"
+
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var profileRowColumns = []string{"id", "username", "display_name", "bio", "avatar_url", "created_at", "last_seen"}
+
+func TestUserRepository_Profiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastSeen := createdAt.Add(time.Hour)
+	rows := sqlmock.NewRows(profileRowColumns).
+		AddRow(1, "alice", "Alice", "", "", createdAt, lastSeen).
+		AddRow(2, "bob", "", "hi", "https://example.com/bob.png", createdAt, nil)
+	mock.ExpectQuery("SELECT id, username, display_name, bio, avatar_url, created_at, last_seen FROM users WHERE id = ANY\\(\\$1\\) OR username = ANY\\(\\$2\\) ORDER BY id").
+		WithArgs(pq.Array([]int64{1}), pq.Array([]string{"bob"})).
+		WillReturnRows(rows)
+
+	repo := postgres.NewUserRepository(db, nil)
+	profiles, err := repo.Profiles(context.Background(), []int{1}, []string{"bob"})
+
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "Alice", profiles[0].DisplayName)
+	require.NotNil(t, profiles[0].LastSeen)
+	assert.Equal(t, lastSeen, *profiles[0].LastSeen)
+	assert.Nil(t, profiles[1].LastSeen)
+	assert.Equal(t, "https://example.com/bob.png", profiles[1].AvatarURL)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserRepository_SearchProfiles_EscapesPattern(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM users\\s+WHERE \\(username ILIKE \\$1 OR display_name ILIKE \\$1\\) AND id > \\$2").
+		WithArgs(`%50\%\_off%`, 10, 21).
+		WillReturnRows(sqlmock.NewRows(profileRowColumns))
+
+	repo := postgres.NewUserRepository(db, nil)
+	profiles, err := repo.SearchProfiles(context.Background(), "50%_off", 10, 21)
+
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserRepository_UpdateProfile(t *testing.T) {
+	bio := "new bio"
+
+	tests := []struct {
+		name        string
+		mock        func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE users SET").
+					WithArgs(1, sql.NullString{}, sql.NullString{String: bio, Valid: true}, sql.NullString{}).
+					WillReturnRows(sqlmock.NewRows(profileRowColumns).
+						AddRow(1, "alice", "", bio, "", time.Now(), nil))
+			},
+		},
+		{
+			name: "User Not Found",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE users SET").
+					WithArgs(1, sql.NullString{}, sql.NullString{String: bio, Valid: true}, sql.NullString{}).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: domain.UserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mock(mock)
+
+			repo := postgres.NewUserRepository(db, nil)
+			profile, err := repo.UpdateProfile(context.Background(), 1, models.ProfileUpdate{Bio: &bio})
+
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				assert.Nil(t, profile)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, bio, profile.Bio)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
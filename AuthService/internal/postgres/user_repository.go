@@ -7,15 +7,25 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// Ограничения уникальности таблицы users из миграций 001 и 009.
+const (
+	usersUsernameKey   = "users_username_key"
+	usersEmailLowerIdx = "idx_users_email_lower"
+)
+
 type UserRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
 }
 
 func NewUserRepository(db *sql.DB, logger *zap.Logger) repositories.UserRepo {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 	return &UserRepository{
 		db:     db,
 		logger: logger.With(zap.String("component", "user_repository")),
@@ -23,14 +33,27 @@ func NewUserRepository(db *sql.DB, logger *zap.Logger) repositories.UserRepo {
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id`
+	query := `INSERT INTO users (username, password_hash, email) VALUES ($1, $2, NULLIF($3, '')) RETURNING id`
 
 	r.logger.Debug("creating new user",
 		zap.String("username", user.Username),
 		zap.String("query", query))
 
-	err := r.db.QueryRowContext(ctx, query, user.Username, user.Password).Scan(&user.ID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, user.Username, user.Password, user.Email).Scan(&user.ID)
 	if err != nil {
+		// Проверка занятости имени и адреса в usecase не защищает от
+		// параллельной регистрации: проигравший запрос получает нарушение
+		// ограничения уникальности.
+		switch uniqueViolation(err) {
+		case usersUsernameKey:
+			r.logger.Warn("username already taken",
+				zap.String("username", user.Username))
+			return domain.UserAlreadyExists
+		case usersEmailLowerIdx:
+			r.logger.Warn("email already taken",
+				zap.String("username", user.Username))
+			return domain.EmailAlreadyExists
+		}
 		r.logger.Error("failed to create user",
 			zap.String("username", user.Username),
 			zap.Error(err))
@@ -45,13 +68,13 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, password_hash FROM users WHERE username = $1`
+	query := `SELECT id, username, password_hash, COALESCE(email, ''), email_verified FROM users WHERE username = $1`
 
 	r.logger.Debug("searching user by username",
 		zap.String("username", username),
 		zap.String("query", query))
 
-	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.EmailVerified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn("user not found",
@@ -73,13 +96,13 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*
 
 func (r *UserRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, password_hash FROM users WHERE id = $1`
+	query := `SELECT id, username, password_hash, COALESCE(email, ''), email_verified FROM users WHERE id = $1`
 
 	r.logger.Debug("searching user by ID",
 		zap.Int("user_id", id),
 		zap.String("query", query))
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.EmailVerified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn("user not found",
@@ -98,3 +121,167 @@ func (r *UserRepository) FindByID(ctx context.Context, id int) (*models.User, er
 		zap.String("username", user.Username))
 	return &user, nil
 }
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	query := `SELECT id, username, password_hash, COALESCE(email, ''), email_verified FROM users WHERE LOWER(email) = LOWER($1)`
+
+	r.logger.Debug("searching user by email",
+		zap.String("query", query))
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.EmailVerified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.UserNotFound
+		}
+
+		r.logger.Error("failed to find user by email",
+			zap.Error(err))
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MarkEmailVerified подтверждает адрес, только если он не изменился
+// с момента выдачи токена подтверждения.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int, email string) (bool, error) {
+	query := `UPDATE users SET email_verified = TRUE WHERE id = $1 AND LOWER(email) = LOWER($2)`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, email)
+	if err != nil {
+		r.logger.Error("failed to mark email as verified",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		r.logger.Info("email verified", zap.Int("user_id", userID))
+	}
+	return affected > 0, nil
+}
+
+func (r *UserRepository) Roles(ctx context.Context, userID int) ([]models.Role, error) {
+	query := `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`
+
+	r.logger.Debug("loading user roles",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to load user roles",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *UserRepository) GrantRole(ctx context.Context, userID int, role models.Role) error {
+	query := `INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	r.logger.Debug("granting role",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, role); err != nil {
+		if foreignKeyViolation(err) {
+			r.logger.Warn("role granted to unknown user",
+				zap.Int("user_id", userID))
+			return domain.UserNotFound
+		}
+		r.logger.Error("failed to grant role",
+			zap.Int("user_id", userID),
+			zap.String("role", string(role)),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("role granted",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)))
+	return nil
+}
+
+func (r *UserRepository) RevokeRole(ctx context.Context, userID int, role models.Role) error {
+	query := `DELETE FROM user_roles WHERE user_id = $1 AND role = $2`
+
+	r.logger.Debug("revoking role",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)),
+		zap.String("query", query))
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, role); err != nil {
+		r.logger.Error("failed to revoke role",
+			zap.Int("user_id", userID),
+			zap.String("role", string(role)),
+			zap.Error(err))
+		return err
+	}
+
+	r.logger.Info("role revoked",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)))
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2 WHERE id = $1`
+
+	r.logger.Debug("updating user password",
+		zap.Int("user_id", userID),
+		zap.String("query", query))
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		r.logger.Error("failed to update user password",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.UserNotFound
+	}
+
+	r.logger.Info("user password updated",
+		zap.Int("user_id", userID))
+	return nil
+}
+
+// foreignKeyViolation сообщает, что запись ссылается на несуществующую
+// строку, например роль выдается удаленному пользователю.
+func foreignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// uniqueViolation возвращает имя нарушенного ограничения уникальности или
+// пустую строку, если err другая ошибка.
+func uniqueViolation(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint
+	}
+	return ""
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,7 +30,7 @@ func TestUserRepository_Create(t *testing.T) {
 			},
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO users").
-					WithArgs("testuser", "hashedpassword").
+					WithArgs("testuser", "hashedpassword", "").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 			},
 			expectedErr: nil,
@@ -42,10 +43,37 @@ func TestUserRepository_Create(t *testing.T) {
 			},
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO users").
-					WithArgs("existinguser", "hashedpassword").
-					WillReturnError(errors.New("duplicate key value violates unique constraint"))
+					WithArgs("existinguser", "hashedpassword", "").
+					WillReturnError(&pq.Error{Code: "23505", Constraint: "users_username_key"})
 			},
-			expectedErr: errors.New("duplicate key value violates unique constraint"),
+			expectedErr: domain.UserAlreadyExists,
+		},
+		{
+			name: "Duplicate Email",
+			user: &models.User{
+				Username: "newuser",
+				Password: "hashedpassword",
+				Email:    "Taken@example.com",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO users").
+					WithArgs("newuser", "hashedpassword", "Taken@example.com").
+					WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_users_email_lower"})
+			},
+			expectedErr: domain.EmailAlreadyExists,
+		},
+		{
+			name: "Database Error",
+			user: &models.User{
+				Username: "newuser",
+				Password: "hashedpassword",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO users").
+					WithArgs("newuser", "hashedpassword", "").
+					WillReturnError(errors.New("connection reset"))
+			},
+			expectedErr: errors.New("connection reset"),
 		},
 	}
 
@@ -86,9 +114,9 @@ func TestUserRepository_FindByUsername(t *testing.T) {
 			name:     "Success",
 			username: "testuser",
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "username", "password_hash"}).
-					AddRow(1, "testuser", "hashedpassword")
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE username = \\$1").
+				rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "email", "email_verified"}).
+					AddRow(1, "testuser", "hashedpassword", "", false)
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE username = \\$1").
 					WithArgs("testuser").
 					WillReturnRows(rows)
 			},
@@ -103,7 +131,7 @@ func TestUserRepository_FindByUsername(t *testing.T) {
 			name:     "User Not Found",
 			username: "nonexistent",
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE username = \\$1").
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE username = \\$1").
 					WithArgs("nonexistent").
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -114,7 +142,7 @@ func TestUserRepository_FindByUsername(t *testing.T) {
 			name:     "Database Error",
 			username: "testuser",
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE username = \\$1").
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE username = \\$1").
 					WithArgs("testuser").
 					WillReturnError(errors.New("database error"))
 			},
@@ -158,9 +186,9 @@ func TestUserRepository_FindByID(t *testing.T) {
 			name: "Success",
 			id:   1,
 			mock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "username", "password_hash"}).
-					AddRow(1, "testuser", "hashedpassword")
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE id = \\$1").
+				rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "email", "email_verified"}).
+					AddRow(1, "testuser", "hashedpassword", "", false)
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE id = \\$1").
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -175,7 +203,7 @@ func TestUserRepository_FindByID(t *testing.T) {
 			name: "User Not Found",
 			id:   999,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE id = \\$1").
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE id = \\$1").
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -186,7 +214,7 @@ func TestUserRepository_FindByID(t *testing.T) {
 			name: "Database Error",
 			id:   1,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, username, password_hash FROM users WHERE id = \\$1").
+				mock.ExpectQuery("SELECT id, username, password_hash, COALESCE\\(email, ''\\), email_verified FROM users WHERE id = \\$1").
 					WithArgs(1).
 					WillReturnError(errors.New("database error"))
 			},
@@ -217,3 +245,68 @@ func TestUserRepository_FindByID(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepository_Roles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"role"}).AddRow("moderator").AddRow("user")
+	mock.ExpectQuery("SELECT role FROM user_roles WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	repo := postgres.NewUserRepository(db, nil)
+	roles, err := repo.Roles(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleModerator, models.RoleUser}, roles)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserRepository_GrantAndRevokeRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO user_roles").
+		WithArgs(1, models.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user_roles WHERE user_id = \\$1 AND role = \\$2").
+		WithArgs(1, models.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := postgres.NewUserRepository(db, nil)
+	assert.NoError(t, repo.GrantRole(context.Background(), 1, models.RoleAdmin))
+	assert.NoError(t, repo.RevokeRole(context.Background(), 1, models.RoleAdmin))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUserRepository_GrantRole_UnknownUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO user_roles").
+		WithArgs(42, models.RoleAdmin).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "user_roles_user_id_fkey"})
+
+	repo := postgres.NewUserRepository(db, nil)
+	assert.Equal(t, domain.UserNotFound, repo.GrantRole(context.Background(), 42, models.RoleAdmin))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
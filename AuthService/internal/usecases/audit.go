@@ -0,0 +1,143 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+const (
+	reasonEventTypesUnknown = "event_types_unknown"
+	reasonEndTimeInvalid    = "end_time_invalid"
+)
+
+// recordAudit добавляет событие в журнал аудита. Запись не зависит от
+// отмены запроса, а ее ошибка только логируется: недоступность журнала
+// не должна мешать входу.
+func (s *AuthServiceStruct) recordAudit(ctx context.Context, event *models.AuditEvent, client models.ClientInfo) {
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+	event.RequestID = client.RequestID
+
+	if err := s.audit.Append(context.WithoutCancel(ctx), event); err != nil {
+		s.logger.Error("failed to record audit event",
+			zap.String("event_type", string(event.Type)),
+			zap.Int("user_id", event.UserID),
+			zap.String("request_id", event.RequestID),
+			zap.Error(err))
+	}
+}
+
+// ListAuditEvents возвращает страницу журнала аудита от новых записей к
+// старым и токен следующей страницы; пустой токен означает, что записей
+// больше нет.
+func (s *AuthServiceStruct) ListAuditEvents(ctx context.Context, filter models.AuditFilter, pageSize int, pageToken string) ([]*models.AuditEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	if pageSize > maxAuditPageSize {
+		pageSize = maxAuditPageSize
+	}
+
+	var reasons []string
+	if pageToken != "" {
+		beforeID, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil || beforeID <= 0 {
+			reasons = append(reasons, reasonPageTokenInvalid)
+		}
+		filter.BeforeID = beforeID
+	}
+	for _, eventType := range filter.Types {
+		if !eventType.Valid() {
+			reasons = append(reasons, reasonEventTypesUnknown)
+			break
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		reasons = append(reasons, reasonEndTimeInvalid)
+	}
+	if len(reasons) > 0 {
+		return nil, "", &domain.ValidationError{Reasons: reasons}
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница.
+	filter.Limit = pageSize + 1
+	events, err := s.audit.List(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		next = strconv.FormatInt(events[pageSize-1].ID, 10)
+	}
+	return events, next, nil
+}
+
+// AuditRetention периодически удаляет записи журнала аудита старше
+// срока хранения.
+type AuditRetention struct {
+	repo      repositories.AuditRepo
+	retention time.Duration
+	interval  time.Duration
+	logger    *zap.Logger
+}
+
+func NewAuditRetention(repo repositories.AuditRepo, retention, interval time.Duration, logger *zap.Logger) *AuditRetention {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AuditRetention{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		logger:    logger.With(zap.String("component", "audit_retention")),
+	}
+}
+
+// Run удаляет устаревшие записи сразу и затем каждые interval до отмены
+// ctx. Нулевой срок хранения отключает удаление.
+func (r *AuditRetention) Run(ctx context.Context) {
+	if r.retention <= 0 || r.interval <= 0 {
+		r.logger.Info("audit log retention disabled")
+		return
+	}
+	r.Prune(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Prune(ctx)
+		}
+	}
+}
+
+// Prune выполняет одно удаление и возвращает число удаленных записей.
+func (r *AuditRetention) Prune(ctx context.Context) int64 {
+	before := time.Now().Add(-r.retention)
+	deleted, err := r.repo.DeleteBefore(ctx, before)
+	if err != nil {
+		r.logger.Error("failed to prune audit log", zap.Error(err))
+		return 0
+	}
+	if deleted > 0 {
+		r.logger.Info("audit log pruned",
+			zap.Int64("deleted", deleted),
+			zap.Time("before", before))
+	}
+	return deleted
+}
@@ -0,0 +1,100 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/memory"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listAudit возвращает записи журнала в порядке их появления.
+func listAudit(t *testing.T, s *AuthServiceStruct) []*models.AuditEvent {
+	events, err := s.audit.List(context.Background(), models.AuditFilter{Limit: 100})
+	require.NoError(t, err)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events
+}
+
+func TestLogin_RecordsAuditEvents(t *testing.T) {
+	s := newTestService(t)
+	// Неудачные попытки не должны блокировать следующий вход.
+	s.throttle.backoffBase, s.throttle.lockout = 0, 0
+	ctx := context.Background()
+	client := models.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8.0", RequestID: "req-1"}
+
+	require.NoError(t, s.Register(ctx, "alice", "correct horse battery", "", client))
+	_, err := s.Login(ctx, "alice", "wrong password", client)
+	assert.ErrorIs(t, err, domain.InvalidData)
+	_, err = s.Login(ctx, "bob", "wrong password", client)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	_, err = s.Login(ctx, "alice", "correct horse battery", client)
+	require.NoError(t, err)
+
+	events := listAudit(t, s)
+	require.Len(t, events, 4)
+	assert.Equal(t, models.AuditRegister, events[0].Type)
+	assert.Equal(t, models.AuditLoginFailed, events[1].Type)
+	assert.Equal(t, events[0].UserID, events[1].UserID)
+	assert.Equal(t, "invalid_password", events[1].Details["reason"])
+	assert.Equal(t, 0, events[2].UserID)
+	assert.Equal(t, "bob", events[2].Details["username"])
+	assert.Equal(t, models.AuditLoginSucceeded, events[3].Type)
+	assert.Equal(t, "password", events[3].Details["method"])
+	for _, event := range events {
+		assert.Equal(t, "10.0.0.1", event.IP)
+		assert.Equal(t, "curl/8.0", event.UserAgent)
+		assert.Equal(t, "req-1", event.RequestID)
+	}
+}
+
+func TestListAuditEvents_Pagination(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.audit.Append(ctx, &models.AuditEvent{Type: models.AuditRefresh, UserID: 1}))
+	}
+
+	events, next, err := s.ListAuditEvents(ctx, models.AuditFilter{UserID: 1}, 2, "")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(5), events[0].ID)
+	assert.Equal(t, "4", next)
+
+	events, next, err = s.ListAuditEvents(ctx, models.AuditFilter{UserID: 1}, 3, next)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, int64(1), events[2].ID)
+	assert.Empty(t, next)
+
+	now := time.Now()
+	_, _, err = s.ListAuditEvents(ctx, models.AuditFilter{
+		Types: []models.AuditEventType{"deleted"},
+		Since: now,
+		Until: now.Add(-time.Hour),
+	}, 0, "abc")
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"page_token_invalid", "event_types_unknown", "end_time_invalid"}, invalid.Reasons)
+}
+
+func TestAuditRetention_Prune(t *testing.T) {
+	audit := memory.NewAuditRepository(memory.NewStore())
+	ctx := context.Background()
+	require.NoError(t, audit.Append(ctx, &models.AuditEvent{Type: models.AuditRegister, UserID: 1}))
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, audit.Append(ctx, &models.AuditEvent{Type: models.AuditRegister, UserID: 2}))
+	retention := NewAuditRetention(audit, 100*time.Millisecond, time.Hour, nil)
+
+	assert.Equal(t, int64(1), retention.Prune(ctx))
+	events, err := audit.List(ctx, models.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 2, events[0].UserID)
+}
@@ -6,43 +6,177 @@ import (
 	"AuthService/internal/domain/models"
 	"AuthService/internal/domain/repositories"
 	"AuthService/pkg/jwt"
+	"AuthService/pkg/notify"
 	"AuthService/pkg/password"
 	"context"
 	"errors"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
 type AuthService interface {
-	Register(ctx context.Context, username, password string) error
-	Login(ctx context.Context, username, password string) (*models.TokenPair, error)
-	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
-	VerifyToken(token string) (*models.TokenClaims, error)
+	Register(ctx context.Context, username, password, email string, client models.ClientInfo) error
+	Login(ctx context.Context, username, password string, client models.ClientInfo) (*models.LoginResult, error)
+	Refresh(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string, client models.ClientInfo) error
+	VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error)
+	Introspect(ctx context.Context, token string) (*models.Introspection, error)
+	GrantRole(ctx context.Context, adminID, userID int, role models.Role, client models.ClientInfo) error
+	BootstrapAdmin(ctx context.Context, username string) error
+	RevokeRole(ctx context.Context, adminID, userID int, role models.Role, client models.ClientInfo) error
+	EnrollTOTP(ctx context.Context, userID int) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
+	VerifySecondFactor(ctx context.Context, challengeToken, code string, client models.ClientInfo) (*models.TokenPair, error)
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string, client models.ClientInfo) (*models.TokenPair, error)
+	RequestPasswordReset(ctx context.Context, username string, client models.ClientInfo) error
+	ResetPassword(ctx context.Context, token, newPassword string, client models.ClientInfo) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, userID int) error
+	GetUser(ctx context.Context, userID int, username string) (*models.Profile, error)
+	BatchGetUsers(ctx context.Context, ids []int, usernames []string) ([]*models.Profile, error)
+	SearchUsers(ctx context.Context, query string, pageSize int, pageToken string) ([]*models.Profile, string, error)
+	UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (*models.Profile, error)
+	SuspendUser(ctx context.Context, adminID, userID int, reason string, until *time.Time, client models.ClientInfo) (*models.Suspension, error)
+	UnsuspendUser(ctx context.Context, adminID, userID int, client models.ClientInfo) error
+	GetSuspension(ctx context.Context, userID int) (*models.Suspension, error)
+	ListSessions(ctx context.Context, userID int) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int, exceptSessionID string) (int, error)
+	RegisterOAuthClient(ctx context.Context, ownerID int, name string, redirectURIs, scopes []string, confidential bool) (*models.OAuthClient, string, error)
+	Authorize(ctx context.Context, userID int, req models.AuthorizeRequest, decision models.ConsentDecision) (*models.AuthorizeResult, error)
+	ExchangeToken(ctx context.Context, req models.TokenRequest, client models.ClientInfo) (*models.OAuthTokens, error)
+	RevokeOAuthToken(ctx context.Context, clientID, clientSecret, token string) error
+	ListExternalProviders(ctx context.Context) []string
+	StartExternalLogin(ctx context.Context, provider string) (*models.ExternalLoginStart, error)
+	CompleteExternalLogin(ctx context.Context, provider, state, code string, client models.ClientInfo) (*models.LoginResult, error)
+	CreatePersonalToken(ctx context.Context, userID int, name string, scopes []string, expiresAt time.Time) (*models.PersonalAccessToken, string, error)
+	ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalAccessToken, error)
+	RevokePersonalToken(ctx context.Context, userID int, tokenID string) error
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, pageSize int, pageToken string) ([]*models.AuditEvent, string, error)
+	WatchUserEvents(ctx context.Context, cursor string, types []models.UserEventType, send func(*models.UserEvent) error) error
 }
 
 type AuthServiceStruct struct {
-	repo          repositories.UserRepo
-	accessSecret  string
-	refreshSecret string
-	accessTTL     time.Duration
-	refreshTTL    time.Duration
-	logger        *zap.Logger
+	tx          repositories.Transactor
+	repo        repositories.UserRepo
+	tokens      repositories.TokenRepo
+	sessions    repositories.SessionRepo
+	factors     repositories.SecondFactorRepo
+	suspensions repositories.SuspensionRepo
+	throttle    *loginThrottle
+	passwords   *password.Policy
+	hasher      *password.Hasher
+	usernames   *usernamePolicy
+	keys        *jwt.KeySet
+	refreshKeys *jwt.HMAC
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+
+	totpIssuer   string
+	challengeTTL time.Duration
+
+	resets   repositories.PasswordResetRepo
+	notifier notify.Notifier
+	resetTTL time.Duration
+	resetURL string
+
+	emailTTL time.Duration
+	emailURL string
+
+	oauth   repositories.OAuthRepo
+	codeTTL time.Duration
+	// consentEnabled - задан ли OAuthConsentURL: без страницы согласия
+	// пользователи не смогут авторизовать клиентов.
+	consentEnabled bool
+
+	identities repositories.ExternalIdentityRepo
+	providers  map[string]ExternalProvider
+	stateTTL   time.Duration
+
+	personalTokens      repositories.PersonalTokenRepo
+	personalTokenTTL    time.Duration
+	personalTokenMaxTTL time.Duration
+
+	audit repositories.AuditRepo
+
+	userEvents    repositories.UserEventRepo
+	userEventWake *eventBroadcast
+	userEventPoll time.Duration
+
+	logger *zap.Logger
 }
 
-func NewAuthService(userRepo repositories.UserRepo, cfg *config.Config, logger *zap.Logger) AuthService {
+func NewAuthService(userRepo repositories.UserRepo, tokenRepo repositories.TokenRepo, sessionRepo repositories.SessionRepo, attemptRepo repositories.AttemptRepo, factorRepo repositories.SecondFactorRepo, resetRepo repositories.PasswordResetRepo, suspensionRepo repositories.SuspensionRepo, oauthRepo repositories.OAuthRepo, identityRepo repositories.ExternalIdentityRepo, personalTokenRepo repositories.PersonalTokenRepo, auditRepo repositories.AuditRepo, userEventRepo repositories.UserEventRepo, transactor repositories.Transactor, keys *jwt.KeySet, passwords *password.Policy, hasher *password.Hasher, notifier notify.Notifier, providers []ExternalProvider, cfg *config.Config, logger *zap.Logger) AuthService {
+	logger = logger.With(zap.String("component", "auth_service"))
 	return &AuthServiceStruct{
-		repo:          userRepo,
-		accessSecret:  cfg.AccessSecret,
-		refreshSecret: cfg.RefreshSecret,
-		accessTTL:     cfg.AccessTTL,
-		refreshTTL:    cfg.RefreshTTL,
-		logger:        logger.With(zap.String("component", "auth_service")),
+		tx:          transactor,
+		repo:        userRepo,
+		tokens:      tokenRepo,
+		sessions:    sessionRepo,
+		factors:     factorRepo,
+		suspensions: suspensionRepo,
+		throttle: &loginThrottle{
+			repo:        attemptRepo,
+			maxAttempts: cfg.LoginMaxAttempts,
+			backoffBase: cfg.LoginBackoffBase,
+			lockout:     cfg.LoginLockout,
+			logger:      logger,
+		},
+		passwords:   passwords,
+		hasher:      hasher,
+		usernames:   newUsernamePolicy(cfg.UsernameMinLength, cfg.UsernameMaxLength, cfg.ReservedUsernames),
+		keys:        keys,
+		refreshKeys: jwt.NewHMAC(cfg.RefreshSecret, jwt.Policy{Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience}),
+		accessTTL:   cfg.AccessTTL,
+		refreshTTL:  cfg.RefreshTTL,
+
+		totpIssuer:   cfg.TOTPIssuer,
+		challengeTTL: cfg.MFAChallengeTTL,
+
+		resets:   resetRepo,
+		notifier: notifier,
+		resetTTL: cfg.PasswordResetTTL,
+		resetURL: cfg.PasswordResetURL,
+
+		emailTTL: cfg.EmailVerificationTTL,
+		emailURL: cfg.EmailVerificationURL,
+
+		oauth:          oauthRepo,
+		codeTTL:        cfg.OAuthCodeTTL,
+		consentEnabled: cfg.OAuthConsentURL != "",
+
+		identities: identityRepo,
+		providers:  providersByName(providers),
+		stateTTL:   cfg.OIDCStateTTL,
+
+		personalTokens:      personalTokenRepo,
+		personalTokenTTL:    cfg.PersonalTokenDefaultTTL,
+		personalTokenMaxTTL: cfg.PersonalTokenMaxTTL,
+
+		audit: auditRepo,
+
+		userEvents:    userEventRepo,
+		userEventWake: newEventBroadcast(),
+		userEventPoll: cfg.UserEventPollInterval,
+
+		logger: logger,
 	}
 }
 
-func (s *AuthServiceStruct) Register(ctx context.Context, username string, plainPassword string) error {
+func (s *AuthServiceStruct) Register(ctx context.Context, username string, plainPassword string, email string, client models.ClientInfo) error {
 	s.logger.Info("registering new user", zap.String("username", username))
 
+	email = strings.TrimSpace(email)
+	reasons := append(s.usernames.check(username), s.passwords.Check(plainPassword, username)...)
+	reasons = append(reasons, checkEmail(email)...)
+	if len(reasons) > 0 {
+		s.logger.Warn("registration data rejected by policy",
+			zap.String("username", username),
+			zap.Strings("reasons", reasons))
+		return &domain.ValidationError{Reasons: reasons}
+	}
+
 	_, err := s.repo.FindByUsername(ctx, username)
 	if err == nil {
 		s.logger.Warn("user already exists", zap.String("username", username))
@@ -54,7 +188,17 @@ func (s *AuthServiceStruct) Register(ctx context.Context, username string, plain
 		return err
 	}
 
-	hashedPassword, err := password.Hash(plainPassword)
+	if email != "" {
+		_, err := s.repo.FindByEmail(ctx, email)
+		if err == nil {
+			s.logger.Warn("email already in use", zap.String("username", username))
+			return domain.EmailAlreadyExists
+		} else if !errors.Is(err, domain.UserNotFound) {
+			return err
+		}
+	}
+
+	hashedPassword, err := s.hasher.Hash(plainPassword)
 	if err != nil {
 		s.logger.Error("failed to hash password",
 			zap.String("username", username),
@@ -65,14 +209,46 @@ func (s *AuthServiceStruct) Register(ctx context.Context, username string, plain
 	user := &models.User{
 		Username: username,
 		Password: hashedPassword,
+		Email:    email,
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
-		s.logger.Error("failed to create user",
-			zap.String("username", username),
-			zap.Error(err))
+	// Пользователь без роли по умолчанию или без события user_created не
+	// должен остаться в базе, если их записать не удалось.
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			s.logger.Error("failed to create user",
+				zap.String("username", username),
+				zap.Error(err))
+			return err
+		}
+		if err := s.repo.GrantRole(ctx, user.ID, models.RoleUser); err != nil {
+			s.logger.Error("failed to grant default role",
+				zap.String("username", username),
+				zap.Error(err))
+			return err
+		}
+		return s.appendUserEvent(ctx, &models.UserEvent{
+			Type:   models.UserCreated,
+			UserID: user.ID,
+			Data:   map[string]string{"username": user.Username},
+		})
+	})
+	if err != nil {
 		return err
 	}
+	s.userEventWake.notify()
+
+	// Регистрация не откатывается, если письмо не ушло: его можно
+	// запросить повторно через ResendVerificationEmail.
+	if email != "" {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			s.logger.Error("failed to send verification email",
+				zap.Int("user_id", user.ID),
+				zap.Error(err))
+		}
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{Type: models.AuditRegister, UserID: user.ID}, client)
 
 	s.logger.Info("user registered successfully",
 		zap.String("username", username),
@@ -80,13 +256,23 @@ func (s *AuthServiceStruct) Register(ctx context.Context, username string, plain
 	return nil
 }
 
-func (s *AuthServiceStruct) Login(ctx context.Context, username string, plainPassword string) (*models.TokenPair, error) {
-	s.logger.Info("user login attempt", zap.String("username", username))
+func (s *AuthServiceStruct) Login(ctx context.Context, username string, plainPassword string, client models.ClientInfo) (*models.LoginResult, error) {
+	s.logger.Info("user login attempt",
+		zap.String("username", username),
+		zap.String("ip", client.IP))
+
+	keys := loginKeys(username, client.IP)
+	if err := s.throttle.check(ctx, keys); err != nil {
+		s.auditLoginFailure(ctx, 0, username, "throttled", client)
+		return nil, err
+	}
 
 	user, err := s.repo.FindByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, domain.UserNotFound) {
 			s.logger.Warn("user not found during login", zap.String("username", username))
+			s.throttle.fail(ctx, keys)
+			s.auditLoginFailure(ctx, 0, username, "user_not_found", client)
 		} else {
 			s.logger.Error("failed to find user during login",
 				zap.String("username", username),
@@ -95,36 +281,126 @@ func (s *AuthServiceStruct) Login(ctx context.Context, username string, plainPas
 		return nil, err
 	}
 
-	if err := password.Verify(user.Password, plainPassword); err != nil {
+	rehash, err := s.hasher.Verify(user.Password, plainPassword)
+	if err != nil {
 		s.logger.Warn("invalid password provided",
 			zap.String("username", username),
+			zap.String("ip", client.IP),
 			zap.Error(err))
+		s.throttle.fail(ctx, keys)
+		s.auditLoginFailure(ctx, user.ID, username, "invalid_password", client)
 		return nil, domain.InvalidData
 	}
+	s.throttle.reset(ctx, keys[0])
+	if rehash {
+		s.rehashPassword(ctx, user, plainPassword)
+	}
+
+	return s.completeLogin(ctx, user, "password", client)
+}
+
+// completeLogin завершает вход пользователя, чья личность уже
+// подтверждена способом method: проверяет блокировку и второй фактор и
+// открывает сессию.
+func (s *AuthServiceStruct) completeLogin(ctx context.Context, user *models.User, method string, client models.ClientInfo) (*models.LoginResult, error) {
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		s.auditLoginFailure(ctx, user.ID, user.Username, "account_suspended", client)
+		return nil, err
+	}
 
-	tokens, err := s.GenerateTokens(user.ID, username)
+	challenge, err := s.secondFactorChallenge(ctx, user)
+	if err != nil {
+		s.logger.Error("failed to check second factor",
+			zap.Int("user_id", user.ID),
+			zap.Error(err))
+		return nil, err
+	}
+	if challenge != "" {
+		s.logger.Info("second factor required",
+			zap.Int("user_id", user.ID),
+			zap.String("username", user.Username))
+		return &models.LoginResult{ChallengeToken: challenge}, nil
+	}
+
+	tokens, err := s.GenerateTokens(ctx, user, client)
 	if err != nil {
 		s.logger.Error("failed to generate tokens",
 			zap.Int("user_id", user.ID),
-			zap.String("username", username),
+			zap.String("username", user.Username),
 			zap.Error(err))
 		return nil, err
 	}
 
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditLoginSucceeded,
+		UserID:  user.ID,
+		Details: map[string]string{"method": method},
+	}, client)
+
 	s.logger.Info("user logged in successfully",
 		zap.Int("user_id", user.ID),
-		zap.String("username", username))
-	return tokens, nil
+		zap.String("username", user.Username))
+	return &models.LoginResult{Tokens: tokens}, nil
+}
+
+// auditLoginFailure записывает неудачный вход. userID равен 0, если
+// пользователь не найден.
+func (s *AuthServiceStruct) auditLoginFailure(ctx context.Context, userID int, username, reason string, client models.ClientInfo) {
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditLoginFailed,
+		UserID:  userID,
+		Details: map[string]string{"username": username, "reason": reason},
+	}, client)
 }
 
-func (s *AuthServiceStruct) Refresh(ctx context.Context, token string) (*models.TokenPair, error) {
+func (s *AuthServiceStruct) Refresh(ctx context.Context, token string, client models.ClientInfo) (*models.TokenPair, error) {
+	tokens, _, err := s.refresh(ctx, token, client, "")
+	return tokens, err
+}
+
+// refresh ротирует refresh токен. Токен, выданный клиенту OAuth,
+// принимается только от этого клиента, а токен первого лица - только при
+// пустом clientID.
+func (s *AuthServiceStruct) refresh(ctx context.Context, token string, client models.ClientInfo, clientID string) (*models.TokenPair, *models.TokenClaims, error) {
 	s.logger.Debug("refreshing tokens")
 
-	claims, err := jwt.ValidateToken(token, s.refreshSecret)
+	claims, err := s.refreshKeys.Validate(token, jwt.TypeRefresh)
 	if err != nil {
 		s.logger.Warn("invalid refresh token provided",
 			zap.Error(err))
-		return nil, domain.InvalidToken
+		return nil, nil, domain.InvalidToken
+	}
+	if claims.ClientID != clientID {
+		s.logger.Warn("refresh token presented by another client",
+			zap.Int("user_id", claims.UserID),
+			zap.String("token_client_id", claims.ClientID),
+			zap.String("client_id", clientID))
+		return nil, nil, domain.InvalidToken
+	}
+
+	stored, err := s.tokens.FindByID(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, domain.TokenNotFound) {
+			s.logger.Warn("refresh token is not known to the server",
+				zap.Int("user_id", claims.UserID))
+			return nil, nil, domain.InvalidToken
+		}
+		s.logger.Error("failed to load refresh token",
+			zap.Int("user_id", claims.UserID),
+			zap.Error(err))
+		return nil, nil, err
+	}
+
+	if stored.Rotated() {
+		s.revokeReusedFamily(ctx, stored)
+		return nil, nil, domain.TokenReused
+	}
+
+	if !stored.Active(time.Now()) {
+		s.logger.Warn("revoked or expired refresh token provided",
+			zap.String("token_id", stored.ID),
+			zap.Int("user_id", stored.UserID))
+		return nil, nil, domain.InvalidToken
 	}
 
 	s.logger.Info("refresh token validated",
@@ -136,63 +412,330 @@ func (s *AuthServiceStruct) Refresh(ctx context.Context, token string) (*models.
 		s.logger.Error("failed to find user by ID during refresh",
 			zap.Int("user_id", claims.UserID),
 			zap.Error(err))
-		return nil, err
+		return nil, nil, err
 	}
 
-	tokens, err := s.GenerateTokens(user.ID, user.Username)
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, next, err := s.signTokens(ctx, user, stored.FamilyID, grantOf(claims))
 	if err != nil {
 		s.logger.Error("failed to generate new tokens during refresh",
 			zap.Int("user_id", user.ID),
 			zap.String("username", user.Username),
 			zap.Error(err))
-		return nil, err
+		return nil, nil, err
+	}
+
+	if err := s.tokens.Rotate(ctx, stored.ID, next); err != nil {
+		if errors.Is(err, domain.TokenReused) {
+			s.revokeReusedFamily(ctx, stored)
+			return nil, nil, domain.TokenReused
+		}
+		s.logger.Error("failed to rotate refresh token",
+			zap.String("token_id", stored.ID),
+			zap.Int("user_id", user.ID),
+			zap.Error(err))
+		return nil, nil, err
+	}
+
+	s.touchLastSeen(ctx, user.ID)
+	s.touchSession(ctx, stored.FamilyID, client)
+
+	details := map[string]string{"session_id": stored.FamilyID}
+	if clientID != "" {
+		details["client_id"] = clientID
 	}
+	s.recordAudit(ctx, &models.AuditEvent{Type: models.AuditRefresh, UserID: user.ID, Details: details}, client)
 
 	s.logger.Info("tokens refreshed successfully",
 		zap.Int("user_id", user.ID),
 		zap.String("username", user.Username))
-	return tokens, nil
+	return tokens, claims, nil
+}
+
+func (s *AuthServiceStruct) revokeReusedFamily(ctx context.Context, token *models.RefreshToken) {
+	s.logger.Error("refresh token reuse detected, revoking session",
+		zap.String("token_id", token.ID),
+		zap.String("session_id", token.FamilyID),
+		zap.Int("user_id", token.UserID))
+
+	err := s.sessions.Revoke(ctx, token.UserID, token.FamilyID)
+	if err != nil && !errors.Is(err, domain.SessionNotFound) {
+		s.logger.Error("failed to revoke session after reuse",
+			zap.String("session_id", token.FamilyID),
+			zap.Error(err))
+	}
+}
+
+func (s *AuthServiceStruct) Logout(ctx context.Context, token string, client models.ClientInfo) error {
+	s.logger.Debug("logging out")
+
+	claims, err := s.refreshKeys.Validate(token, jwt.TypeRefresh)
+	if err != nil {
+		s.logger.Warn("invalid refresh token provided on logout",
+			zap.Error(err))
+		return domain.InvalidToken
+	}
+
+	stored, err := s.tokens.FindByID(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, domain.TokenNotFound) {
+			return domain.InvalidToken
+		}
+		return err
+	}
+
+	// Повторный выход из уже отозванной сессии не считается ошибкой.
+	err = s.sessions.Revoke(ctx, stored.UserID, stored.FamilyID)
+	if err != nil && !errors.Is(err, domain.SessionNotFound) {
+		s.logger.Error("failed to revoke session on logout",
+			zap.Int("user_id", claims.UserID),
+			zap.Error(err))
+		return err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditLogout,
+		UserID:  stored.UserID,
+		Details: map[string]string{"session_id": stored.FamilyID},
+	}, client)
+
+	s.logger.Info("user logged out",
+		zap.Int("user_id", claims.UserID),
+		zap.String("username", claims.Username))
+	return nil
+}
+
+// GenerateTokens открывает новую сессию для устройства client и выдает
+// первую пару токенов в ней.
+func (s *AuthServiceStruct) GenerateTokens(ctx context.Context, user *models.User, client models.ClientInfo) (*models.TokenPair, error) {
+	sessionID, err := jwt.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return s.openSession(ctx, user, client, sessionID, tokenGrant{})
 }
 
-func (s *AuthServiceStruct) GenerateTokens(id int, username string) (*models.TokenPair, error) {
+// tokenGrant ограничивает токены сессии, открытой клиентом OAuth. Нулевое
+// значение соответствует входу самого пользователя.
+type tokenGrant struct {
+	clientID string
+	scopes   []string
+}
+
+func grantOf(claims *models.TokenClaims) tokenGrant {
+	return tokenGrant{clientID: claims.ClientID, scopes: claims.Scopes}
+}
+
+func (s *AuthServiceStruct) openSession(ctx context.Context, user *models.User, client models.ClientInfo, sessionID string, grant tokenGrant) (*models.TokenPair, error) {
 	s.logger.Debug("generating new tokens",
-		zap.Int("user_id", id),
-		zap.String("username", username))
+		zap.Int("user_id", user.ID),
+		zap.String("username", user.Username),
+		zap.String("client_id", grant.clientID))
 
-	accessToken, err := jwt.GenerateToken(
-		models.TokenClaims{UserID: id, Username: username},
-		s.accessSecret, s.accessTTL,
-	)
+	tokens, refresh, err := s.signTokens(ctx, user, sessionID, grant)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := jwt.GenerateToken(
-		models.TokenClaims{UserID: id, Username: username},
-		s.refreshSecret, s.refreshTTL,
-	)
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ClientID:  grant.clientID,
+	}
+	// Сессия без refresh токена не видна пользователю как устройство,
+	// которое можно продлить, но числится в списке активных.
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessions.Create(ctx, session); err != nil {
+			return err
+		}
+		return s.tokens.Create(ctx, refresh)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.TokenPair{
+	s.touchLastSeen(ctx, user.ID)
+	return tokens, nil
+}
+
+func (s *AuthServiceStruct) signTokens(ctx context.Context, user *models.User, sessionID string, grant tokenGrant) (*models.TokenPair, *models.RefreshToken, error) {
+	roles, err := s.repo.Roles(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := s.keys.Sign(
+		models.TokenClaims{
+			Type:          jwt.TypeAccess,
+			UserID:        user.ID,
+			Username:      user.Username,
+			Roles:         roleNames(roles),
+			EmailVerified: user.EmailVerified,
+			SessionID:     sessionID,
+			ClientID:      grant.clientID,
+			Scopes:        grant.scopes,
+		},
+		s.accessTTL,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokenID, err := jwt.NewID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := s.refreshKeys.Sign(
+		models.TokenClaims{
+			ID:       tokenID,
+			Type:     jwt.TypeRefresh,
+			UserID:   user.ID,
+			Username: user.Username,
+			ClientID: grant.clientID,
+			Scopes:   grant.scopes,
+		},
+		s.refreshTTL,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens := &models.TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, nil
+	}
+	refresh := &models.RefreshToken{
+		ID:        tokenID,
+		FamilyID:  sessionID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	return tokens, refresh, nil
 }
 
-func (s *AuthServiceStruct) VerifyToken(token string) (*models.TokenClaims, error) {
-	s.logger.Debug("verifying token")
+func (s *AuthServiceStruct) GrantRole(ctx context.Context, adminID, userID int, role models.Role, client models.ClientInfo) error {
+	if !role.Valid() {
+		return domain.InvalidRole
+	}
+	if _, err := s.repo.FindByID(ctx, userID); err != nil {
+		return err
+	}
 
-	tokenClaims, err := jwt.ValidateToken(token, s.accessSecret)
+	event := &models.UserEvent{
+		Type:   models.RolesChanged,
+		UserID: userID,
+		Data:   map[string]string{"role": string(role), "action": "granted"},
+	}
+	err := s.changeWithUserEvent(ctx, event, func(ctx context.Context) error {
+		return s.repo.GrantRole(ctx, userID, role)
+	})
 	if err != nil {
-		s.logger.Warn("token verification failed",
+		s.logger.Error("failed to grant role",
+			zap.Int("user_id", userID),
+			zap.String("role", string(role)),
 			zap.Error(err))
-		return nil, err
+		return err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditRoleGranted,
+		UserID:  userID,
+		ActorID: adminID,
+		Details: map[string]string{"role": string(role)},
+	}, client)
+
+	s.logger.Info("role granted",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)))
+	return nil
+}
+
+// BootstrapAdmin выдает роль admin пользователю username. RPC GrantRole
+// доступен только администраторам, поэтому первого из них назначает
+// subcommand grant-admin через этот метод.
+func (s *AuthServiceStruct) BootstrapAdmin(ctx context.Context, username string) error {
+	user, err := s.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	return s.GrantRole(ctx, 0, user.ID, models.RoleAdmin, models.ClientInfo{})
+}
+
+func (s *AuthServiceStruct) RevokeRole(ctx context.Context, adminID, userID int, role models.Role, client models.ClientInfo) error {
+	if !role.Valid() {
+		return domain.InvalidRole
+	}
+	if _, err := s.repo.FindByID(ctx, userID); err != nil {
+		return err
 	}
 
-	s.logger.Debug("token verified successfully",
-		zap.Int("user_id", tokenClaims.UserID),
-		zap.String("username", tokenClaims.Username))
-	return tokenClaims, nil
+	event := &models.UserEvent{
+		Type:   models.RolesChanged,
+		UserID: userID,
+		Data:   map[string]string{"role": string(role), "action": "revoked"},
+	}
+	err := s.changeWithUserEvent(ctx, event, func(ctx context.Context) error {
+		return s.repo.RevokeRole(ctx, userID, role)
+	})
+	if err != nil {
+		s.logger.Error("failed to revoke role",
+			zap.Int("user_id", userID),
+			zap.String("role", string(role)),
+			zap.Error(err))
+		return err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditRoleRevoked,
+		UserID:  userID,
+		ActorID: adminID,
+		Details: map[string]string{"role": string(role)},
+	}, client)
+
+	s.logger.Info("role revoked",
+		zap.Int("user_id", userID),
+		zap.String("role", string(role)))
+	return nil
+}
+
+// rehashPassword пересчитывает хеш устаревшего алгоритма или с
+// устаревшими параметрами. Ошибка не прерывает вход.
+func (s *AuthServiceStruct) rehashPassword(ctx context.Context, user *models.User, plainPassword string) {
+	hashedPassword, err := s.hasher.Hash(plainPassword)
+	if err == nil {
+		err = s.repo.UpdatePassword(ctx, user.ID, hashedPassword)
+	}
+	if err != nil {
+		s.logger.Warn("failed to upgrade password hash",
+			zap.Int("user_id", user.ID),
+			zap.Error(err))
+		return
+	}
+
+	user.Password = hashedPassword
+	s.logger.Info("password hash upgraded", zap.Int("user_id", user.ID))
+}
+
+// touchLastSeen обновляет время последней активности. Ошибка не
+// прерывает выдачу токенов.
+func (s *AuthServiceStruct) touchLastSeen(ctx context.Context, userID int) {
+	if err := s.repo.TouchLastSeen(ctx, userID); err != nil {
+		s.logger.Warn("failed to update last seen",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+func roleNames(roles []models.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, string(role))
+	}
+	return names
 }
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"AuthService/internal/config"
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/memory"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/notify"
+	"AuthService/pkg/password"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testClient = models.ClientInfo{IP: "10.0.0.1", UserAgent: "test"}
+
+// newTestService собирает сервис на репозиториях из internal/memory.
+// Тесты, которым нужно другое поведение, меняют поля сервиса напрямую.
+func newTestService(t *testing.T, providers ...ExternalProvider) *AuthServiceStruct {
+	cfg := &config.Config{
+		JWTIssuer:               "auth-service",
+		JWTAudience:             "go-forum",
+		RefreshSecret:           "refresh-secret",
+		AccessTTL:               time.Minute,
+		RefreshTTL:              time.Hour,
+		TOTPIssuer:              "GO-FORUM",
+		MFAChallengeTTL:         time.Minute,
+		LoginMaxAttempts:        3,
+		LoginBackoffBase:        time.Minute,
+		LoginLockout:            time.Hour,
+		UsernameMinLength:       3,
+		UsernameMaxLength:       32,
+		ReservedUsernames:       []string{"admin"},
+		PasswordResetTTL:        time.Hour,
+		PasswordResetURL:        "https://forum.example/reset",
+		EmailVerificationTTL:    time.Hour,
+		EmailVerificationURL:    "https://forum.example/verify",
+		OAuthCodeTTL:            time.Minute,
+		OAuthConsentURL:         "https://forum.example/consent",
+		OIDCStateTTL:            time.Minute,
+		PersonalTokenDefaultTTL: 24 * time.Hour,
+		PersonalTokenMaxTTL:     30 * 24 * time.Hour,
+		// Опрос раз в минуту: подписчики получают события только благодаря
+		// пробуждению при записи.
+		UserEventPollInterval: time.Minute,
+	}
+	keys, err := jwt.GenerateKeySet("k1", jwt.Policy{Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience})
+	require.NoError(t, err)
+	passwords, err := password.NewPolicy(8, "")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	s := NewAuthService(memory.NewUserRepository(store), memory.NewTokenRepository(store), memory.NewSessionRepository(store),
+		memory.NewAttemptRepository(store), memory.NewSecondFactorRepository(store), memory.NewPasswordResetRepository(store),
+		memory.NewSuspensionRepository(store), memory.NewOAuthRepository(store), memory.NewExternalIdentityRepository(store),
+		memory.NewPersonalTokenRepository(store), memory.NewAuditRepository(store), memory.NewUserEventRepository(store), store,
+		keys, passwords, password.NewHasher(password.NewBcrypt(4)), notify.NewLogNotifier(zap.NewNop()), providers, cfg, zap.NewNop())
+	return s.(*AuthServiceStruct)
+}
+
+// createUser заводит пользователя с ролью user в обход Register.
+func createUser(t *testing.T, s *AuthServiceStruct, user *models.User) *models.User {
+	ctx := context.Background()
+	verified := user.EmailVerified
+	require.NoError(t, s.repo.Create(ctx, user))
+	require.NoError(t, s.repo.GrantRole(ctx, user.ID, models.RoleUser))
+	if verified {
+		_, err := s.repo.MarkEmailVerified(ctx, user.ID, user.Email)
+		require.NoError(t, err)
+	}
+	return user
+}
+
+// registerAndLogin регистрирует пользователя и входит под ним.
+func registerAndLogin(t *testing.T, s *AuthServiceStruct, username string) (*models.User, *models.TokenPair) {
+	ctx := context.Background()
+	require.NoError(t, s.Register(ctx, username, "correct-horse-battery", "", testClient))
+	user, err := s.repo.FindByUsername(ctx, username)
+	require.NoError(t, err)
+
+	result, err := s.Login(ctx, username, "correct-horse-battery", testClient)
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	return user, result.Tokens
+}
+
+func TestRefresh_RotatesWithinSession(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	_, first := registerAndLogin(t, s, "alice")
+
+	second, err := s.Refresh(ctx, first.RefreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	before, err := s.VerifyToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+	after, err := s.VerifyToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, after.SessionID)
+	assert.Equal(t, before.SessionID, after.SessionID)
+
+	_, err = s.Refresh(ctx, second.AccessToken, testClient)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+}
+
+func TestRefresh_ReuseRevokesSession(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, first := registerAndLogin(t, s, "alice")
+
+	second, err := s.Refresh(ctx, first.RefreshToken, testClient)
+	require.NoError(t, err)
+	third, err := s.Refresh(ctx, second.RefreshToken, testClient)
+	require.NoError(t, err)
+
+	// Ротированный токен предъявлен повторно: украден либо он, либо
+	// действующий, поэтому закрывается вся сессия.
+	_, err = s.Refresh(ctx, first.RefreshToken, testClient)
+	assert.ErrorIs(t, err, domain.TokenReused)
+
+	sessions, err := s.ListSessions(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	_, err = s.Refresh(ctx, third.RefreshToken, testClient)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+	_, err = s.VerifyToken(ctx, third.AccessToken)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+
+	// Другие сессии пользователя не затрагиваются.
+	result, err := s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	require.NoError(t, err)
+	_, err = s.Refresh(ctx, result.Tokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	_, tokens := registerAndLogin(t, s, "alice")
+
+	require.NoError(t, s.Logout(ctx, tokens.RefreshToken, testClient))
+
+	_, err := s.VerifyToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+	_, err = s.Refresh(ctx, tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"net/mail"
+	"strings"
+)
+
+const (
+	reasonUsernameTooShort     = "username_too_short"
+	reasonUsernameTooLong      = "username_too_long"
+	reasonUsernameInvalidChars = "username_invalid_characters"
+	reasonUsernameReserved     = "username_reserved"
+	reasonEmailInvalid         = "email_invalid"
+)
+
+// Ограничение длины адреса из RFC 5321 и размер колонки users.email.
+const maxEmailLength = 254
+
+type usernamePolicy struct {
+	minLength int
+	maxLength int
+	reserved  map[string]struct{}
+}
+
+func newUsernamePolicy(minLength, maxLength int, reserved []string) *usernamePolicy {
+	policy := &usernamePolicy{
+		minLength: minLength,
+		maxLength: maxLength,
+		reserved:  make(map[string]struct{}, len(reserved)),
+	}
+	for _, name := range reserved {
+		if name = strings.TrimSpace(name); name != "" {
+			policy.reserved[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	return policy
+}
+
+// check допускает латинские буквы, цифры, '_', '-' и '.'.
+func (p *usernamePolicy) check(username string) []string {
+	var reasons []string
+	if len(username) < p.minLength {
+		reasons = append(reasons, reasonUsernameTooShort)
+	}
+	if len(username) > p.maxLength {
+		reasons = append(reasons, reasonUsernameTooLong)
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			reasons = append(reasons, reasonUsernameInvalidChars)
+			break
+		}
+	}
+	if _, ok := p.reserved[strings.ToLower(username)]; ok {
+		reasons = append(reasons, reasonUsernameReserved)
+	}
+	return reasons
+}
+
+// checkEmail принимает пустой адрес: почта при регистрации необязательна.
+func checkEmail(email string) []string {
+	if email == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return []string{reasonEmailInvalid}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsernamePolicy_Check(t *testing.T) {
+	policy := newUsernamePolicy(3, 16, []string{"Admin", " root ", ""})
+
+	tests := []struct {
+		name     string
+		username string
+		expected []string
+	}{
+		{name: "Valid username", username: "alice.smith-1_"},
+		{name: "Minimum length", username: "bob"},
+		{name: "Maximum length", username: strings.Repeat("a", 16)},
+		{name: "Too short", username: "al", expected: []string{reasonUsernameTooShort}},
+		{name: "Empty", username: "", expected: []string{reasonUsernameTooShort}},
+		{name: "Too long", username: strings.Repeat("a", 17), expected: []string{reasonUsernameTooLong}},
+		{name: "Space", username: "alice smith", expected: []string{reasonUsernameInvalidChars}},
+		{name: "Non-latin letters", username: "алиса", expected: []string{reasonUsernameInvalidChars}},
+		{name: "Reserved case-insensitive", username: "ADMIN", expected: []string{reasonUsernameReserved}},
+		{name: "Reserved name is trimmed", username: "root", expected: []string{reasonUsernameReserved}},
+		{
+			name:     "Several violations",
+			username: "a@",
+			expected: []string{reasonUsernameTooShort, reasonUsernameInvalidChars},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.check(tt.username))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/notify"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/url"
+)
+
+// VerifyEmail подтверждает адрес по токену из письма. Токен привязан к
+// адресу, поэтому после смены адреса старые письма перестают работать.
+func (s *AuthServiceStruct) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.refreshKeys.Validate(token, jwt.TypeEmail)
+	if err != nil || claims.Email == "" {
+		s.logger.Warn("invalid email verification token provided", zap.Error(err))
+		return domain.InvalidToken
+	}
+
+	ok, err := s.repo.MarkEmailVerified(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.logger.Warn("email verification token does not match current address",
+			zap.Int("user_id", claims.UserID))
+		return domain.InvalidToken
+	}
+	return nil
+}
+
+func (s *AuthServiceStruct) ResendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return domain.EmailNotSet
+	}
+	if user.EmailVerified {
+		return domain.EmailAlreadyVerified
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+func (s *AuthServiceStruct) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := s.refreshKeys.Sign(
+		models.TokenClaims{Type: jwt.TypeEmail, UserID: user.ID, Username: user.Username, Email: user.Email},
+		s.emailTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("To confirm your email address open the link below. It expires in %s.\n\n%s?token=%s",
+			s.emailTTL, s.emailURL, url.QueryEscape(token)),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("verification email sent", zap.Int("user_id", user.ID))
+	return nil
+}
@@ -0,0 +1,308 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/oidc"
+	"context"
+	"crypto/rand"
+	"errors"
+	"go.uber.org/zap"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// usernameAttempts - сколько раз пробуется имя со случайным суффиксом,
+// если имя, предложенное провайдером, занято.
+const usernameAttempts = 5
+
+// ExternalProvider - внешний OpenID Connect провайдер. Реализуется
+// oidc.Provider.
+type ExternalProvider interface {
+	Name() string
+	LinkByEmail() bool
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oidc.Identity, error)
+}
+
+func providersByName(providers []ExternalProvider) map[string]ExternalProvider {
+	byName := make(map[string]ExternalProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return byName
+}
+
+func (s *AuthServiceStruct) ListExternalProviders(ctx context.Context) []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartExternalLogin начинает authorization code flow с PKCE у
+// провайдера. state, nonce и code_verifier сохраняются до возврата
+// пользователя от провайдера.
+func (s *AuthServiceStruct) StartExternalLogin(ctx context.Context, providerName string) (*models.ExternalLoginStart, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, domain.ExternalProviderNotFound
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := newResetToken()
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeChallenge(verifier))
+	if err != nil {
+		s.logger.Error("failed to build authorization url",
+			zap.String("provider", providerName),
+			zap.Error(err))
+		return nil, err
+	}
+
+	err = s.identities.SaveState(ctx, &models.ExternalLoginState{
+		StateHash:    hashResetToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("external login started", zap.String("provider", providerName))
+	return &models.ExternalLoginStart{AuthorizationURL: authURL, State: state}, nil
+}
+
+// CompleteExternalLogin обменивает code от провайдера на ID токен и
+// выполняет вход пользователя, привязанного к его subject. При первом
+// входе пользователь создается или, если провайдеру разрешено,
+// связывается с существующим по подтвержденному адресу почты.
+func (s *AuthServiceStruct) CompleteExternalLogin(ctx context.Context, providerName, state, code string, client models.ClientInfo) (*models.LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, domain.ExternalProviderNotFound
+	}
+
+	stored, err := s.identities.ConsumeState(ctx, hashResetToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if stored.Provider != providerName || !time.Now().Before(stored.ExpiresAt) {
+		s.logger.Warn("external login state expired or issued for another provider",
+			zap.String("provider", providerName),
+			zap.String("state_provider", stored.Provider))
+		return nil, domain.ExternalLoginStateNotFound
+	}
+
+	identity, err := provider.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		s.logger.Warn("external login rejected",
+			zap.String("provider", providerName),
+			zap.String("ip", client.IP),
+			zap.Error(err))
+		if errors.Is(err, oidc.ErrDiscovery) {
+			return nil, err
+		}
+		s.recordAudit(ctx, &models.AuditEvent{
+			Type:    models.AuditLoginFailed,
+			Details: map[string]string{"method": "oidc:" + providerName, "reason": "external_login_rejected"},
+		}, client)
+		return nil, domain.ExternalLoginFailed
+	}
+
+	user, err := s.externalUser(ctx, provider, identity)
+	if err != nil {
+		s.logger.Error("failed to resolve external user",
+			zap.String("provider", providerName),
+			zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("external identity authenticated",
+		zap.String("provider", providerName),
+		zap.Int("user_id", user.ID))
+	return s.completeLogin(ctx, user, "oidc:"+providerName, client)
+}
+
+// externalUser находит пользователя, привязанного к identity, или
+// привязывает нового.
+func (s *AuthServiceStruct) externalUser(ctx context.Context, provider ExternalProvider, identity *oidc.Identity) (*models.User, error) {
+	email := ""
+	if identity.EmailVerified && checkEmail(identity.Email) == nil {
+		email = identity.Email
+	}
+
+	linked, err := s.identities.Find(ctx, provider.Name(), identity.Subject)
+	if err == nil {
+		if err := s.identities.TouchLogin(ctx, provider.Name(), identity.Subject, email); err != nil {
+			s.logger.Warn("failed to update external identity",
+				zap.String("provider", provider.Name()),
+				zap.Error(err))
+		}
+		return s.repo.FindByID(ctx, linked.UserID)
+	} else if !errors.Is(err, domain.ExternalIdentityNotFound) {
+		return nil, err
+	}
+
+	var user *models.User
+	if email != "" {
+		existing, err := s.repo.FindByEmail(ctx, email)
+		switch {
+		case err == nil && provider.LinkByEmail() && existing.EmailVerified:
+			s.logger.Info("linking external identity by verified email",
+				zap.String("provider", provider.Name()),
+				zap.Int("user_id", existing.ID))
+			user = existing
+		case err == nil:
+			// Адрес занят другим пользователем: новый пользователь
+			// создается без почты.
+			email = ""
+		case !errors.Is(err, domain.UserNotFound):
+			return nil, err
+		}
+	}
+
+	// Пользователь, привязка и событие user_created создаются в одной
+	// транзакции: если параллельный вход с тем же subject успел создать
+	// привязку первым, созданный здесь пользователь откатывается вместе с
+	// ней.
+	created := user == nil
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if created {
+			var err error
+			if user, err = s.createExternalUser(ctx, identity, email); err != nil {
+				return err
+			}
+		}
+		err := s.identities.Create(ctx, &models.ExternalIdentity{
+			Provider: provider.Name(),
+			Subject:  identity.Subject,
+			UserID:   user.ID,
+			Email:    email,
+		})
+		if err != nil || !created {
+			return err
+		}
+		return s.appendUserEvent(ctx, &models.UserEvent{
+			Type:   models.UserCreated,
+			UserID: user.ID,
+			Data:   map[string]string{"username": user.Username},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.userEventWake.notify()
+	}
+	return user, nil
+}
+
+// createExternalUser создает пользователя со случайным паролем: войти по
+// паролю он сможет только после сброса пароля.
+func (s *AuthServiceStruct) createExternalUser(ctx context.Context, identity *oidc.Identity, email string) (*models.User, error) {
+	secret, err := newResetToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.hasher.Hash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := s.externalUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{Username: username, Password: hashedPassword, Email: email}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.repo.GrantRole(ctx, user.ID, models.RoleUser); err != nil {
+		return nil, err
+	}
+	if email != "" {
+		if _, err := s.repo.MarkEmailVerified(ctx, user.ID, email); err != nil {
+			return nil, err
+		}
+		user.EmailVerified = true
+	}
+
+	s.logger.Info("user created from external identity",
+		zap.Int("user_id", user.ID),
+		zap.String("username", username))
+	return user, nil
+}
+
+// externalUsername выбирает свободное имя пользователя на основе
+// preferred_username, адреса почты или имени из ID токена.
+func (s *AuthServiceStruct) externalUsername(ctx context.Context, identity *oidc.Identity) (string, error) {
+	localPart, _, _ := strings.Cut(identity.Email, "@")
+	base := "user"
+	for _, candidate := range []string{identity.PreferredUsername, localPart, identity.Name} {
+		if candidate = sanitizeUsername(candidate, s.usernames.maxLength); len(candidate) >= s.usernames.minLength {
+			base = candidate
+			break
+		}
+	}
+
+	username := base
+	for attempt := 0; attempt <= usernameAttempts; attempt++ {
+		if attempt > 0 {
+			suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return "", err
+			}
+			tail := "-" + suffix.String()
+			username = base
+			if len(username)+len(tail) > s.usernames.maxLength {
+				username = username[:s.usernames.maxLength-len(tail)]
+			}
+			username += tail
+		}
+
+		if len(s.usernames.check(username)) > 0 {
+			continue
+		}
+		_, err := s.repo.FindByUsername(ctx, username)
+		if errors.Is(err, domain.UserNotFound) {
+			return username, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", domain.UserAlreadyExists
+}
+
+// sanitizeUsername оставляет в имени только символы, допустимые
+// usernamePolicy, заменяя пробелы точками.
+func sanitizeUsername(name string, maxLength int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('.')
+		}
+	}
+	username := strings.Trim(b.String(), ".-_")
+	if len(username) > maxLength {
+		username = username[:maxLength]
+	}
+	return username
+}
@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/oidc"
+	"AuthService/pkg/oidc/oidctest"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCorpProvider(idp *oidctest.IdP, linkByEmail bool) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Name:         "corp",
+		Issuer:       idp.Issuer,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "https://forum.example/login/corp/callback",
+		LinkByEmail:  linkByEmail,
+	}, nil)
+}
+
+// externalLogin проходит весь flow: StartExternalLogin, вход у
+// провайдера и CompleteExternalLogin.
+func externalLogin(t *testing.T, s *AuthServiceStruct, idp *oidctest.IdP, identity oidc.Identity) (*models.LoginResult, error) {
+	start, err := s.StartExternalLogin(context.Background(), "corp")
+	require.NoError(t, err)
+
+	code, state, err := idp.Login(start.AuthorizationURL, identity)
+	require.NoError(t, err)
+	require.Equal(t, start.State, state)
+
+	return s.CompleteExternalLogin(context.Background(), "corp", state, code, models.ClientInfo{IP: "127.0.0.1"})
+}
+
+var corpAlice = oidc.Identity{
+	Subject:           "alice-123",
+	Email:             "alice@corp.example",
+	EmailVerified:     true,
+	PreferredUsername: "Alice",
+}
+
+func TestExternalLogin_CreatesAndReusesUser(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	s := newTestService(t, newCorpProvider(idp, false))
+	ctx := context.Background()
+
+	result, err := externalLogin(t, s, idp, corpAlice)
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+
+	claims, err := s.keys.Validate(result.Tokens.AccessToken, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+	user, err := s.repo.FindByID(ctx, claims.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@corp.example", user.Email)
+
+	result, err = externalLogin(t, s, idp, corpAlice)
+	require.NoError(t, err)
+	again, err := s.keys.Validate(result.Tokens.AccessToken, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, claims.UserID, again.UserID)
+	_, err = s.repo.FindByID(ctx, claims.UserID+1)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
+
+// failingIdentities не может сохранить привязку внешнего аккаунта.
+type failingIdentities struct {
+	repositories.ExternalIdentityRepo
+}
+
+func (failingIdentities) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	return errors.New("connection reset")
+}
+
+func TestExternalLogin_RollsBackUserWhenIdentityIsNotCreated(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	s := newTestService(t, newCorpProvider(idp, false))
+	s.identities = failingIdentities{s.identities}
+
+	_, err := externalLogin(t, s, idp, corpAlice)
+	assert.EqualError(t, err, "connection reset")
+
+	_, err = s.repo.FindByUsername(context.Background(), "alice")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	_, err = s.repo.FindByEmail(context.Background(), corpAlice.Email)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
+
+func TestExternalLogin_LinkByEmail(t *testing.T) {
+	for _, linkByEmail := range []bool{true, false} {
+		idp := oidctest.NewIdP("forum", "secret")
+		defer idp.Close()
+		s := newTestService(t, newCorpProvider(idp, linkByEmail))
+		ctx := context.Background()
+		existing := createUser(t, s, &models.User{Username: "alice", Email: "alice@corp.example", EmailVerified: true})
+
+		_, err := externalLogin(t, s, idp, corpAlice)
+		require.NoError(t, err)
+
+		linked, err := s.identities.Find(ctx, "corp", "alice-123")
+		require.NoError(t, err)
+		if linkByEmail {
+			assert.Equal(t, existing.ID, linked.UserID)
+		} else {
+			// Адрес занят: создается отдельный пользователь без почты.
+			assert.NotEqual(t, existing.ID, linked.UserID)
+			created, err := s.repo.FindByID(ctx, linked.UserID)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(created.Username, "alice-"), created.Username)
+			assert.Empty(t, created.Email)
+		}
+	}
+}
+
+func TestExternalLogin_RejectsInvalidState(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	s := newTestService(t, newCorpProvider(idp, false))
+	ctx := context.Background()
+
+	start, err := s.StartExternalLogin(ctx, "corp")
+	require.NoError(t, err)
+	code, state, err := idp.Login(start.AuthorizationURL, corpAlice)
+	require.NoError(t, err)
+
+	_, err = s.CompleteExternalLogin(ctx, "corp", "forged", code, models.ClientInfo{})
+	assert.ErrorIs(t, err, domain.ExternalLoginStateNotFound)
+
+	// state одноразовый.
+	_, err = s.CompleteExternalLogin(ctx, "corp", state, code, models.ClientInfo{})
+	require.NoError(t, err)
+	_, err = s.CompleteExternalLogin(ctx, "corp", state, code, models.ClientInfo{})
+	assert.ErrorIs(t, err, domain.ExternalLoginStateNotFound)
+
+	s.stateTTL = -time.Second
+	start, err = s.StartExternalLogin(ctx, "corp")
+	require.NoError(t, err)
+	code, state, err = idp.Login(start.AuthorizationURL, corpAlice)
+	require.NoError(t, err)
+	_, err = s.CompleteExternalLogin(ctx, "corp", state, code, models.ClientInfo{})
+	assert.ErrorIs(t, err, domain.ExternalLoginStateNotFound)
+
+	_, err = s.StartExternalLogin(ctx, "other")
+	assert.ErrorIs(t, err, domain.ExternalProviderNotFound)
+}
+
+func TestExternalLogin_RejectsForgedIDToken(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	idp.Claims = func(claims gojwt.MapClaims) { claims["aud"] = "other-client" }
+	s := newTestService(t, newCorpProvider(idp, false))
+
+	_, err := externalLogin(t, s, idp, corpAlice)
+	assert.ErrorIs(t, err, domain.ExternalLoginFailed)
+	_, err = s.repo.FindByEmail(context.Background(), corpAlice.Email)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
+
+func TestSanitizeUsername(t *testing.T) {
+	assert.Equal(t, "alice", sanitizeUsername("Alice", 32))
+	assert.Equal(t, "alice.smith", sanitizeUsername(" Alice Smith ", 32))
+	assert.Equal(t, "j.doeforum", sanitizeUsername("j.doe+forum", 32))
+	assert.Equal(t, "", sanitizeUsername("Алиса", 32))
+	assert.Equal(t, "abc", sanitizeUsername("abcdef", 3))
+}
@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// Introspect проверяет подпись и claims access токена, а также что
+// владелец не заблокирован и сессия токена не отозвана: блокировка и
+// выход с устройства действуют сразу, не дожидаясь истечения токена.
+// Personal access токены проверяются по базе. Как в RFC 7662,
+// неактивный токен не считается ошибкой: причина отказа возвращается в
+// Introspection.Reason. Ошибка означает, что проверить токен не удалось.
+func (s *AuthServiceStruct) Introspect(ctx context.Context, token string) (*models.Introspection, error) {
+	s.logger.Debug("introspecting token")
+
+	if strings.HasPrefix(token, personalTokenPrefix) {
+		return s.introspectPersonalToken(ctx, token)
+	}
+
+	claims, err := s.keys.Validate(token, jwt.TypeAccess)
+	if err != nil {
+		reason := s.jwtInactiveReason(token, err)
+		s.logger.Warn("token verification failed",
+			zap.String("reason", string(reason)),
+			zap.Error(err))
+		return &models.Introspection{Reason: reason}, nil
+	}
+
+	if inactive, err := s.introspectOwner(ctx, claims.UserID); inactive != nil || err != nil {
+		return inactive, err
+	}
+	if err := s.checkSession(ctx, claims); errors.Is(err, domain.SessionRevoked) {
+		return &models.Introspection{Reason: models.TokenRevoked}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	claims.Credential = models.CredentialAccessToken
+	if claims.ClientID != "" {
+		claims.Credential = models.CredentialOAuthAccessToken
+	}
+
+	s.logger.Debug("token verified successfully",
+		zap.Int("user_id", claims.UserID),
+		zap.String("username", claims.Username))
+	return &models.Introspection{Active: true, Claims: claims}, nil
+}
+
+// jwtInactiveReason классифицирует ошибку проверки access токена.
+// Refresh токены, токены подтверждения почты и второго фактора подписаны
+// другим ключом, поэтому их тип виден только после проверки этим ключом.
+func (s *AuthServiceStruct) jwtInactiveReason(token string, err error) models.InactiveReason {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return models.TokenExpired
+	case errors.Is(err, jwt.ErrWrongTokenType):
+		return models.TokenWrongType
+	}
+	if _, err := s.refreshKeys.Validate(token, jwt.TypeRefresh); err == nil || errors.Is(err, jwt.ErrWrongTokenType) {
+		return models.TokenWrongType
+	}
+	return models.TokenMalformed
+}
+
+// introspectOwner возвращает неактивный результат, если владелец токена
+// заблокирован, и nil, если токен можно принимать.
+func (s *AuthServiceStruct) introspectOwner(ctx context.Context, userID int) (*models.Introspection, error) {
+	err := s.checkSuspension(ctx, userID)
+	var suspended *domain.SuspendedError
+	if errors.As(err, &suspended) {
+		return &models.Introspection{
+			Reason:     models.TokenOwnerBanned,
+			Suspension: &models.Suspension{UserID: userID, Reason: suspended.Reason, Until: suspended.Until},
+		}, nil
+	}
+	return nil, err
+}
+
+// VerifyToken принимает токен, только если Introspect признал его
+// активным. Для заблокированного владельца возвращается SuspendedError,
+// для остальных неактивных токенов - InvalidToken. Вид предъявленного
+// токена сообщается в TokenClaims.Credential.
+func (s *AuthServiceStruct) VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error) {
+	result, err := s.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if result.Active {
+		return result.Claims, nil
+	}
+	if result.Suspension != nil {
+		return nil, &domain.SuspendedError{Reason: result.Suspension.Reason, Until: result.Suspension.Until}
+	}
+	return nil, domain.InvalidToken
+}
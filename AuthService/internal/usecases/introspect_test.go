@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospect_AccessToken(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	sign := func(claims models.TokenClaims, ttl time.Duration) string {
+		token, err := s.keys.Sign(claims, ttl)
+		require.NoError(t, err)
+		return token
+	}
+	refresh, err := s.refreshKeys.Sign(models.TokenClaims{Type: jwt.TypeRefresh, UserID: 1, Username: "alice"}, time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		reason models.InactiveReason
+	}{
+		{"malformed", "not-a-token", models.TokenMalformed},
+		{"expired", sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 1, Username: "alice"}, -time.Minute), models.TokenExpired},
+		{"refresh token", refresh, models.TokenWrongType},
+		// Сессии s1 нет в хранилище.
+		{"revoked session", sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 1, Username: "alice", SessionID: "s1"}, time.Minute), models.TokenRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.Introspect(ctx, tt.token)
+			require.NoError(t, err)
+			assert.False(t, result.Active)
+			assert.Nil(t, result.Claims)
+			assert.Equal(t, tt.reason, result.Reason)
+
+			_, err = s.VerifyToken(ctx, tt.token)
+			assert.ErrorIs(t, err, domain.InvalidToken)
+		})
+	}
+
+	token := sign(models.TokenClaims{Type: jwt.TypeAccess, ID: "jti-1", UserID: 1, Username: "alice", Roles: []string{"user"}}, time.Minute)
+	result, err := s.Introspect(ctx, token)
+	require.NoError(t, err)
+	require.True(t, result.Active)
+	assert.Equal(t, "jti-1", result.Claims.ID)
+	assert.Equal(t, 1, result.Claims.UserID)
+	assert.Equal(t, []string{"user"}, result.Claims.Roles)
+	assert.Equal(t, models.CredentialAccessToken, result.Claims.Credential)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), result.Claims.ExpiresAt, 2*time.Second)
+}
+
+func TestIntrospect_SuspendedOwner(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+	_, err := s.SuspendUser(ctx, testAdminID, alice.ID, "spam", nil, models.ClientInfo{})
+	require.NoError(t, err)
+
+	token, err := s.keys.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: alice.ID, Username: "alice"}, time.Minute)
+	require.NoError(t, err)
+
+	result, err := s.Introspect(ctx, token)
+	require.NoError(t, err)
+	assert.False(t, result.Active)
+	assert.Equal(t, models.TokenOwnerBanned, result.Reason)
+	require.NotNil(t, result.Suspension)
+	assert.Equal(t, "spam", result.Suspension.Reason)
+
+	_, err = s.VerifyToken(ctx, token)
+	var suspended *domain.SuspendedError
+	require.ErrorAs(t, err, &suspended)
+	assert.Equal(t, "spam", suspended.Reason)
+}
+
+func TestIntrospect_PersonalToken(t *testing.T) {
+	s, alice := newPersonalTokenService(t)
+	ctx := context.Background()
+
+	token, raw, err := s.CreatePersonalToken(ctx, alice.ID, "ci", []string{"topics:write"}, time.Time{})
+	require.NoError(t, err)
+
+	result, err := s.Introspect(ctx, raw+"x")
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenMalformed, result.Reason)
+
+	setPersonalTokenExpiry(t, s, raw, time.Now().Add(-time.Second))
+	result, err = s.Introspect(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenExpired, result.Reason)
+
+	setPersonalTokenExpiry(t, s, raw, time.Now().Add(time.Hour))
+	require.NoError(t, s.RevokePersonalToken(ctx, alice.ID, token.ID))
+	result, err = s.Introspect(ctx, raw)
+	require.NoError(t, err)
+	assert.False(t, result.Active)
+	assert.Equal(t, models.TokenRevoked, result.Reason)
+}
@@ -0,0 +1,444 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"go.uber.org/zap"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxOAuthClientNameLength = 100
+	// Длина code_verifier по RFC 7636, раздел 4.1.
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// RegisterOAuthClient регистрирует клиент OAuth. Для конфиденциального
+// клиента возвращается секрет; он показывается один раз и хранится только
+// в виде хеша. Без страницы согласия клиенты не регистрируются.
+func (s *AuthServiceStruct) RegisterOAuthClient(ctx context.Context, ownerID int, name string, redirectURIs, scopes []string, confidential bool) (*models.OAuthClient, string, error) {
+	if !s.consentEnabled {
+		return nil, "", domain.OAuthConsentDisabled
+	}
+	name = strings.TrimSpace(name)
+	reasons := checkOAuthClient(name, redirectURIs, scopes)
+	if len(reasons) > 0 {
+		return nil, "", &domain.ValidationError{Reasons: reasons}
+	}
+
+	id, err := jwt.NewID()
+	if err != nil {
+		return nil, "", err
+	}
+	client := &models.OAuthClient{
+		ID:           id,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		OwnerID:      ownerID,
+	}
+
+	var secret string
+	if confidential {
+		// Секрет генерируется так же, как токен сброса пароля, но
+		// хешируется паролевым хешем: он живет долго.
+		if secret, err = newResetToken(); err != nil {
+			return nil, "", err
+		}
+		if client.SecretHash, err = s.hasher.Hash(secret); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := s.oauth.CreateClient(ctx, client); err != nil {
+		s.logger.Error("failed to register oauth client",
+			zap.String("name", name),
+			zap.Error(err))
+		return nil, "", err
+	}
+
+	s.logger.Info("oauth client registered",
+		zap.String("client_id", client.ID),
+		zap.String("name", name),
+		zap.Bool("confidential", confidential),
+		zap.Int("owner_id", ownerID))
+	return client, secret, nil
+}
+
+func checkOAuthClient(name string, redirectURIs, scopes []string) []string {
+	var reasons []string
+	if name == "" || len(name) > maxOAuthClientNameLength {
+		reasons = append(reasons, "name_invalid")
+	}
+	if len(redirectURIs) == 0 {
+		reasons = append(reasons, "redirect_uris_required")
+	}
+	for _, raw := range redirectURIs {
+		if !validRedirectURI(raw) {
+			reasons = append(reasons, "redirect_uri_invalid")
+			break
+		}
+	}
+	if len(scopes) == 0 {
+		reasons = append(reasons, "scopes_required")
+	}
+	for _, scope := range scopes {
+		if !models.Scope(scope).Valid() {
+			reasons = append(reasons, "scope_unknown")
+			break
+		}
+	}
+	return reasons
+}
+
+// validRedirectURI допускает абсолютные URI без фрагмента (RFC 6749,
+// раздел 3.1.2): https, http только на loopback и схемы нативных
+// приложений в виде обратного доменного имени (RFC 8252, разделы 7.1 и
+// 7.3). Остальные схемы, например javascript:, data: или file:, браузер
+// выполнил бы или открыл локально вместо перехода к клиенту.
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		return privateUseScheme(u.Scheme)
+	}
+}
+
+// privateUseScheme сообщает, записана ли схема как обратное доменное имя
+// (com.example.app): такую схему регистрирует только приложение ее
+// владельца.
+func privateUseScheme(scheme string) bool {
+	labels := strings.Split(scheme, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Authorize обрабатывает запрос к authorize endpoint от имени вошедшего
+// пользователя userID. Если пользователь уже давал клиенту согласие на
+// запрошенные scopes или одобрил запрос сейчас, выдается код; иначе
+// результат требует показать экран согласия.
+func (s *AuthServiceStruct) Authorize(ctx context.Context, userID int, req models.AuthorizeRequest, decision models.ConsentDecision) (*models.AuthorizeResult, error) {
+	client, err := s.oauth.FindClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, domain.OAuthClientNotFound) {
+			return nil, &domain.OAuthError{Code: domain.OAuthInvalidClient, Description: "unknown client_id"}
+		}
+		return nil, err
+	}
+	if !client.AllowsRedirect(req.RedirectURI) {
+		return nil, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "redirect_uri is not registered for the client"}
+	}
+
+	// Дальше redirect_uri проверен, и ошибки возвращаются клиенту через него.
+	if err := checkAuthorizeRequest(client, req); err != nil {
+		return nil, err
+	}
+	if decision == models.ConsentDenied {
+		s.logger.Info("oauth authorization denied by user",
+			zap.Int("user_id", userID),
+			zap.String("client_id", client.ID))
+		return nil, &domain.OAuthError{Code: domain.OAuthAccessDenied, Description: "the user denied the request", Redirect: true}
+	}
+	if err := s.checkSuspension(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	result := &models.AuthorizeResult{Client: client, Scopes: req.Scopes}
+	if decision == models.ConsentApproved {
+		err := s.oauth.SaveConsent(ctx, &models.OAuthConsent{UserID: userID, ClientID: client.ID, Scopes: req.Scopes})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		consent, err := s.oauth.FindConsent(ctx, userID, client.ID)
+		if err != nil && !errors.Is(err, domain.OAuthConsentNotFound) {
+			return nil, err
+		}
+		if consent == nil || !models.ContainsScopes(consent.Scopes, req.Scopes) {
+			result.ConsentRequired = true
+			return result, nil
+		}
+	}
+
+	code, err := newResetToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.oauth.CreateCode(ctx, &models.AuthorizationCode{
+		CodeHash:      hashResetToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.codeTTL),
+	})
+	if err != nil {
+		s.logger.Error("failed to create authorization code",
+			zap.Int("user_id", userID),
+			zap.String("client_id", client.ID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("authorization code issued",
+		zap.Int("user_id", userID),
+		zap.String("client_id", client.ID),
+		zap.Strings("scopes", req.Scopes))
+	result.Code = code
+	return result, nil
+}
+
+func checkAuthorizeRequest(client *models.OAuthClient, req models.AuthorizeRequest) error {
+	redirectError := func(code, description string) error {
+		return &domain.OAuthError{Code: code, Description: description, Redirect: true}
+	}
+
+	if req.ResponseType != "code" {
+		return redirectError(domain.OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+	if len(req.Scopes) == 0 {
+		return redirectError(domain.OAuthInvalidScope, "scope is required")
+	}
+	if !models.ContainsScopes(client.Scopes, req.Scopes) {
+		return redirectError(domain.OAuthInvalidScope, "scope is not allowed for the client")
+	}
+	// PKCE обязателен для всех клиентов, метод plain не поддерживается.
+	if req.CodeChallengeMethod != "S256" {
+		return redirectError(domain.OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if len(req.CodeChallenge) != base64.RawURLEncoding.EncodedLen(sha256.Size) {
+		return redirectError(domain.OAuthInvalidRequest, "code_challenge is invalid")
+	}
+	return nil
+}
+
+// ExchangeToken обрабатывает запрос к token endpoint: обмен кода
+// авторизации (grant_type=authorization_code) и обновление токенов
+// (grant_type=refresh_token).
+func (s *AuthServiceStruct) ExchangeToken(ctx context.Context, req models.TokenRequest, client models.ClientInfo) (*models.OAuthTokens, error) {
+	oauthClient, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens *models.TokenPair
+	var scopes []string
+	switch req.GrantType {
+	case "authorization_code":
+		tokens, scopes, err = s.exchangeCode(ctx, oauthClient, req, client)
+	case "refresh_token":
+		var claims *models.TokenClaims
+		tokens, claims, err = s.refresh(ctx, req.RefreshToken, client, oauthClient.ID)
+		if errors.Is(err, domain.InvalidToken) || errors.Is(err, domain.TokenReused) {
+			return nil, &domain.OAuthError{Code: domain.OAuthInvalidGrant, Description: "refresh token is invalid, expired or revoked"}
+		}
+		if claims != nil {
+			scopes = claims.Scopes
+		}
+	default:
+		return nil, &domain.OAuthError{Code: domain.OAuthUnsupportedGrantType, Description: "grant_type must be authorization_code or refresh_token"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OAuthTokens{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    s.accessTTL,
+		Scopes:       scopes,
+	}, nil
+}
+
+func (s *AuthServiceStruct) exchangeCode(ctx context.Context, oauthClient *models.OAuthClient, req models.TokenRequest, client models.ClientInfo) (*models.TokenPair, []string, error) {
+	invalidGrant := &domain.OAuthError{Code: domain.OAuthInvalidGrant, Description: "authorization code is invalid or expired"}
+
+	sessionID, err := jwt.NewID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	code, err := s.oauth.ConsumeCode(ctx, hashResetToken(req.Code), sessionID)
+	if err != nil {
+		if errors.Is(err, domain.OAuthCodeUsed) {
+			// Повторное предъявление кода означает его утечку: токены,
+			// выданные по нему, отзываются (RFC 6749, раздел 4.1.2).
+			s.revokeCodeSession(ctx, code)
+			return nil, nil, invalidGrant
+		}
+		if errors.Is(err, domain.OAuthCodeNotFound) {
+			return nil, nil, invalidGrant
+		}
+		return nil, nil, err
+	}
+
+	if code.ClientID != oauthClient.ID || code.RedirectURI != req.RedirectURI || time.Now().After(code.ExpiresAt) {
+		s.logger.Warn("authorization code rejected",
+			zap.String("client_id", oauthClient.ID),
+			zap.String("code_client_id", code.ClientID),
+			zap.Int("user_id", code.UserID))
+		return nil, nil, invalidGrant
+	}
+	if !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		s.logger.Warn("pkce verification failed",
+			zap.String("client_id", oauthClient.ID),
+			zap.Int("user_id", code.UserID))
+		return nil, nil, &domain.OAuthError{Code: domain.OAuthInvalidGrant, Description: "code_verifier does not match code_challenge"}
+	}
+
+	user, err := s.repo.FindByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, domain.UserNotFound) {
+			return nil, nil, invalidGrant
+		}
+		return nil, nil, err
+	}
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.openSession(ctx, user, client, sessionID, tokenGrant{clientID: oauthClient.ID, scopes: code.Scopes})
+	if err != nil {
+		s.logger.Error("failed to issue oauth tokens",
+			zap.String("client_id", oauthClient.ID),
+			zap.Int("user_id", user.ID),
+			zap.Error(err))
+		return nil, nil, err
+	}
+
+	s.logger.Info("authorization code exchanged",
+		zap.String("client_id", oauthClient.ID),
+		zap.Int("user_id", user.ID),
+		zap.String("session_id", sessionID))
+	return tokens, code.Scopes, nil
+}
+
+func (s *AuthServiceStruct) revokeCodeSession(ctx context.Context, code *models.AuthorizationCode) {
+	if code == nil || code.SessionID == "" {
+		return
+	}
+	err := s.sessions.Revoke(ctx, code.UserID, code.SessionID)
+	if err != nil && !errors.Is(err, domain.SessionNotFound) {
+		s.logger.Error("failed to revoke session of reused authorization code",
+			zap.String("session_id", code.SessionID),
+			zap.Error(err))
+	}
+}
+
+// verifyCodeChallenge проверяет code_verifier по методу S256:
+// BASE64URL(SHA256(verifier)) == challenge.
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < minCodeVerifierLength || len(verifier) > maxCodeVerifierLength {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(codeChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// codeChallenge вычисляет code_challenge метода S256.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RevokeOAuthToken отзывает access или refresh токен клиента по RFC 7009
+// вместе со всей сессией. Неизвестный, чужой или уже отозванный токен
+// ошибкой не считается.
+func (s *AuthServiceStruct) RevokeOAuthToken(ctx context.Context, clientID, clientSecret, token string) error {
+	oauthClient, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	claims, err := s.refreshKeys.Validate(token, jwt.TypeRefresh)
+	if err != nil {
+		claims, err = s.keys.Validate(token, jwt.TypeAccess)
+	}
+	if err != nil || claims.ClientID != oauthClient.ID {
+		s.logger.Debug("ignoring revocation of unknown token", zap.String("client_id", oauthClient.ID))
+		return nil
+	}
+
+	sessionID := claims.SessionID
+	if claims.Type == jwt.TypeRefresh {
+		stored, err := s.tokens.FindByID(ctx, claims.ID)
+		if errors.Is(err, domain.TokenNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		sessionID = stored.FamilyID
+	}
+	if sessionID == "" {
+		return nil
+	}
+
+	err = s.sessions.Revoke(ctx, claims.UserID, sessionID)
+	if err != nil && !errors.Is(err, domain.SessionNotFound) {
+		return err
+	}
+
+	s.logger.Info("oauth token revoked",
+		zap.String("client_id", oauthClient.ID),
+		zap.Int("user_id", claims.UserID),
+		zap.String("session_id", sessionID))
+	return nil
+}
+
+// authenticateClient проверяет client_id и, для конфиденциальных
+// клиентов, секрет.
+func (s *AuthServiceStruct) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	invalidClient := &domain.OAuthError{Code: domain.OAuthInvalidClient, Description: "client authentication failed"}
+
+	if clientID == "" {
+		return nil, invalidClient
+	}
+	client, err := s.oauth.FindClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, domain.OAuthClientNotFound) {
+			return nil, invalidClient
+		}
+		return nil, err
+	}
+
+	if client.Public() {
+		if clientSecret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+	if _, err := s.hasher.Verify(client.SecretHash, clientSecret); err != nil {
+		s.logger.Warn("invalid oauth client secret", zap.String("client_id", clientID))
+		return nil, invalidClient
+	}
+	return client, nil
+}
@@ -0,0 +1,241 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChallenge = BASE64URL(SHA256(testVerifier)).
+const (
+	testVerifier  = "dBjftJeZ4CVP-mJ92K3gqOQKYrJ9r6xjUKQ4SBvSEfc"
+	testChallenge = "V3GHZGQRrqTun92Oj5XcxktE7YNyPL3J4Ulu8BRqHSk"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	assert.True(t, verifyCodeChallenge(testChallenge, testVerifier))
+	assert.False(t, verifyCodeChallenge(testChallenge, testVerifier[:len(testVerifier)-1]+"d"))
+	assert.False(t, verifyCodeChallenge(testChallenge, ""))
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri   string
+		valid bool
+	}{
+		{"https://bot.example/callback", true},
+		{"http://localhost:8080/callback", true},
+		{"http://127.0.0.1:51004/callback", true},
+		{"http://[::1]:51004/callback", true},
+		{"com.example.forum:/oauth", true},
+		{"com.example.forum://oauth", true},
+		{"http://bot.example/callback", false},
+		{"https://bot.example/callback#frag", false},
+		{"https:///callback", false},
+		{"/callback", false},
+		{"forumapp://oauth", false},
+		{"com..forum:/oauth", false},
+		{"javascript:alert(document.cookie)", false},
+		{"JavaScript:alert(1)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"vbscript:msgbox(1)", false},
+		{"file:///etc/passwd", false},
+		{"ftp://bot.example/callback", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			assert.Equal(t, tt.valid, validRedirectURI(tt.uri))
+		})
+	}
+}
+
+func TestRegisterOAuthClient(t *testing.T) {
+	t.Run("consent page is not configured", func(t *testing.T) {
+		s := newTestService(t)
+		s.consentEnabled = false
+		_, _, err := s.RegisterOAuthClient(context.Background(), 1, "Forum bot", []string{"https://bot.example/cb"}, []string{"topics:write"}, false)
+		assert.ErrorIs(t, err, domain.OAuthConsentDisabled)
+	})
+
+	t.Run("script redirect uri", func(t *testing.T) {
+		s := newTestService(t)
+		_, _, err := s.RegisterOAuthClient(context.Background(), 1, "Forum bot", []string{"javascript:alert(1)"}, []string{"topics:write"}, false)
+		var invalid *domain.ValidationError
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, []string{"redirect_uri_invalid"}, invalid.Reasons)
+	})
+
+	t.Run("public client", func(t *testing.T) {
+		s := newTestService(t)
+		client, secret, err := s.RegisterOAuthClient(context.Background(), 1, "Forum bot", []string{"com.example.forum:/oauth"}, []string{"topics:write"}, false)
+		require.NoError(t, err)
+		assert.Empty(t, secret)
+		_, err = s.oauth.FindClient(context.Background(), client.ID)
+		assert.NoError(t, err)
+	})
+}
+
+// newOAuthService возвращает сервис с публичным клиентом forum-bot и
+// пользователем alice.
+func newOAuthService(t *testing.T) (*AuthServiceStruct, *models.User) {
+	s := newTestService(t)
+	require.NoError(t, s.oauth.CreateClient(context.Background(), &models.OAuthClient{
+		ID:           "forum-bot",
+		Name:         "Forum bot",
+		RedirectURIs: []string{"https://bot.example/callback"},
+		Scopes:       []string{"topics:write", "profile:read"},
+	}))
+	return s, createUser(t, s, &models.User{Username: "alice"})
+}
+
+func authorizeRequest(modify func(req *models.AuthorizeRequest)) models.AuthorizeRequest {
+	req := models.AuthorizeRequest{
+		ClientID:            "forum-bot",
+		RedirectURI:         "https://bot.example/callback",
+		ResponseType:        "code",
+		Scopes:              []string{"topics:write"},
+		CodeChallenge:       testChallenge,
+		CodeChallengeMethod: "S256",
+	}
+	if modify != nil {
+		modify(&req)
+	}
+	return req
+}
+
+func codeRequest(code, verifier string) models.TokenRequest {
+	return models.TokenRequest{
+		GrantType:    "authorization_code",
+		ClientID:     "forum-bot",
+		Code:         code,
+		RedirectURI:  "https://bot.example/callback",
+		CodeVerifier: verifier,
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          models.AuthorizeRequest
+		wantCode     string
+		wantRedirect bool
+	}{
+		{name: "unknown client", req: authorizeRequest(func(r *models.AuthorizeRequest) { r.ClientID = "other" }),
+			wantCode: domain.OAuthInvalidClient},
+		{name: "unregistered redirect", req: authorizeRequest(func(r *models.AuthorizeRequest) { r.RedirectURI = "https://evil.example" }),
+			wantCode: domain.OAuthInvalidRequest},
+		{name: "scope not allowed", req: authorizeRequest(func(r *models.AuthorizeRequest) { r.Scopes = []string{"comments:write"} }),
+			wantCode: domain.OAuthInvalidScope, wantRedirect: true},
+		{name: "plain pkce", req: authorizeRequest(func(r *models.AuthorizeRequest) { r.CodeChallengeMethod = "plain" }),
+			wantCode: domain.OAuthInvalidRequest, wantRedirect: true},
+		{name: "token response type", req: authorizeRequest(func(r *models.AuthorizeRequest) { r.ResponseType = "token" }),
+			wantCode: domain.OAuthUnsupportedResponseType, wantRedirect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, alice := newOAuthService(t)
+			_, err := s.Authorize(context.Background(), alice.ID, tt.req, models.ConsentApproved)
+
+			var oauthErr *domain.OAuthError
+			require.True(t, errors.As(err, &oauthErr))
+			assert.Equal(t, tt.wantCode, oauthErr.Code)
+			assert.Equal(t, tt.wantRedirect, oauthErr.Redirect)
+		})
+	}
+
+	t.Run("consent flow", func(t *testing.T) {
+		s, alice := newOAuthService(t)
+		ctx := context.Background()
+
+		result, err := s.Authorize(ctx, alice.ID, authorizeRequest(nil), models.ConsentPending)
+		require.NoError(t, err)
+		assert.True(t, result.ConsentRequired)
+		assert.Empty(t, result.Code)
+
+		_, err = s.Authorize(ctx, alice.ID, authorizeRequest(nil), models.ConsentDenied)
+		var oauthErr *domain.OAuthError
+		require.True(t, errors.As(err, &oauthErr))
+		assert.Equal(t, domain.OAuthAccessDenied, oauthErr.Code)
+
+		result, err = s.Authorize(ctx, alice.ID, authorizeRequest(nil), models.ConsentApproved)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Code)
+
+		// Согласие запоминается и повторно не запрашивается.
+		result, err = s.Authorize(ctx, alice.ID, authorizeRequest(nil), models.ConsentPending)
+		require.NoError(t, err)
+		assert.False(t, result.ConsentRequired)
+		assert.NotEmpty(t, result.Code)
+
+		// Новый scope требует нового согласия.
+		result, err = s.Authorize(ctx, alice.ID, authorizeRequest(func(r *models.AuthorizeRequest) {
+			r.Scopes = []string{"topics:write", "profile:read"}
+		}), models.ConsentPending)
+		require.NoError(t, err)
+		assert.True(t, result.ConsentRequired)
+	})
+}
+
+func TestExchangeToken_IssuesScopedTokens(t *testing.T) {
+	s, alice := newOAuthService(t)
+	ctx := context.Background()
+
+	result, err := s.Authorize(ctx, alice.ID, authorizeRequest(nil), models.ConsentApproved)
+	require.NoError(t, err)
+	tokens, err := s.ExchangeToken(ctx, codeRequest(result.Code, testVerifier), testClient)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"topics:write"}, tokens.Scopes)
+
+	claims, err := s.VerifyToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, claims.UserID)
+	assert.Equal(t, "forum-bot", claims.ClientID)
+	assert.Equal(t, []string{"topics:write"}, claims.Scopes)
+
+	// Повторное предъявление кода отзывает выданную по нему сессию.
+	_, err = s.ExchangeToken(ctx, codeRequest(result.Code, testVerifier), testClient)
+	var oauthErr *domain.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, domain.OAuthInvalidGrant, oauthErr.Code)
+	_, err = s.VerifyToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+}
+
+func TestExchangeToken_RejectsInvalidGrants(t *testing.T) {
+	s, alice := newOAuthService(t)
+	ctx := context.Background()
+	require.NoError(t, s.oauth.CreateCode(ctx, &models.AuthorizationCode{
+		CodeHash:      hashResetToken("code"),
+		ClientID:      "forum-bot",
+		UserID:        alice.ID,
+		RedirectURI:   "https://bot.example/callback",
+		Scopes:        []string{"topics:write"},
+		CodeChallenge: testChallenge,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}))
+
+	exchange := func(req models.TokenRequest) string {
+		_, err := s.ExchangeToken(ctx, req, models.ClientInfo{})
+		var oauthErr *domain.OAuthError
+		require.True(t, errors.As(err, &oauthErr), "unexpected error: %v", err)
+		return oauthErr.Code
+	}
+
+	assert.Equal(t, domain.OAuthInvalidClient, exchange(models.TokenRequest{GrantType: "authorization_code", ClientID: "other"}))
+	assert.Equal(t, domain.OAuthUnsupportedGrantType, exchange(models.TokenRequest{GrantType: "password", ClientID: "forum-bot"}))
+	assert.Equal(t, domain.OAuthInvalidGrant, exchange(codeRequest("unknown", testVerifier)))
+	assert.Equal(t, domain.OAuthInvalidGrant, exchange(codeRequest("code", "wrong-verifier-wrong-verifier-wrong-verifier")))
+	// Код одноразовый, даже если первая попытка не прошла проверку PKCE.
+	assert.Equal(t, domain.OAuthInvalidGrant, exchange(codeRequest("code", testVerifier)))
+
+	sessions, err := s.ListSessions(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
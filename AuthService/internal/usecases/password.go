@@ -0,0 +1,230 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/notify"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ChangePassword меняет пароль по текущему паролю. Все сессии
+// пользователя отзываются, для вызывающего открывается новая.
+func (s *AuthServiceStruct) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string, client models.ClientInfo) (*models.TokenPair, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подбор текущего пароля ограничивается так же, как подбор при входе.
+	keys := loginKeys(user.Username, "")
+	if err := s.throttle.check(ctx, keys); err != nil {
+		return nil, err
+	}
+	if _, err := s.hasher.Verify(user.Password, currentPassword); err != nil {
+		s.logger.Warn("invalid current password on password change",
+			zap.Int("user_id", userID))
+		s.throttle.fail(ctx, keys)
+		return nil, domain.InvalidData
+	}
+	s.throttle.reset(ctx, keys[0])
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.GenerateTokens(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditPasswordChanged,
+		UserID:  userID,
+		Details: map[string]string{"method": "change"},
+	}, client)
+
+	s.logger.Info("password changed", zap.Int("user_id", userID))
+	return tokens, nil
+}
+
+// RequestPasswordReset отправляет на адрес пользователя одноразовый
+// токен сброса. Для неизвестного имени, пользователя без адреса и при сбое
+// отправки письма ошибка не возвращается, чтобы по ответу нельзя было
+// проверить существование пользователя или наличие у него адреса.
+//
+// Запросы ограничиваются по имени и по адресу клиента так же, как
+// неудачные попытки входа: каждый запрос, включая запрос для
+// неизвестного имени, откладывает следующий. Иначе сброс можно
+// использовать для рассылки писем на чужой адрес.
+func (s *AuthServiceStruct) RequestPasswordReset(ctx context.Context, username string, client models.ClientInfo) error {
+	keys := resetKeys(username, client.IP)
+	if err := s.throttle.check(ctx, keys); err != nil {
+		var retry *domain.RetryAfterError
+		if errors.As(err, &retry) {
+			s.logger.Warn("password reset requested while throttled", zap.String("username", username))
+			return &domain.RetryAfterError{Err: domain.TooManyResets, RetryAfter: retry.RetryAfter}
+		}
+		return err
+	}
+	s.throttle.fail(ctx, keys)
+
+	user, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, domain.UserNotFound) {
+		s.logger.Warn("password reset requested for unknown user", zap.String("username", username))
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if user.Email == "" {
+		s.logger.Warn("password reset requested for user without email", zap.Int("user_id", user.ID))
+		return nil
+	}
+
+	// Действует только последний запрошенный токен.
+	if err := s.resets.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	reset := &models.PasswordReset{
+		TokenHash: hashResetToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err := s.resets.Create(ctx, reset); err != nil {
+		return err
+	}
+
+	err = s.notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("To reset your password open the link below. It expires in %s.\n\n%s?token=%s",
+			s.resetTTL, s.resetURL, token),
+	})
+	if err != nil {
+		s.logger.Error("failed to deliver password reset token",
+			zap.Int("user_id", user.ID),
+			zap.Error(err))
+		return nil
+	}
+
+	s.logger.Info("password reset requested", zap.Int("user_id", user.ID))
+	return nil
+}
+
+func (s *AuthServiceStruct) ResetPassword(ctx context.Context, token, newPassword string, client models.ClientInfo) error {
+	tokenHash := hashResetToken(token)
+
+	reset, err := s.resets.FindByHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.TokenNotFound) {
+			return domain.InvalidToken
+		}
+		return err
+	}
+	if !reset.Active(time.Now()) {
+		s.logger.Warn("used or expired password reset token provided",
+			zap.Int("user_id", reset.UserID))
+		return domain.InvalidToken
+	}
+
+	user, err := s.repo.FindByID(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Политика проверяется до использования токена, чтобы отказ из-за
+	// слабого пароля не сжигал токен.
+	if reasons := s.passwords.Check(newPassword, user.Username); len(reasons) > 0 {
+		return &domain.ValidationError{Reasons: reasons}
+	}
+	// Токен сгорает только вместе со сменой пароля.
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.resets.Use(ctx, tokenHash); err != nil {
+			return err
+		}
+		return s.setPassword(ctx, user, newPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditPasswordChanged,
+		UserID:  user.ID,
+		Details: map[string]string{"method": "reset"},
+	}, client)
+
+	s.logger.Info("password reset", zap.Int("user_id", user.ID))
+	return nil
+}
+
+// setPassword проверяет и сохраняет новый пароль, после чего отзывает
+// все сессии и неиспользованные токены сброса пользователя. Все три записи
+// выполняются в одной транзакции: иначе сбой после смены пароля оставил бы
+// действующими старые сессии или токены сброса.
+func (s *AuthServiceStruct) setPassword(ctx context.Context, user *models.User, newPassword string) error {
+	if reasons := s.passwords.Check(newPassword, user.Username); len(reasons) > 0 {
+		s.logger.Warn("new password rejected by policy",
+			zap.Int("user_id", user.ID),
+			zap.Strings("reasons", reasons))
+		return &domain.ValidationError{Reasons: reasons}
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+
+		if _, err := s.sessions.RevokeAll(ctx, user.ID, ""); err != nil {
+			s.logger.Error("failed to revoke sessions after password change",
+				zap.Int("user_id", user.ID),
+				zap.Error(err))
+			return err
+		}
+		if err := s.resets.DeleteForUser(ctx, user.ID); err != nil {
+			s.logger.Error("failed to delete password reset tokens",
+				zap.Int("user_id", user.ID),
+				zap.Error(err))
+			return err
+		}
+		return nil
+	})
+}
+
+func resetKeys(username, ip string) []string {
+	keys := loginKeys(username, ip)
+	for i := range keys {
+		keys[i] = "reset:" + keys[i]
+	}
+	return keys
+}
+
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
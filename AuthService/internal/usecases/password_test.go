@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"AuthService/pkg/notify"
+	"AuthService/pkg/password"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessages struct {
+	messages []notify.Message
+}
+
+func (s *sentMessages) Send(ctx context.Context, msg notify.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	return errors.New("smtp: connection refused")
+}
+
+// failingSessions не может отозвать сессии.
+type failingSessions struct {
+	repositories.SessionRepo
+}
+
+func (failingSessions) RevokeAll(ctx context.Context, userID int, exceptID string) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+func newPasswordService(t *testing.T) (*AuthServiceStruct, *sentMessages) {
+	s := newTestService(t)
+	hash, err := s.hasher.Hash("old-password")
+	require.NoError(t, err)
+	createUser(t, s, &models.User{Username: "alice", Password: hash, Email: "alice@example.com"})
+	sent := &sentMessages{}
+	s.notifier = sent
+	return s, sent
+}
+
+func TestRequestPasswordReset_ThrottledByUsernameAndIP(t *testing.T) {
+	s, sent := newPasswordService(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.RequestPasswordReset(ctx, "alice", models.ClientInfo{IP: "192.0.2.1"}))
+	require.Len(t, sent.messages, 1)
+	assert.Equal(t, "alice@example.com", sent.messages[0].To)
+
+	// Тот же пользователь с другого адреса.
+	err := s.RequestPasswordReset(ctx, "alice", models.ClientInfo{IP: "192.0.2.2"})
+	var retry *domain.RetryAfterError
+	require.ErrorAs(t, err, &retry)
+	assert.ErrorIs(t, err, domain.TooManyResets)
+	assert.Positive(t, retry.RetryAfter)
+
+	// Тот же адрес с другим, в том числе несуществующим, именем.
+	err = s.RequestPasswordReset(ctx, "nobody", models.ClientInfo{IP: "192.0.2.1"})
+	assert.ErrorIs(t, err, domain.TooManyResets)
+
+	assert.Len(t, sent.messages, 1)
+}
+
+func TestResetPassword_PolicyIsCheckedBeforeTokenIsUsed(t *testing.T) {
+	s, sent := newPasswordService(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.RequestPasswordReset(ctx, "alice", models.ClientInfo{IP: "192.0.2.1"}))
+	require.Len(t, sent.messages, 1)
+	match := resetTokenPattern.FindStringSubmatch(sent.messages[0].Body)
+	require.NotNil(t, match)
+	token := match[1]
+
+	err := s.ResetPassword(ctx, token, "password", models.ClientInfo{})
+	var validation *domain.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Contains(t, validation.Reasons, password.ReasonCommon)
+
+	require.NoError(t, s.ResetPassword(ctx, token, "correct-horse-battery", models.ClientInfo{}))
+	user, err := s.repo.FindByUsername(ctx, "alice")
+	require.NoError(t, err)
+	_, err = s.hasher.Verify(user.Password, "correct-horse-battery")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, s.ResetPassword(ctx, token, "another-long-password", models.ClientInfo{}), domain.InvalidToken)
+}
+
+func TestResetPassword_UnknownToken(t *testing.T) {
+	s, _ := newPasswordService(t)
+
+	err := s.ResetPassword(context.Background(), "deadbeef", "correct-horse-battery", models.ClientInfo{})
+
+	assert.ErrorIs(t, err, domain.InvalidToken)
+}
+
+func TestRequestPasswordReset_DeliveryFailureIsNotReported(t *testing.T) {
+	s, _ := newPasswordService(t)
+	s.notifier = failingNotifier{}
+
+	// Ответ тот же, что и для пользователя без адреса.
+	assert.NoError(t, s.RequestPasswordReset(context.Background(), "alice", models.ClientInfo{IP: "192.0.2.1"}))
+}
+
+func TestResetPassword_RollsBackWhenSessionsAreNotRevoked(t *testing.T) {
+	s, sent := newPasswordService(t)
+	ctx := context.Background()
+	s.sessions = failingSessions{s.sessions}
+
+	require.NoError(t, s.RequestPasswordReset(ctx, "alice", models.ClientInfo{IP: "192.0.2.1"}))
+	require.Len(t, sent.messages, 1)
+	token := resetTokenPattern.FindStringSubmatch(sent.messages[0].Body)[1]
+
+	err := s.ResetPassword(ctx, token, "correct-horse-battery", models.ClientInfo{})
+	assert.EqualError(t, err, "connection reset")
+
+	// Ни пароль, ни токен не изменились.
+	user, err := s.repo.FindByUsername(ctx, "alice")
+	require.NoError(t, err)
+	_, err = s.hasher.Verify(user.Password, "old-password")
+	assert.NoError(t, err)
+	reset, err := s.resets.FindByHash(ctx, hashResetToken(token))
+	require.NoError(t, err)
+	assert.Nil(t, reset.UsedAt)
+}
@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	// Personal access токен имеет вид gfp.<payload>.<secret>, где payload -
+	// base64url JSON с id токена и scopes. Подделать payload нельзя: в базе
+	// хранится хеш всего токена. Данные пользователя в payload не попадают:
+	// они меняются, и сервисы получают их из VerifyToken.
+	personalTokenPrefix = "gfp."
+
+	maxPersonalTokenNameLength = 100
+)
+
+type personalTokenPayload struct {
+	ID    string `json:"tid"`
+	Scope string `json:"scope"`
+}
+
+// CreatePersonalToken выпускает personal access токен с именем name.
+// Нулевой expiresAt означает срок по умолчанию. Токен возвращается один
+// раз и хранится только в виде хеша.
+func (s *AuthServiceStruct) CreatePersonalToken(ctx context.Context, userID int, name string, scopes []string, expiresAt time.Time) (*models.PersonalAccessToken, string, error) {
+	now := time.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(s.personalTokenTTL)
+	}
+	name = strings.TrimSpace(name)
+	if reasons := s.checkPersonalToken(name, scopes, expiresAt, now); len(reasons) > 0 {
+		return nil, "", &domain.ValidationError{Reasons: reasons}
+	}
+
+	if _, err := s.repo.FindByID(ctx, userID); err != nil {
+		return nil, "", err
+	}
+
+	id, err := jwt.NewID()
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := newResetToken()
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := json.Marshal(personalTokenPayload{
+		ID:    id,
+		Scope: strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	raw := personalTokenPrefix + base64.RawURLEncoding.EncodeToString(payload) + "." + secret
+
+	token := &models.PersonalAccessToken{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashResetToken(raw),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.personalTokens.Create(ctx, token); err != nil {
+		s.logger.Error("failed to create personal access token",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, "", err
+	}
+
+	s.logger.Info("personal access token created",
+		zap.Int("user_id", userID),
+		zap.String("token_id", id),
+		zap.Strings("scopes", scopes),
+		zap.Time("expires_at", expiresAt))
+	return token, raw, nil
+}
+
+func (s *AuthServiceStruct) checkPersonalToken(name string, scopes []string, expiresAt, now time.Time) []string {
+	var reasons []string
+	if name == "" || len(name) > maxPersonalTokenNameLength {
+		reasons = append(reasons, "name_invalid")
+	}
+	if len(scopes) == 0 {
+		reasons = append(reasons, "scopes_required")
+	}
+	for _, scope := range scopes {
+		if !models.Scope(scope).Valid() {
+			reasons = append(reasons, "scope_unknown")
+			break
+		}
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(s.personalTokenMaxTTL)) {
+		reasons = append(reasons, "expires_at_invalid")
+	}
+	return reasons
+}
+
+func (s *AuthServiceStruct) ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalAccessToken, error) {
+	return s.personalTokens.ListByUser(ctx, userID)
+}
+
+func (s *AuthServiceStruct) RevokePersonalToken(ctx context.Context, userID int, tokenID string) error {
+	if err := s.personalTokens.Revoke(ctx, userID, tokenID); err != nil {
+		if !errors.Is(err, domain.PersonalTokenNotFound) {
+			s.logger.Error("failed to revoke personal access token",
+				zap.Int("user_id", userID),
+				zap.String("token_id", tokenID),
+				zap.Error(err))
+		}
+		return err
+	}
+	return nil
+}
+
+// introspectPersonalToken проверяет personal access токен по хешу. Роли
+// и email_verified берутся из текущих данных пользователя, а не из payload.
+func (s *AuthServiceStruct) introspectPersonalToken(ctx context.Context, raw string) (*models.Introspection, error) {
+	token, err := s.personalTokens.FindByHash(ctx, hashResetToken(raw))
+	if err != nil {
+		if errors.Is(err, domain.PersonalTokenNotFound) {
+			s.logger.Warn("unknown personal access token presented")
+			return &models.Introspection{Reason: models.TokenMalformed}, nil
+		}
+		return nil, err
+	}
+	if !token.Active(time.Now()) {
+		reason := models.TokenExpired
+		if token.RevokedAt != nil {
+			reason = models.TokenRevoked
+		}
+		s.logger.Warn("revoked or expired personal access token presented",
+			zap.String("token_id", token.ID),
+			zap.Int("user_id", token.UserID))
+		return &models.Introspection{Reason: reason}, nil
+	}
+
+	if inactive, err := s.introspectOwner(ctx, token.UserID); inactive != nil || err != nil {
+		return inactive, err
+	}
+	user, err := s.repo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.repo.Roles(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.personalTokens.TouchLastUsed(ctx, token.ID); err != nil {
+		s.logger.Warn("failed to update personal access token usage",
+			zap.String("token_id", token.ID),
+			zap.Error(err))
+	}
+
+	return &models.Introspection{Active: true, Claims: &models.TokenClaims{
+		ID:            token.ID,
+		UserID:        user.ID,
+		Username:      user.Username,
+		Roles:         roleNames(roles),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Scopes:        token.Scopes,
+		ExpiresAt:     token.ExpiresAt,
+		IssuedAt:      token.CreatedAt,
+		Credential:    models.CredentialPersonalToken,
+	}}, nil
+}
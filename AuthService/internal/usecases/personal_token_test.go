@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPersonalTokenService(t *testing.T) (*AuthServiceStruct, *models.User) {
+	s := newTestService(t)
+	alice := createUser(t, s, &models.User{Username: "alice", Email: "alice@example.com", EmailVerified: true})
+	return s, alice
+}
+
+// setPersonalTokenExpiry переносит срок действия уже выданного токена.
+func setPersonalTokenExpiry(t *testing.T, s *AuthServiceStruct, raw string, expiresAt time.Time) {
+	ctx := context.Background()
+	token, err := s.personalTokens.FindByHash(ctx, hashResetToken(raw))
+	require.NoError(t, err)
+	token.ExpiresAt = expiresAt
+	require.NoError(t, s.personalTokens.Create(ctx, token))
+}
+
+func TestPersonalToken_CreateAndVerify(t *testing.T) {
+	s, alice := newPersonalTokenService(t)
+	ctx := context.Background()
+
+	token, raw, err := s.CreatePersonalToken(ctx, alice.ID, " release bot ", []string{"topics:write"}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "release bot", token.Name)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), token.ExpiresAt, time.Minute)
+	assert.Equal(t, hashResetToken(raw), token.TokenHash)
+
+	// Payload читается без обращения к AuthService.
+	parts := strings.Split(strings.TrimPrefix(raw, personalTokenPrefix), ".")
+	require.Len(t, parts, 2)
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	var payload personalTokenPayload
+	require.NoError(t, json.Unmarshal(decoded, &payload))
+	assert.Equal(t, personalTokenPayload{ID: token.ID, Scope: "topics:write"}, payload)
+
+	claims, err := s.VerifyToken(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, models.CredentialPersonalToken, claims.Credential)
+	assert.True(t, claims.Scoped())
+	assert.Equal(t, "alice", claims.Username)
+	assert.Equal(t, []string{"topics:write"}, claims.Scopes)
+
+	listed, err := s.ListPersonalTokens(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, token.ID, listed[0].ID)
+	assert.NotNil(t, listed[0].LastUsedAt)
+}
+
+func TestPersonalToken_Rejected(t *testing.T) {
+	s, alice := newPersonalTokenService(t)
+	ctx := context.Background()
+
+	token, raw, err := s.CreatePersonalToken(ctx, alice.ID, "ci", []string{"comments:write"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = s.VerifyToken(ctx, raw+"x")
+	assert.ErrorIs(t, err, domain.InvalidToken)
+
+	setPersonalTokenExpiry(t, s, raw, time.Now().Add(-time.Second))
+	_, err = s.VerifyToken(ctx, raw)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+
+	setPersonalTokenExpiry(t, s, raw, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, s.RevokePersonalToken(ctx, alice.ID+1, token.ID), domain.PersonalTokenNotFound)
+	require.NoError(t, s.RevokePersonalToken(ctx, alice.ID, token.ID))
+	_, err = s.VerifyToken(ctx, raw)
+	assert.ErrorIs(t, err, domain.InvalidToken)
+
+	listed, err := s.ListPersonalTokens(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func TestPersonalToken_Validation(t *testing.T) {
+	s, alice := newPersonalTokenService(t)
+
+	_, _, err := s.CreatePersonalToken(context.Background(), alice.ID, "", []string{"admin"}, time.Now().Add(365*24*time.Hour))
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"name_invalid", "scope_unknown", "expires_at_invalid"}, invalid.Reasons)
+
+	_, _, err = s.CreatePersonalToken(context.Background(), alice.ID, "ci", nil, time.Now().Add(-time.Hour))
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"scopes_required", "expires_at_invalid"}, invalid.Reasons)
+}
+
+func TestVerifyToken_ReportsCredentialKind(t *testing.T) {
+	s, alice := newPersonalTokenService(t)
+
+	access, err := s.keys.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: alice.ID, Username: "alice"}, time.Minute)
+	require.NoError(t, err)
+	claims, err := s.VerifyToken(context.Background(), access)
+	require.NoError(t, err)
+	assert.Equal(t, models.CredentialAccessToken, claims.Credential)
+	assert.False(t, claims.Scoped())
+}
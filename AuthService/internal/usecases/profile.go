@@ -0,0 +1,162 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"go.uber.org/zap"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 500
+	maxAvatarURLLength   = 512
+
+	maxBatchSize       = 100
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// Триграммный индекс не помогает с шаблонами короче трех символов,
+	// а пустой запрос перебирал бы всю таблицу.
+	minSearchQueryLength = 3
+)
+
+const (
+	reasonDisplayNameTooLong = "display_name_too_long"
+	reasonBioTooLong         = "bio_too_long"
+	reasonAvatarURLInvalid   = "avatar_url_invalid"
+	reasonBatchTooLarge      = "batch_too_large"
+	reasonPageTokenInvalid   = "page_token_invalid"
+	reasonQueryTooShort      = "query_too_short"
+)
+
+func (s *AuthServiceStruct) GetUser(ctx context.Context, userID int, username string) (*models.Profile, error) {
+	var ids []int
+	var usernames []string
+	if userID != 0 {
+		ids = []int{userID}
+	} else {
+		usernames = []string{username}
+	}
+
+	profiles, err := s.repo.Profiles(ctx, ids, usernames)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, domain.UserNotFound
+	}
+	return profiles[0], nil
+}
+
+// BatchGetUsers возвращает найденные профили в порядке id; неизвестные
+// id и имена пропускаются.
+func (s *AuthServiceStruct) BatchGetUsers(ctx context.Context, ids []int, usernames []string) ([]*models.Profile, error) {
+	if len(ids)+len(usernames) > maxBatchSize {
+		return nil, &domain.ValidationError{Reasons: []string{reasonBatchTooLarge}}
+	}
+	if len(ids)+len(usernames) == 0 {
+		return nil, nil
+	}
+	return s.repo.Profiles(ctx, ids, usernames)
+}
+
+// SearchUsers возвращает страницу результатов и токен следующей
+// страницы; пустой токен означает, что результатов больше нет.
+func (s *AuthServiceStruct) SearchUsers(ctx context.Context, query string, pageSize int, pageToken string) ([]*models.Profile, string, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < minSearchQueryLength {
+		return nil, "", &domain.ValidationError{Reasons: []string{reasonQueryTooShort}}
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSearchLimit
+	}
+	if pageSize > maxSearchLimit {
+		pageSize = maxSearchLimit
+	}
+
+	afterID := 0
+	if pageToken != "" {
+		var err error
+		if afterID, err = strconv.Atoi(pageToken); err != nil || afterID < 0 {
+			return nil, "", &domain.ValidationError{Reasons: []string{reasonPageTokenInvalid}}
+		}
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница.
+	profiles, err := s.repo.SearchProfiles(ctx, query, afterID, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(profiles) > pageSize {
+		profiles = profiles[:pageSize]
+		next = strconv.Itoa(profiles[pageSize-1].UserID)
+	}
+	return profiles, next, nil
+}
+
+func (s *AuthServiceStruct) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (*models.Profile, error) {
+	if update.DisplayName != nil {
+		trimmed := strings.TrimSpace(*update.DisplayName)
+		update.DisplayName = &trimmed
+	}
+	if reasons := checkProfile(update); len(reasons) > 0 {
+		s.logger.Warn("profile update rejected",
+			zap.Int("user_id", userID),
+			zap.Strings("reasons", reasons))
+		return nil, &domain.ValidationError{Reasons: reasons}
+	}
+
+	var profile *models.Profile
+	event := &models.UserEvent{
+		Type:   models.ProfileUpdated,
+		UserID: userID,
+		Data:   map[string]string{"fields": strings.Join(updatedFields(update), ",")},
+	}
+	err := s.changeWithUserEvent(ctx, event, func(ctx context.Context) error {
+		var err error
+		profile, err = s.repo.UpdateProfile(ctx, userID, update)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func updatedFields(update models.ProfileUpdate) []string {
+	var fields []string
+	if update.DisplayName != nil {
+		fields = append(fields, "display_name")
+	}
+	if update.Bio != nil {
+		fields = append(fields, "bio")
+	}
+	if update.AvatarURL != nil {
+		fields = append(fields, "avatar_url")
+	}
+	return fields
+}
+
+func checkProfile(update models.ProfileUpdate) []string {
+	var reasons []string
+	if update.DisplayName != nil && utf8.RuneCountInString(*update.DisplayName) > maxDisplayNameLength {
+		reasons = append(reasons, reasonDisplayNameTooLong)
+	}
+	if update.Bio != nil && utf8.RuneCountInString(*update.Bio) > maxBioLength {
+		reasons = append(reasons, reasonBioTooLong)
+	}
+	// Пустая строка удаляет аватар, иначе нужен абсолютный http(s) адрес.
+	if update.AvatarURL != nil && *update.AvatarURL != "" {
+		u, err := url.Parse(*update.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*update.AvatarURL) > maxAvatarURLLength {
+			reasons = append(reasons, reasonAvatarURLInvalid)
+		}
+	}
+	return reasons
+}
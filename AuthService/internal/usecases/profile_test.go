@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchSpy запоминает запросы, дошедшие до репозитория.
+type searchSpy struct {
+	repositories.UserRepo
+	searches []string
+}
+
+func (u *searchSpy) SearchProfiles(ctx context.Context, query string, afterID, limit int) ([]*models.Profile, error) {
+	u.searches = append(u.searches, query)
+	return u.UserRepo.SearchProfiles(ctx, query, afterID, limit)
+}
+
+func newProfileService(t *testing.T, count int) (*AuthServiceStruct, *searchSpy) {
+	s := newTestService(t)
+	for id := 1; id <= count; id++ {
+		createUser(t, s, &models.User{Username: "user" + strconv.Itoa(id)})
+	}
+	users := &searchSpy{UserRepo: s.repo}
+	s.repo = users
+	return s, users
+}
+
+func TestGetUser(t *testing.T) {
+	s, _ := newProfileService(t, 2)
+	ctx := context.Background()
+
+	profile, err := s.GetUser(ctx, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, "user2", profile.Username)
+
+	profile, err = s.GetUser(ctx, 0, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, profile.UserID)
+
+	_, err = s.GetUser(ctx, 3, "")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
+
+func TestBatchGetUsers(t *testing.T) {
+	s, _ := newProfileService(t, 3)
+	ctx := context.Background()
+
+	profiles, err := s.BatchGetUsers(ctx, []int{3, 1, 42}, []string{"user2"})
+	require.NoError(t, err)
+	require.Len(t, profiles, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{profiles[0].UserID, profiles[1].UserID, profiles[2].UserID})
+
+	_, err = s.BatchGetUsers(ctx, make([]int, maxBatchSize+1), nil)
+	var validation *domain.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, []string{reasonBatchTooLarge}, validation.Reasons)
+}
+
+func TestSearchUsers_Pagination(t *testing.T) {
+	s, users := newProfileService(t, 5)
+	ctx := context.Background()
+
+	page, next, err := s.SearchUsers(ctx, " user ", 2, "")
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "2", next)
+	assert.Equal(t, "user", users.searches[0])
+
+	page, next, err = s.SearchUsers(ctx, "user", 2, next)
+	require.NoError(t, err)
+	assert.Equal(t, 3, page[0].UserID)
+	assert.Equal(t, "4", next)
+
+	page, next, err = s.SearchUsers(ctx, "user", 2, next)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Empty(t, next)
+}
+
+func TestSearchUsers_RejectsInvalidInput(t *testing.T) {
+	s, users := newProfileService(t, 1)
+
+	tests := []struct {
+		name      string
+		query     string
+		pageToken string
+		reason    string
+	}{
+		{name: "Empty query", query: "", reason: reasonQueryTooShort},
+		{name: "Whitespace query", query: "   ", reason: reasonQueryTooShort},
+		{name: "Short query", query: "us", reason: reasonQueryTooShort},
+		{name: "Short non-latin query", query: "ал", reason: reasonQueryTooShort},
+		{name: "Invalid page token", query: "user", pageToken: "abc", reason: reasonPageTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.SearchUsers(context.Background(), tt.query, 10, tt.pageToken)
+			var validation *domain.ValidationError
+			require.ErrorAs(t, err, &validation)
+			assert.Equal(t, []string{tt.reason}, validation.Reasons)
+		})
+	}
+	assert.Empty(t, users.searches)
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRoles не может выдать роль.
+type failingRoles struct {
+	repositories.UserRepo
+}
+
+func (failingRoles) GrantRole(ctx context.Context, userID int, role models.Role) error {
+	return errors.New("connection reset")
+}
+
+func TestRegister_CreatesUserWithDefaultRole(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Register(ctx, "alice", "correct-horse-battery", "", models.ClientInfo{}))
+
+	user, err := s.repo.FindByUsername(ctx, "alice")
+	require.NoError(t, err)
+	roles, err := s.repo.Roles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleUser}, roles)
+}
+
+func TestRegister_RollsBackWhenDefaultRoleIsNotGranted(t *testing.T) {
+	s := newTestService(t)
+	s.repo = failingRoles{s.repo}
+	ctx := context.Background()
+
+	err := s.Register(ctx, "alice", "correct-horse-battery", "", models.ClientInfo{})
+
+	assert.EqualError(t, err, "connection reset")
+	_, err = s.repo.FindByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, domain.UserNotFound)
+	assert.Empty(t, listAudit(t, s))
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+
+	require.NoError(t, s.BootstrapAdmin(ctx, "alice"))
+
+	roles, err := s.repo.Roles(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleAdmin, models.RoleUser}, roles)
+	events := listAudit(t, s)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditRoleGranted, events[0].Type)
+	assert.Equal(t, alice.ID, events[0].UserID)
+	assert.Zero(t, events[0].ActorID)
+}
+
+func TestBootstrapAdmin_UnknownUser(t *testing.T) {
+	s := newTestService(t)
+
+	assert.ErrorIs(t, s.BootstrapAdmin(context.Background(), "nobody"), domain.UserNotFound)
+}
+
+func TestGrantRole_RejectsUnknownRole(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+
+	err := s.GrantRole(ctx, testAdminID, alice.ID, models.Role("root"), models.ClientInfo{})
+
+	assert.ErrorIs(t, err, domain.InvalidRole)
+	roles, err := s.repo.Roles(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleUser}, roles)
+}
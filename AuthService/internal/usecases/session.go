@@ -0,0 +1,80 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+)
+
+func (s *AuthServiceStruct) ListSessions(ctx context.Context, userID int) ([]*models.Session, error) {
+	sessions, err := s.sessions.ListActive(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list sessions",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает одну сессию пользователя. Чужая сессия
+// неотличима от несуществующей.
+func (s *AuthServiceStruct) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	if err := s.sessions.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	s.logger.Info("session revoked by user",
+		zap.Int("user_id", userID),
+		zap.String("session_id", sessionID))
+	return nil
+}
+
+// RevokeAllSessions завершает все сессии пользователя, кроме
+// exceptSessionID, если он задан, и возвращает число отозванных.
+func (s *AuthServiceStruct) RevokeAllSessions(ctx context.Context, userID int, exceptSessionID string) (int, error) {
+	revoked, err := s.sessions.RevokeAll(ctx, userID, exceptSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("all sessions revoked by user",
+		zap.Int("user_id", userID),
+		zap.String("kept_session_id", exceptSessionID),
+		zap.Int("revoked", revoked))
+	return revoked, nil
+}
+
+// checkSession отклоняет access токены отозванных сессий. Токены,
+// выпущенные до появления сессий, не содержат sid и не проверяются.
+func (s *AuthServiceStruct) checkSession(ctx context.Context, claims *models.TokenClaims) error {
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	session, err := s.sessions.FindByID(ctx, claims.SessionID)
+	if errors.Is(err, domain.SessionNotFound) {
+		return domain.SessionRevoked
+	} else if err != nil {
+		return err
+	}
+	if !session.Active() || session.UserID != claims.UserID {
+		s.logger.Warn("access token of revoked session provided",
+			zap.Int("user_id", claims.UserID),
+			zap.String("session_id", claims.SessionID))
+		return domain.SessionRevoked
+	}
+	return nil
+}
+
+// touchSession отмечает использование сессии. Ошибка не прерывает
+// выдачу токенов.
+func (s *AuthServiceStruct) touchSession(ctx context.Context, sessionID string, client models.ClientInfo) {
+	if err := s.sessions.Touch(ctx, sessionID, client); err != nil {
+		s.logger.Warn("failed to update session usage",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+	}
+}
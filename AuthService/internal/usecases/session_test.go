@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTokens не может сохранить refresh токен.
+type failingTokens struct {
+	repositories.TokenRepo
+}
+
+func (failingTokens) Create(ctx context.Context, token *models.RefreshToken) error {
+	return errors.New("connection reset")
+}
+
+func TestGenerateTokens_CreatesSessionWithToken(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+
+	pair, err := s.GenerateTokens(ctx, alice, testClient)
+	require.NoError(t, err)
+	claims, err := s.VerifyToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	sessions, err := s.ListSessions(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, claims.SessionID, sessions[0].ID)
+	assert.Equal(t, testClient.IP, sessions[0].IP)
+}
+
+func TestGenerateTokens_RollsBackSessionWhenTokenIsNotCreated(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+	s.tokens = failingTokens{s.tokens}
+
+	_, err := s.GenerateTokens(ctx, alice, testClient)
+	assert.EqualError(t, err, "connection reset")
+
+	sessions, err := s.ListSessions(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	reasonSuspensionReasonRequired = "reason_required"
+	reasonSuspensionUntilInPast    = "until_in_past"
+	reasonSuspensionSelf           = "user_is_caller"
+)
+
+// SuspendUser блокирует пользователя до until, а при until == nil
+// бессрочно. Повторный вызов заменяет действующую блокировку.
+func (s *AuthServiceStruct) SuspendUser(ctx context.Context, adminID, userID int, reason string, until *time.Time, client models.ClientInfo) (*models.Suspension, error) {
+	reason = strings.TrimSpace(reason)
+
+	var reasons []string
+	if reason == "" {
+		reasons = append(reasons, reasonSuspensionReasonRequired)
+	}
+	if until != nil && !until.After(time.Now()) {
+		reasons = append(reasons, reasonSuspensionUntilInPast)
+	}
+	if userID == adminID {
+		reasons = append(reasons, reasonSuspensionSelf)
+	}
+	if len(reasons) > 0 {
+		return nil, &domain.ValidationError{Reasons: reasons}
+	}
+
+	if _, err := s.repo.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	suspension := &models.Suspension{
+		UserID:      userID,
+		Reason:      reason,
+		Until:       until,
+		SuspendedBy: adminID,
+	}
+	details := map[string]string{"reason": reason}
+	if until != nil {
+		details["until"] = until.UTC().Format(time.RFC3339)
+	}
+	err := s.changeWithUserEvent(ctx, &models.UserEvent{Type: models.UserSuspended, UserID: userID, Data: details},
+		func(ctx context.Context) error { return s.suspensions.Save(ctx, suspension) })
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditUserSuspended,
+		UserID:  userID,
+		ActorID: adminID,
+		Details: details,
+	}, client)
+
+	s.logger.Warn("user suspended",
+		zap.Int("user_id", userID),
+		zap.Int("admin_id", adminID),
+		zap.String("reason", reason),
+		zap.Bool("permanent", suspension.Permanent()))
+	return suspension, nil
+}
+
+func (s *AuthServiceStruct) UnsuspendUser(ctx context.Context, adminID, userID int, client models.ClientInfo) error {
+	err := s.changeWithUserEvent(ctx, &models.UserEvent{Type: models.UserUnsuspended, UserID: userID},
+		func(ctx context.Context) error { return s.suspensions.Delete(ctx, userID) })
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditUserUnsuspended,
+		UserID:  userID,
+		ActorID: adminID,
+	}, client)
+
+	s.logger.Info("user suspension lifted", zap.Int("user_id", userID))
+	return nil
+}
+
+// GetSuspension возвращает действующую блокировку или
+// domain.SuspensionNotFound, если ее нет или срок истек.
+func (s *AuthServiceStruct) GetSuspension(ctx context.Context, userID int) (*models.Suspension, error) {
+	suspension, err := s.suspensions.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !suspension.Active(time.Now()) {
+		return nil, domain.SuspensionNotFound
+	}
+	return suspension, nil
+}
+
+// checkSuspension возвращает *domain.SuspendedError, если пользователь
+// заблокирован.
+func (s *AuthServiceStruct) checkSuspension(ctx context.Context, userID int) error {
+	suspension, err := s.GetSuspension(ctx, userID)
+	if errors.Is(err, domain.SuspensionNotFound) {
+		return nil
+	} else if err != nil {
+		s.logger.Error("failed to check suspension",
+			zap.Int("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+
+	s.logger.Warn("suspended user rejected", zap.Int("user_id", userID))
+	return &domain.SuspendedError{Reason: suspension.Reason, Until: suspension.Until}
+}
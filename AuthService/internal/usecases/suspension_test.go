@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminID = 100
+
+func TestSuspendUser_BlocksLoginRefreshAndTokens(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, tokens := registerAndLogin(t, s, "alice")
+
+	until := time.Now().Add(time.Hour)
+	_, err := s.SuspendUser(ctx, testAdminID, alice.ID, " spam ", &until, testClient)
+	require.NoError(t, err)
+
+	suspension, err := s.GetSuspension(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "spam", suspension.Reason)
+	assert.Equal(t, testAdminID, suspension.SuspendedBy)
+
+	requireSuspended := func(err error) {
+		t.Helper()
+		var suspended *domain.SuspendedError
+		require.ErrorAs(t, err, &suspended)
+		assert.Equal(t, "spam", suspended.Reason)
+		require.NotNil(t, suspended.Until)
+		assert.WithinDuration(t, until, *suspended.Until, time.Second)
+	}
+
+	_, err = s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	requireSuspended(err)
+	_, err = s.Refresh(ctx, tokens.RefreshToken, testClient)
+	requireSuspended(err)
+	_, err = s.VerifyToken(ctx, tokens.AccessToken)
+	requireSuspended(err)
+
+	require.NoError(t, s.UnsuspendUser(ctx, testAdminID, alice.ID, testClient))
+
+	_, err = s.GetSuspension(ctx, alice.ID)
+	assert.ErrorIs(t, err, domain.SuspensionNotFound)
+	// Отклоненный refresh токен не ротирован и остается действующим.
+	_, err = s.Refresh(ctx, tokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+	_, err = s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	assert.NoError(t, err)
+}
+
+func TestSuspendUser_BlocksSecondFactor(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, _ := registerAndLogin(t, s, "alice")
+	recoveryCodes := enableTOTP(t, s, alice.ID)
+
+	result, err := s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ChallengeToken)
+
+	// Блокировка, наложенная после выдачи challenge токена.
+	_, err = s.SuspendUser(ctx, testAdminID, alice.ID, "spam", nil, testClient)
+	require.NoError(t, err)
+
+	_, err = s.VerifySecondFactor(ctx, result.ChallengeToken, recoveryCodes[0], testClient)
+	var suspended *domain.SuspendedError
+	require.ErrorAs(t, err, &suspended)
+	assert.Nil(t, suspended.Until)
+}
+
+func TestSuspendUser_Expired(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, _ := registerAndLogin(t, s, "alice")
+
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, s.suspensions.Save(ctx, &models.Suspension{UserID: alice.ID, Reason: "spam", Until: &past, SuspendedBy: testAdminID}))
+
+	_, err := s.GetSuspension(ctx, alice.ID)
+	assert.ErrorIs(t, err, domain.SuspensionNotFound)
+	_, err = s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	assert.NoError(t, err)
+}
+
+func TestSuspendUser_Validation(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+
+	past := time.Now().Add(-time.Minute)
+	_, err := s.SuspendUser(ctx, alice.ID, alice.ID, " ", &past, testClient)
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{reasonSuspensionReasonRequired, reasonSuspensionUntilInPast, reasonSuspensionSelf}, invalid.Reasons)
+
+	_, err = s.SuspendUser(ctx, testAdminID, 42, "spam", nil, testClient)
+	assert.ErrorIs(t, err, domain.UserNotFound)
+}
@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+// loginThrottle считает неудачные попытки входа по имени пользователя
+// и по адресу клиента. Каждая неудача до порога блокирует вход на
+// экспоненциально растущую задержку, по достижении порога ключ
+// блокируется на lockout.
+type loginThrottle struct {
+	repo        repositories.AttemptRepo
+	maxAttempts int
+	backoffBase time.Duration
+	lockout     time.Duration
+	logger      *zap.Logger
+}
+
+func loginKeys(username, ip string) []string {
+	keys := []string{"user:" + username}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func (t *loginThrottle) check(ctx context.Context, keys []string) error {
+	now := time.Now()
+	for _, key := range keys {
+		lockedUntil, err := t.repo.LockedUntil(ctx, key)
+		if err != nil {
+			return err
+		}
+		if lockedUntil.After(now) {
+			t.logger.Warn("login attempt while locked",
+				zap.String("key", key),
+				zap.Time("locked_until", lockedUntil))
+			return &domain.RetryAfterError{
+				Err:        domain.TooManyAttempts,
+				RetryAfter: lockedUntil.Sub(now),
+			}
+		}
+	}
+	return nil
+}
+
+func (t *loginThrottle) fail(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		failures, err := t.repo.RegisterFailure(ctx, key, t.lockout)
+		if err != nil {
+			t.logger.Error("failed to register login failure",
+				zap.String("key", key),
+				zap.Error(err))
+			continue
+		}
+
+		delay := t.delay(failures)
+		if err := t.repo.Lock(ctx, key, time.Now().Add(delay)); err != nil {
+			t.logger.Error("failed to lock login",
+				zap.String("key", key),
+				zap.Error(err))
+			continue
+		}
+
+		if failures >= t.maxAttempts {
+			t.logger.Warn("login locked after repeated failures",
+				zap.String("key", key),
+				zap.Int("failures", failures),
+				zap.Duration("lockout", delay))
+		}
+	}
+}
+
+func (t *loginThrottle) reset(ctx context.Context, key string) {
+	if err := t.repo.Reset(ctx, key); err != nil {
+		t.logger.Error("failed to reset login attempts",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+}
+
+func (t *loginThrottle) delay(failures int) time.Duration {
+	if failures >= t.maxAttempts {
+		return t.lockout
+	}
+
+	delay := t.backoffBase << (failures - 1)
+	if delay <= 0 || delay > t.lockout {
+		return t.lockout
+	}
+	return delay
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/memory"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoginThrottle_Delay(t *testing.T) {
+	throttle := &loginThrottle{maxAttempts: 5, backoffBase: time.Second, lockout: 15 * time.Minute}
+
+	assert.Equal(t, time.Second, throttle.delay(1))
+	assert.Equal(t, 2*time.Second, throttle.delay(2))
+	assert.Equal(t, 8*time.Second, throttle.delay(4))
+	assert.Equal(t, 15*time.Minute, throttle.delay(5))
+	assert.Equal(t, 15*time.Minute, throttle.delay(100))
+}
+
+func TestLoginThrottle_LocksAfterFailure(t *testing.T) {
+	throttle := &loginThrottle{
+		repo:        memory.NewAttemptRepository(memory.NewStore()),
+		maxAttempts: 3,
+		backoffBase: time.Minute,
+		lockout:     time.Hour,
+		logger:      zap.NewNop(),
+	}
+	ctx := context.Background()
+	keys := loginKeys("alice", "10.0.0.1")
+
+	require.NoError(t, throttle.check(ctx, keys))
+	throttle.fail(ctx, keys)
+
+	err := throttle.check(ctx, keys)
+	var retry *domain.RetryAfterError
+	require.True(t, errors.As(err, &retry))
+	assert.ErrorIs(t, err, domain.TooManyAttempts)
+	assert.InDelta(t, time.Minute.Seconds(), retry.RetryAfter.Seconds(), 1)
+
+	throttle.reset(ctx, keys[0])
+	assert.Error(t, throttle.check(ctx, keys), "address lock must survive a username reset")
+	assert.NoError(t, throttle.check(ctx, loginKeys("alice", "10.0.0.2")))
+}
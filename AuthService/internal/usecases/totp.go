@@ -0,0 +1,203 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/totp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	totpSkew          = 1
+)
+
+func (s *AuthServiceStruct) EnrollTOTP(ctx context.Context, userID int) (*models.TOTPEnrollment, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.factors.FindTOTP(ctx, userID)
+	if err == nil && existing.Enabled() {
+		s.logger.Warn("totp enrollment requested while already enabled", zap.Int("user_id", userID))
+		return nil, domain.SecondFactorAlreadyEnabled
+	} else if err != nil && !errors.Is(err, domain.SecondFactorNotFound) {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.factors.SaveTOTP(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("totp enrollment started", zap.Int("user_id", userID))
+	return &models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.totpIssuer, user.Username, secret),
+	}, nil
+}
+
+func (s *AuthServiceStruct) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	pending, err := s.factors.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending.Enabled() {
+		return nil, domain.SecondFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(pending.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		s.logger.Warn("invalid totp code on confirmation", zap.Int("user_id", userID))
+		return nil, domain.InvalidSecondFactor
+	}
+
+	recoveryCodes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.factors.ConfirmTOTP(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	if _, err := s.factors.MarkTOTPUsed(ctx, userID, step); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("totp enabled", zap.Int("user_id", userID))
+	return recoveryCodes, nil
+}
+
+func (s *AuthServiceStruct) VerifySecondFactor(ctx context.Context, challengeToken, code string, client models.ClientInfo) (*models.TokenPair, error) {
+	claims, err := s.refreshKeys.Validate(challengeToken, jwt.TypeChallenge)
+	if err != nil || claims.ID == "" {
+		s.logger.Warn("invalid challenge token provided", zap.Error(err))
+		return nil, domain.InvalidToken
+	}
+
+	keys := []string{"mfa:" + strconv.Itoa(claims.UserID)}
+	if err := s.throttle.check(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, claims.UserID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.logger.Warn("invalid second factor code",
+			zap.Int("user_id", claims.UserID))
+		s.throttle.fail(ctx, keys)
+		s.auditLoginFailure(ctx, claims.UserID, claims.Username, "invalid_second_factor", client)
+		return nil, domain.InvalidSecondFactor
+	}
+	s.throttle.reset(ctx, keys[0])
+
+	// Challenge токен обменивается на сессию один раз, даже если
+	// перехвачен вместе с еще не использованным кодом восстановления.
+	fresh, err := s.factors.ConsumeChallenge(ctx, claims.ID, claims.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		s.logger.Warn("challenge token replayed",
+			zap.Int("user_id", claims.UserID))
+		return nil, domain.InvalidToken
+	}
+
+	user, err := s.repo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.GenerateTokens(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, &models.AuditEvent{
+		Type:    models.AuditLoginSucceeded,
+		UserID:  user.ID,
+		Details: map[string]string{"method": "second_factor"},
+	}, client)
+
+	s.logger.Info("second factor verified",
+		zap.Int("user_id", claims.UserID),
+		zap.String("username", claims.Username))
+	return tokens, nil
+}
+
+// checkSecondFactor принимает либо текущий TOTP код, либо один из
+// неиспользованных кодов восстановления.
+func (s *AuthServiceStruct) checkSecondFactor(ctx context.Context, userID int, code string) (bool, error) {
+	secondFactor, err := s.factors.FindTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !secondFactor.Enabled() {
+		return false, domain.SecondFactorNotFound
+	}
+
+	if step, ok := totp.Validate(secondFactor.Secret, strings.TrimSpace(code), time.Now(), totpSkew); ok {
+		// Каждый код принимается только один раз.
+		return s.factors.MarkTOTPUsed(ctx, userID, step)
+	}
+
+	return s.factors.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+}
+
+// secondFactorChallenge возвращает challenge токен, если у пользователя
+// включен второй фактор, и пустую строку в противном случае.
+func (s *AuthServiceStruct) secondFactorChallenge(ctx context.Context, user *models.User) (string, error) {
+	secondFactor, err := s.factors.FindTOTP(ctx, user.ID)
+	if errors.Is(err, domain.SecondFactorNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if !secondFactor.Enabled() {
+		return "", nil
+	}
+
+	return s.refreshKeys.Sign(
+		models.TokenClaims{Type: jwt.TypeChallenge, UserID: user.ID, Username: user.Username},
+		s.challengeTTL,
+	)
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+		recoveryCodes = append(recoveryCodes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return recoveryCodes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/pkg/totp"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTOTP включает второй фактор и возвращает коды восстановления.
+func enableTOTP(t *testing.T, s *AuthServiceStruct, userID int) []string {
+	ctx := context.Background()
+	enrollment, err := s.EnrollTOTP(ctx, userID)
+	require.NoError(t, err)
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := s.ConfirmTOTP(ctx, userID, code)
+	require.NoError(t, err)
+	return recoveryCodes
+}
+
+// loginChallenge входит паролем и возвращает challenge токен второго фактора.
+func loginChallenge(t *testing.T, s *AuthServiceStruct, username string) string {
+	result, err := s.Login(context.Background(), username, "correct-horse-battery", testClient)
+	require.NoError(t, err)
+	require.Nil(t, result.Tokens)
+	require.NotEmpty(t, result.ChallengeToken)
+	return result.ChallengeToken
+}
+
+func TestEnrollTOTP_ConfirmationRequired(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, _ := registerAndLogin(t, s, "alice")
+
+	enrollment, err := s.EnrollTOTP(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+
+	// Пока фактор не подтвержден, вход по паролю не требует кода, а
+	// повторная регистрация выдает новый секрет.
+	_, err = s.Login(ctx, "alice", "correct-horse-battery", testClient)
+	require.NoError(t, err)
+	again, err := s.EnrollTOTP(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, again.Secret)
+
+	stale, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = s.ConfirmTOTP(ctx, alice.ID, stale)
+	assert.ErrorIs(t, err, domain.InvalidSecondFactor)
+
+	code, err := totp.Code(again.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := s.ConfirmTOTP(ctx, alice.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, recoveryCodeCount)
+
+	_, err = s.ConfirmTOTP(ctx, alice.ID, code)
+	assert.ErrorIs(t, err, domain.SecondFactorAlreadyEnabled)
+	_, err = s.EnrollTOTP(ctx, alice.ID)
+	assert.ErrorIs(t, err, domain.SecondFactorAlreadyEnabled)
+}
+
+func TestVerifySecondFactor_TOTPCodeIsSingleUse(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, _ := registerAndLogin(t, s, "alice")
+
+	enrollment, err := s.EnrollTOTP(ctx, alice.ID)
+	require.NoError(t, err)
+	step := totp.Step(time.Now())
+	code, err := totp.Code(enrollment.Secret, step)
+	require.NoError(t, err)
+	_, err = s.ConfirmTOTP(ctx, alice.ID, code)
+	require.NoError(t, err)
+
+	// Код, принятый при подтверждении, второй раз не подходит.
+	_, err = s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), code, testClient)
+	assert.ErrorIs(t, err, domain.InvalidSecondFactor)
+	s.throttle.reset(ctx, "mfa:"+strconv.Itoa(alice.ID))
+
+	next, err := totp.Code(enrollment.Secret, step+1)
+	require.NoError(t, err)
+	tokens, err := s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), next, testClient)
+	require.NoError(t, err)
+	claims, err := s.VerifyToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, claims.UserID)
+}
+
+func TestVerifySecondFactor_RecoveryCodes(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice, _ := registerAndLogin(t, s, "alice")
+	recoveryCodes := enableTOTP(t, s, alice.ID)
+
+	// Регистр и разделители в коде восстановления не важны.
+	code := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", " "))
+	_, err := s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), code, testClient)
+	require.NoError(t, err)
+
+	_, err = s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), recoveryCodes[0], testClient)
+	assert.ErrorIs(t, err, domain.InvalidSecondFactor)
+
+	// Неудачные попытки ограничиваются так же, как вход по паролю.
+	_, err = s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), recoveryCodes[1], testClient)
+	assert.ErrorIs(t, err, domain.TooManyAttempts)
+
+	s.throttle.reset(ctx, "mfa:"+strconv.Itoa(alice.ID))
+	_, err = s.VerifySecondFactor(ctx, loginChallenge(t, s, "alice"), recoveryCodes[1], testClient)
+	assert.NoError(t, err)
+}
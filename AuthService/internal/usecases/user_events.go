@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"context"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const userEventBatchSize = 100
+
+const reasonCursorInvalid = "cursor_invalid"
+
+// eventBroadcast будит подписчиков ленты, когда событие записано этим же
+// экземпляром сервиса. События других экземпляров подписчики находят
+// периодическим опросом таблицы.
+type eventBroadcast struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newEventBroadcast() *eventBroadcast {
+	return &eventBroadcast{ch: make(chan struct{})}
+}
+
+// wait возвращает канал, который закроется при следующем notify.
+func (b *eventBroadcast) wait() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ch
+}
+
+func (b *eventBroadcast) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(b.ch)
+	b.ch = make(chan struct{})
+}
+
+// appendUserEvent записывает событие в ленту в транзакции ctx, вместе с
+// изменением, которое оно описывает: иначе лента могла бы разойтись с
+// данными. Ошибка записи отменяет всю транзакцию. Событие записывается
+// последним действием транзакции (см. UserEventRepository.Append), а
+// подписчиков вызывающий будит после ее фиксации.
+func (s *AuthServiceStruct) appendUserEvent(ctx context.Context, event *models.UserEvent) error {
+	if err := s.userEvents.Append(ctx, event); err != nil {
+		s.logger.Error("failed to append user event",
+			zap.String("event_type", string(event.Type)),
+			zap.Int("user_id", event.UserID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// changeWithUserEvent выполняет change и записывает event в одной
+// транзакции, а после ее фиксации будит подписчиков ленты.
+func (s *AuthServiceStruct) changeWithUserEvent(ctx context.Context, event *models.UserEvent, change func(ctx context.Context) error) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := change(ctx); err != nil {
+			return err
+		}
+		return s.appendUserEvent(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	s.userEventWake.notify()
+	return nil
+}
+
+// WatchUserEvents передает в send события ленты после cursor по мере их
+// появления, пока не отменен ctx или send не вернет ошибку. Пустой cursor
+// означает только новые события, "0" - всю ленту с начала. Курсор
+// события - его id в десятичной записи. Если types не пуст, остальные
+// события пропускаются.
+func (s *AuthServiceStruct) WatchUserEvents(ctx context.Context, cursor string, types []models.UserEventType, send func(*models.UserEvent) error) error {
+	var reasons []string
+	after, err := strconv.ParseInt(cursor, 10, 64)
+	if cursor != "" && (err != nil || after < 0) {
+		reasons = append(reasons, reasonCursorInvalid)
+	}
+	wanted := make(map[models.UserEventType]bool, len(types))
+	for _, eventType := range types {
+		if !eventType.Valid() {
+			reasons = append(reasons, reasonEventTypesUnknown)
+			break
+		}
+		wanted[eventType] = true
+	}
+	if len(reasons) > 0 {
+		return &domain.ValidationError{Reasons: reasons}
+	}
+
+	if cursor == "" {
+		if after, err = s.userEvents.LastID(ctx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		// Канал берется до запроса, чтобы не пропустить событие,
+		// записанное между запросом и ожиданием.
+		wake := s.userEventWake.wait()
+
+		events, err := s.userEvents.ListAfter(ctx, after, userEventBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			after = event.ID
+			if len(wanted) > 0 && !wanted[event.Type] {
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		if len(events) == userEventBatchSize {
+			continue
+		}
+
+		timer := time.NewTimer(s.userEventPoll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
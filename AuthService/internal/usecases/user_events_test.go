@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/domain/repositories"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingUserEvents не может записать событие.
+type failingUserEvents struct {
+	repositories.UserEventRepo
+}
+
+func (failingUserEvents) Append(ctx context.Context, event *models.UserEvent) error {
+	return errors.New("connection reset")
+}
+
+var errStopWatch = errors.New("stop watch")
+
+// publishUserEvent записывает событие так же, как его записывают действия
+// с пользователем, но без самого изменения.
+func publishUserEvent(t *testing.T, s *AuthServiceStruct, event *models.UserEvent) {
+	require.NoError(t, s.changeWithUserEvent(context.Background(), event, func(context.Context) error { return nil }))
+}
+
+// collectUserEvents читает события из WatchUserEvents, пока не получит n штук.
+func collectUserEvents(t *testing.T, s *AuthServiceStruct, cursor string, types []models.UserEventType, n int) []*models.UserEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []*models.UserEvent
+	err := s.WatchUserEvents(ctx, cursor, types, func(event *models.UserEvent) error {
+		got = append(got, event)
+		if len(got) == n {
+			return errStopWatch
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStopWatch)
+	return got
+}
+
+func TestWatchUserEvents_ResumesFromCursor(t *testing.T) {
+	s := newTestService(t)
+	publishUserEvent(t, s, &models.UserEvent{Type: models.UserCreated, UserID: 1})
+	publishUserEvent(t, s, &models.UserEvent{Type: models.ProfileUpdated, UserID: 1})
+	publishUserEvent(t, s, &models.UserEvent{Type: models.UserSuspended, UserID: 2})
+
+	got := collectUserEvents(t, s, "1", nil, 2)
+	assert.Equal(t, int64(2), got[0].ID)
+	assert.Equal(t, int64(3), got[1].ID)
+
+	got = collectUserEvents(t, s, "0", []models.UserEventType{models.UserSuspended}, 1)
+	assert.Equal(t, 2, got[0].UserID)
+}
+
+func TestWatchUserEvents_WakesOnPublish(t *testing.T) {
+	s := newTestService(t)
+	publishUserEvent(t, s, &models.UserEvent{Type: models.UserCreated, UserID: 1})
+
+	done := make(chan []*models.UserEvent)
+	go func() {
+		// Пустой cursor: уже записанные события не передаются.
+		done <- collectUserEvents(t, s, "", nil, 1)
+	}()
+
+	// Опрос в тесте раз в минуту, поэтому событие приходит только благодаря
+	// пробуждению подписчика при записи.
+	deadline := time.After(5 * time.Second)
+	for {
+		publishUserEvent(t, s, &models.UserEvent{Type: models.RolesChanged, UserID: 1})
+		select {
+		case got := <-done:
+			assert.Equal(t, models.RolesChanged, got[0].Type)
+			return
+		case <-deadline:
+			t.Fatal("watcher did not receive the published event")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestGrantRole_RollsBackWhenEventIsNotAppended(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	alice := createUser(t, s, &models.User{Username: "alice"})
+
+	require.NoError(t, s.GrantRole(ctx, testAdminID, alice.ID, models.RoleModerator, models.ClientInfo{}))
+	last, err := s.userEvents.LastID(ctx)
+	require.NoError(t, err)
+	events, err := s.userEvents.ListAfter(ctx, last-1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.RolesChanged, events[0].Type)
+
+	s.userEvents = failingUserEvents{s.userEvents}
+	err = s.GrantRole(ctx, testAdminID, alice.ID, models.RoleAdmin, models.ClientInfo{})
+
+	assert.EqualError(t, err, "connection reset")
+	roles, err := s.repo.Roles(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Role{models.RoleModerator, models.RoleUser}, roles)
+	assert.Len(t, listAudit(t, s), 1)
+}
+
+func TestWatchUserEvents_Validation(t *testing.T) {
+	s := newTestService(t)
+
+	err := s.WatchUserEvents(context.Background(), "abc", []models.UserEventType{"user_renamed"}, func(*models.UserEvent) error {
+		return nil
+	})
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"cursor_invalid", "event_types_unknown"}, invalid.Reasons)
+}
+
+func TestWatchUserEvents_StopsOnCancel(t *testing.T) {
+	s := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.WatchUserEvents(ctx, "0", nil, func(*models.UserEvent) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
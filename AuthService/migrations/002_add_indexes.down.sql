@@ -1,2 +1 @@
 DROP INDEX IF EXISTS idx_users_username;
-DROP INDEX IF EXISTS idx_users_username;
\ No newline at end of file
@@ -1,2 +1 @@
 CREATE INDEX idx_users_username ON users(username);
-CREATE INDEX idx_users_username ON users(id);
\ No newline at end of file
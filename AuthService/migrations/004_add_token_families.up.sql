@@ -0,0 +1,6 @@
+ALTER TABLE refresh_tokens ADD COLUMN family_id VARCHAR(64);
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by VARCHAR(64);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
@@ -0,0 +1,8 @@
+CREATE TABLE user_roles (
+                            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            role VARCHAR(20) NOT NULL CHECK (role IN ('user', 'moderator', 'admin')),
+                            granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                            PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO user_roles (user_id, role) SELECT id, 'user' FROM users;
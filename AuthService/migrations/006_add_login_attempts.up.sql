@@ -0,0 +1,6 @@
+CREATE TABLE login_attempts (
+                                key VARCHAR(128) PRIMARY KEY,
+                                failures INTEGER NOT NULL DEFAULT 0,
+                                locked_until TIMESTAMPTZ,
+                                updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+CREATE TABLE user_totp (
+                           user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                           secret VARCHAR(64) NOT NULL,
+                           confirmed_at TIMESTAMPTZ,
+                           last_used_step BIGINT NOT NULL DEFAULT 0,
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE recovery_codes (
+                                id SERIAL PRIMARY KEY,
+                                user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                code_hash VARCHAR(64) NOT NULL,
+                                used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,9 @@
+CREATE TABLE password_resets (
+                                 token_hash VARCHAR(64) PRIMARY KEY,
+                                 user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 expires_at TIMESTAMPTZ NOT NULL,
+                                 used_at TIMESTAMPTZ,
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_resets_user_id ON password_resets(user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_display_name_lower;
+ALTER TABLE users DROP COLUMN IF EXISTS last_seen;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
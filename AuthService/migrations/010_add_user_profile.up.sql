@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN display_name VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN last_seen TIMESTAMPTZ;
+
+CREATE INDEX idx_users_display_name_lower ON users(LOWER(display_name));
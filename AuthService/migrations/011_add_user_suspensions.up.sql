@@ -0,0 +1,7 @@
+CREATE TABLE user_suspensions (
+                                  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                  reason TEXT NOT NULL,
+                                  suspended_until TIMESTAMPTZ,
+                                  suspended_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,21 @@
+CREATE TABLE sessions (
+                          id VARCHAR(64) PRIMARY KEY,
+                          user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          user_agent TEXT NOT NULL DEFAULT '',
+                          ip VARCHAR(64) NOT NULL DEFAULT '',
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+-- Существующие цепочки refresh токенов становятся сессиями без данных об устройстве.
+INSERT INTO sessions (id, user_id, created_at, last_used_at, revoked_at)
+SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at),
+       CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
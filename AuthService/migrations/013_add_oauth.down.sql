@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_oauth_codes_expires_at;
+DROP TABLE IF EXISTS oauth_codes;
+ALTER TABLE sessions DROP COLUMN IF EXISTS client_id;
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,37 @@
+CREATE TABLE oauth_clients (
+                               id VARCHAR(64) PRIMARY KEY,
+                               secret_hash VARCHAR(255),
+                               name VARCHAR(100) NOT NULL,
+                               redirect_uris TEXT[] NOT NULL,
+                               scopes TEXT[] NOT NULL,
+                               owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE oauth_consents (
+                                user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+                                scopes TEXT[] NOT NULL,
+                                granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                PRIMARY KEY (user_id, client_id)
+);
+
+-- Сессия, выданная клиенту OAuth, отзывается вместе с клиентом.
+ALTER TABLE sessions ADD COLUMN client_id VARCHAR(64) REFERENCES oauth_clients(id) ON DELETE CASCADE;
+
+-- Хранится только SHA-256 кода. session_id заполняется при обмене кода
+-- на токены и нужен, чтобы отозвать их при повторном предъявлении кода.
+CREATE TABLE oauth_codes (
+                             code_hash VARCHAR(64) PRIMARY KEY,
+                             client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+                             user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             redirect_uri TEXT NOT NULL,
+                             scopes TEXT[] NOT NULL,
+                             code_challenge VARCHAR(128) NOT NULL,
+                             expires_at TIMESTAMPTZ NOT NULL,
+                             used_at TIMESTAMPTZ,
+                             session_id VARCHAR(64),
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_codes_expires_at ON oauth_codes(expires_at);
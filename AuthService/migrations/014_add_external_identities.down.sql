@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_external_login_states_expires_at;
+DROP TABLE IF EXISTS external_login_states;
+DROP INDEX IF EXISTS idx_external_identities_user_id;
+DROP TABLE IF EXISTS external_identities;
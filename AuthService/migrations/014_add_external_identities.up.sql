@@ -0,0 +1,25 @@
+-- Привязка пользователя к subject внешнего OpenID Connect провайдера.
+CREATE TABLE external_identities (
+                                     provider VARCHAR(64) NOT NULL,
+                                     subject VARCHAR(255) NOT NULL,
+                                     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     email VARCHAR(255),
+                                     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                     last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                     PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX idx_external_identities_user_id ON external_identities(user_id);
+
+-- Незавершенные входы через провайдера. Хранится только SHA-256 state,
+-- nonce и code_verifier нужны, чтобы проверить ответ провайдера.
+CREATE TABLE external_login_states (
+                                       state_hash VARCHAR(64) PRIMARY KEY,
+                                       provider VARCHAR(64) NOT NULL,
+                                       nonce VARCHAR(128) NOT NULL,
+                                       code_verifier VARCHAR(128) NOT NULL,
+                                       expires_at TIMESTAMPTZ NOT NULL,
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_external_login_states_expires_at ON external_login_states(expires_at);
@@ -0,0 +1,15 @@
+-- Хранится только SHA-256 токена, сам токен показывается один раз при
+-- создании.
+CREATE TABLE personal_access_tokens (
+                                        id VARCHAR(32) PRIMARY KEY,
+                                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                        name VARCHAR(100) NOT NULL,
+                                        token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                        scopes TEXT[] NOT NULL,
+                                        expires_at TIMESTAMPTZ NOT NULL,
+                                        last_used_at TIMESTAMPTZ,
+                                        revoked_at TIMESTAMPTZ,
+                                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
@@ -0,0 +1,27 @@
+-- Журнал аудита не ссылается на users: записи должны пережить удаление
+-- пользователя и удаляются только по сроку хранения.
+CREATE TABLE audit_events (
+                              id BIGSERIAL PRIMARY KEY,
+                              event_type VARCHAR(32) NOT NULL,
+                              user_id INTEGER,
+                              actor_id INTEGER,
+                              ip VARCHAR(64) NOT NULL DEFAULT '',
+                              user_agent TEXT NOT NULL DEFAULT '',
+                              request_id VARCHAR(128) NOT NULL DEFAULT '',
+                              details JSONB NOT NULL DEFAULT '{}',
+                              created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events(user_id, id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+
+-- Записи журнала не изменяются.
+CREATE FUNCTION audit_events_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_immutable();
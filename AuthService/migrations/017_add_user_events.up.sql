@@ -0,0 +1,9 @@
+-- Лента событий пользователей для других сервисов. Как и журнал аудита,
+-- не ссылается на users, чтобы события пережили удаление пользователя.
+CREATE TABLE user_events (
+                             id BIGSERIAL PRIMARY KEY,
+                             event_type VARCHAR(32) NOT NULL,
+                             user_id INTEGER NOT NULL,
+                             data JSONB NOT NULL DEFAULT '{}',
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
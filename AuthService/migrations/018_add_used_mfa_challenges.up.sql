@@ -0,0 +1,8 @@
+-- Использованные challenge токены второго фактора. Токен принимается
+-- только один раз; запись нужна, пока токен не истек.
+CREATE TABLE used_mfa_challenges (
+                                     id VARCHAR(64) PRIMARY KEY,
+                                     expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_used_mfa_challenges_expires_at ON used_mfa_challenges(expires_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_display_name_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+
+CREATE INDEX idx_users_display_name_lower ON users(LOWER(display_name));
@@ -0,0 +1,9 @@
+-- SearchUsers ищет подстроку через ILIKE '%...%', для которой B-tree
+-- индекс по LOWER(display_name) бесполезен. Триграммные GIN индексы
+-- используются ILIKE с шаблоном от трех символов.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+DROP INDEX IF EXISTS idx_users_display_name_lower;
+
+CREATE INDEX idx_users_username_trgm ON users USING GIN (username gin_trgm_ops);
+CREATE INDEX idx_users_display_name_trgm ON users USING GIN (display_name gin_trgm_ops);
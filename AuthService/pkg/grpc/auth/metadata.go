@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/grpc/interceptors"
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"strings"
+)
+
+// Метаданные, которые проксирующие сервисы (TopicService) выставляют,
+// чтобы передать адрес и user agent конечного клиента.
+const (
+	forwardedForKey       = "x-forwarded-for"
+	forwardedUserAgentKey = "x-forwarded-user-agent"
+)
+
+type clientInfoKey struct{}
+
+// WithClientInfo сохраняет в ctx адрес и user agent клиента, уже
+// определенные вызывающим кодом того же процесса (REST шлюзом). Такие
+// вызовы не проходят через сеть, и метаданные x-forwarded-* для них не
+// читаются.
+func WithClientInfo(ctx context.Context, info models.ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext возвращает данные, сохраненные WithClientInfo.
+func ClientInfoFromContext(ctx context.Context) (models.ClientInfo, bool) {
+	info, ok := ctx.Value(clientInfoKey{}).(models.ClientInfo)
+	return info, ok
+}
+
+// clientInfo определяет адрес и user agent клиента. Метаданные
+// x-forwarded-* принимаются только от доверенных прокси, остальным
+// вызовам соответствует адрес собеседника соединения.
+func (s *Server) clientInfo(ctx context.Context) models.ClientInfo {
+	if info, ok := ClientInfoFromContext(ctx); ok {
+		info.RequestID = interceptors.RequestID(ctx)
+		return info
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	trusted := s.TrustedProxies.Contains(remoteAddr)
+
+	var info models.ClientInfo
+	var forwardedFor string
+	if values := md.Get(forwardedForKey); len(values) > 0 {
+		forwardedFor = strings.Join(values, ",")
+	}
+	info.IP = s.TrustedProxies.ClientIP(remoteAddr, forwardedFor)
+
+	if values := md.Get(forwardedUserAgentKey); trusted && len(values) > 0 {
+		info.UserAgent = values[0]
+	} else if values := md.Get("user-agent"); len(values) > 0 {
+		info.UserAgent = values[0]
+	}
+
+	info.RequestID = interceptors.RequestID(ctx)
+	return info
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/proxy"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func incoming(remoteAddr string, pairs ...string) context.Context {
+	addr, _ := net.ResolveTCPAddr("tcp", remoteAddr)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(pairs...))
+}
+
+func TestClientInfo(t *testing.T) {
+	trusted, err := proxy.ParseTrusted("10.0.0.2")
+	require.NoError(t, err)
+	s := &Server{TrustedProxies: trusted}
+
+	forwarded := []string{
+		forwardedForKey, "198.51.100.1",
+		forwardedUserAgentKey, "Firefox",
+		"user-agent", "grpc-go/1.72",
+	}
+
+	info := s.clientInfo(incoming("10.0.0.2:5000", forwarded...))
+	assert.Equal(t, "198.51.100.1", info.IP)
+	assert.Equal(t, "Firefox", info.UserAgent)
+
+	// Метаданные x-forwarded-* от произвольного клиента игнорируются.
+	info = s.clientInfo(incoming("203.0.113.9:5000", forwarded...))
+	assert.Equal(t, "203.0.113.9", info.IP)
+	assert.Equal(t, "grpc-go/1.72", info.UserAgent)
+
+	// Шлюз в том же процессе передает уже определенные данные.
+	ctx := WithClientInfo(incoming("203.0.113.9:5000", forwarded...), models.ClientInfo{IP: "192.0.2.1", UserAgent: "curl"})
+	info = s.clientInfo(ctx)
+	assert.Equal(t, "192.0.2.1", info.IP)
+	assert.Equal(t, "curl", info.UserAgent)
+}
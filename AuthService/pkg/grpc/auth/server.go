@@ -2,33 +2,64 @@ package auth
 
 import (
 	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
 	"AuthService/internal/usecases"
+	"AuthService/pkg/oidc"
+	"AuthService/pkg/proxy"
 	"context"
 	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Server struct {
 	UnimplementedAuthServiceServer
 	AuthService usecases.AuthService
+	// TrustedProxies - адреса сервисов, которым разрешено передавать адрес
+	// и user agent конечного клиента в метаданных x-forwarded-*.
+	TrustedProxies proxy.Trusted
+	// Shutdown закрывается при остановке сервиса и завершает потоки
+	// WatchUserEvents, чтобы они не задерживали GracefulStop.
+	Shutdown <-chan struct{}
 }
 
 func (s *Server) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
-	err := s.AuthService.Register(ctx, req.Username, req.Password)
+	err := s.AuthService.Register(ctx, req.Username, req.Password, req.Email, s.clientInfo(ctx))
 	if err != nil {
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError("invalid registration data", invalid)
+		}
 		if errors.Is(err, domain.UserAlreadyExists) {
 			return nil, status.Errorf(codes.AlreadyExists, "user already exists")
 		}
+		if errors.Is(err, domain.EmailAlreadyExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "email already in use")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to register user: %v", err)
 	}
 	return &RegisterResponse{Message: "user created successfully"}, nil
 }
 
 func (s *Server) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
-	tokens, err := s.AuthService.Login(ctx, req.Username, req.Password)
+	result, err := s.AuthService.Login(ctx, req.Username, req.Password, s.clientInfo(ctx))
 	if err != nil {
+		var retry *domain.RetryAfterError
+		var suspended *domain.SuspendedError
 		switch {
+		case errors.As(err, &retry):
+			return nil, retryError(retry)
+		case errors.As(err, &suspended):
+			return nil, suspendedError(suspended)
 		case errors.Is(err, domain.UserNotFound), errors.Is(err, domain.InvalidData):
 			return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
 		default:
@@ -36,19 +67,32 @@ func (s *Server) Login(ctx context.Context, req *LoginRequest) (*LoginResponse,
 		}
 	}
 
+	if result.ChallengeToken != "" {
+		return &LoginResponse{
+			Message:              "second factor required",
+			SecondFactorRequired: true,
+			ChallengeToken:       result.ChallengeToken,
+		}, nil
+	}
+
 	return &LoginResponse{
 		Message:      "login successful",
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
 	}, nil
 }
 
 func (s *Server) Refresh(ctx context.Context, req *RefreshRequest) (*RefreshResponse, error) {
-	tokens, err := s.AuthService.Refresh(ctx, req.RefreshToken)
+	tokens, err := s.AuthService.Refresh(ctx, req.RefreshToken, s.clientInfo(ctx))
 	if err != nil {
+		var suspended *domain.SuspendedError
 		switch {
+		case errors.As(err, &suspended):
+			return nil, suspendedError(suspended)
 		case errors.Is(err, domain.InvalidToken):
 			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		case errors.Is(err, domain.TokenReused):
+			return nil, status.Errorf(codes.Unauthenticated, "refresh token reuse detected, session revoked")
 		case errors.Is(err, domain.UserNotFound):
 			return nil, status.Errorf(codes.Unauthenticated, "user not found")
 		default:
@@ -64,21 +108,956 @@ func (s *Server) Refresh(ctx context.Context, req *RefreshRequest) (*RefreshResp
 }
 
 func (s *Server) Logout(ctx context.Context, req *LogoutRequest) (*LogoutResponse, error) {
-	// В реальной реализации здесь должна быть логика инвалидации токена
+	if err := s.AuthService.Logout(ctx, req.RefreshToken, s.clientInfo(ctx)); err != nil {
+		if errors.Is(err, domain.InvalidToken) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to logout: %v", err)
+	}
 	return &LogoutResponse{Message: "logout successful"}, nil
 }
 
+// VerifyToken определен через Introspect: ответ сворачивает причину
+// отказа к прежним значениям error_reason. Как и до появления Introspect,
+// ошибка проверки, в том числе ошибка хранилища, возвращается не ошибкой
+// RPC, а ответом invalid_token: клиенты VerifyToken на нее не рассчитаны.
 func (s *Server) VerifyToken(ctx context.Context, req *VerifyTokenRequest) (*VerifyTokenResponse, error) {
-	claim, err := s.AuthService.VerifyToken(req.Token)
+	introspection, err := s.Introspect(ctx, &IntrospectRequest{Token: req.Token})
 	if err != nil {
 		return &VerifyTokenResponse{
-			Valid: false,
-			Error: "invalid token",
+			Valid:       false,
+			Error:       "invalid token",
+			ErrorReason: "invalid_token",
 		}, nil
 	}
 
+	if !introspection.Active {
+		switch introspection.Error {
+		case IntrospectionError_INTROSPECTION_ERROR_ACCOUNT_SUSPENDED:
+			suspended := &domain.SuspendedError{Reason: introspection.Suspension.Reason}
+			if !introspection.Suspension.Permanent {
+				until := time.Unix(introspection.Suspension.Until, 0)
+				suspended.Until = &until
+			}
+			return &VerifyTokenResponse{
+				Valid:       false,
+				Error:       suspended.Error(),
+				ErrorReason: "account_suspended",
+				Suspension:  introspection.Suspension,
+			}, nil
+		case IntrospectionError_INTROSPECTION_ERROR_REVOKED:
+			return &VerifyTokenResponse{
+				Valid:       false,
+				Error:       "session revoked",
+				ErrorReason: "session_revoked",
+			}, nil
+		default:
+			return &VerifyTokenResponse{
+				Valid:       false,
+				Error:       "invalid token",
+				ErrorReason: "invalid_token",
+			}, nil
+		}
+	}
+
+	claims := introspection.Claims
 	return &VerifyTokenResponse{
-		Valid:    true,
-		Username: claim.Username,
+		Valid:          true,
+		Username:       claims.Username,
+		UserId:         claims.UserId,
+		Roles:          claims.Roles,
+		EmailVerified:  claims.EmailVerified,
+		ClientId:       claims.ClientId,
+		Scopes:         claims.Scopes,
+		CredentialKind: claims.CredentialKind,
+	}, nil
+}
+
+func (s *Server) Introspect(ctx context.Context, req *IntrospectRequest) (*IntrospectResponse, error) {
+	result, err := s.AuthService.Introspect(ctx, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to introspect token: %v", err)
+	}
+
+	if !result.Active {
+		resp := &IntrospectResponse{Error: inactiveReasonToProto(result.Reason)}
+		if result.Suspension != nil {
+			resp.Suspension = suspensionToProto(result.Suspension)
+		}
+		return resp, nil
+	}
+
+	claims := result.Claims
+	return &IntrospectResponse{
+		Active: true,
+		Claims: &TokenClaims{
+			TokenId:        claims.ID,
+			UserId:         int64(claims.UserID),
+			Username:       claims.Username,
+			Roles:          claims.Roles,
+			EmailVerified:  claims.EmailVerified,
+			SessionId:      claims.SessionID,
+			ClientId:       claims.ClientID,
+			Scopes:         claims.Scopes,
+			ExpiresAt:      claims.ExpiresAt.Unix(),
+			IssuedAt:       claims.IssuedAt.Unix(),
+			CredentialKind: credentialKindToProto(claims.Credential),
+		},
+	}, nil
+}
+
+func inactiveReasonToProto(reason models.InactiveReason) IntrospectionError {
+	switch reason {
+	case models.TokenMalformed:
+		return IntrospectionError_INTROSPECTION_ERROR_MALFORMED
+	case models.TokenExpired:
+		return IntrospectionError_INTROSPECTION_ERROR_EXPIRED
+	case models.TokenRevoked:
+		return IntrospectionError_INTROSPECTION_ERROR_REVOKED
+	case models.TokenWrongType:
+		return IntrospectionError_INTROSPECTION_ERROR_WRONG_TYPE
+	case models.TokenOwnerBanned:
+		return IntrospectionError_INTROSPECTION_ERROR_ACCOUNT_SUSPENDED
+	default:
+		return IntrospectionError_INTROSPECTION_ERROR_UNSPECIFIED
+	}
+}
+
+func credentialKindToProto(kind models.CredentialKind) CredentialKind {
+	switch kind {
+	case models.CredentialAccessToken:
+		return CredentialKind_CREDENTIAL_KIND_ACCESS_TOKEN
+	case models.CredentialOAuthAccessToken:
+		return CredentialKind_CREDENTIAL_KIND_OAUTH_ACCESS_TOKEN
+	case models.CredentialPersonalToken:
+		return CredentialKind_CREDENTIAL_KIND_PERSONAL_ACCESS_TOKEN
+	default:
+		return CredentialKind_CREDENTIAL_KIND_UNSPECIFIED
+	}
+}
+
+func (s *Server) GrantRole(ctx context.Context, req *GrantRoleRequest) (*GrantRoleResponse, error) {
+	admin, err := s.authorize(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AuthService.GrantRole(ctx, admin.UserID, int(req.UserId), models.Role(req.Role), s.clientInfo(ctx)); err != nil {
+		return nil, roleError(err)
+	}
+	return &GrantRoleResponse{Message: "role granted"}, nil
+}
+
+func (s *Server) RevokeRole(ctx context.Context, req *RevokeRoleRequest) (*RevokeRoleResponse, error) {
+	admin, err := s.authorize(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AuthService.RevokeRole(ctx, admin.UserID, int(req.UserId), models.Role(req.Role), s.clientInfo(ctx)); err != nil {
+		return nil, roleError(err)
+	}
+	return &RevokeRoleResponse{Message: "role revoked"}, nil
+}
+
+func (s *Server) SuspendUser(ctx context.Context, req *SuspendUserRequest) (*SuspendUserResponse, error) {
+	admin, err := s.authorize(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	var until *time.Time
+	if req.Until != 0 {
+		t := time.Unix(req.Until, 0)
+		until = &t
+	}
+
+	suspension, err := s.AuthService.SuspendUser(ctx, admin.UserID, int(req.UserId), req.Reason, until, s.clientInfo(ctx))
+	if err != nil {
+		return nil, suspensionError(err)
+	}
+	return &SuspendUserResponse{
+		Message:    "user suspended",
+		Suspension: suspensionToProto(suspension),
+	}, nil
+}
+
+func (s *Server) UnsuspendUser(ctx context.Context, req *UnsuspendUserRequest) (*UnsuspendUserResponse, error) {
+	admin, err := s.authorize(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AuthService.UnsuspendUser(ctx, admin.UserID, int(req.UserId), s.clientInfo(ctx)); err != nil {
+		return nil, suspensionError(err)
+	}
+	return &UnsuspendUserResponse{Message: "user suspension lifted"}, nil
+}
+
+// GetSuspension отдает блокировку самому пользователю и администраторам.
+// Заблокированный пользователь не пройдет authenticate, но причину и срок
+// блокировки получит в ErrorInfo отказа.
+func (s *Server) GetSuspension(ctx context.Context, req *GetSuspensionRequest) (*GetSuspensionResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != int(req.UserId) && !models.HasRole(claims.Roles, models.RoleAdmin) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", models.RoleAdmin)
+	}
+
+	suspension, err := s.AuthService.GetSuspension(ctx, int(req.UserId))
+	if errors.Is(err, domain.SuspensionNotFound) {
+		return &GetSuspensionResponse{Suspended: false}, nil
+	} else if err != nil {
+		return nil, suspensionError(err)
+	}
+	return &GetSuspensionResponse{
+		Suspended:  true,
+		Suspension: suspensionToProto(suspension),
+	}, nil
+}
+
+func (s *Server) ListSessions(ctx context.Context, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.AuthService.ListSessions(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+
+	result := make([]*Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, &Session{
+			Id:         session.ID,
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastUsedAt: session.LastUsedAt.Unix(),
+			Current:    session.ID == claims.SessionID,
+			ClientId:   session.ClientID,
+		})
+	}
+	return &ListSessionsResponse{Sessions: result}, nil
+}
+
+func (s *Server) RevokeSession(ctx context.Context, req *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.SessionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session_id is required")
+	}
+
+	if err := s.AuthService.RevokeSession(ctx, claims.UserID, req.SessionId); err != nil {
+		if errors.Is(err, domain.SessionNotFound) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	return &RevokeSessionResponse{Message: "session revoked"}, nil
+}
+
+func (s *Server) RevokeAllSessions(ctx context.Context, req *RevokeAllSessionsRequest) (*RevokeAllSessionsResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var keep string
+	if req.KeepCurrent {
+		if claims.SessionID == "" {
+			return nil, status.Errorf(codes.FailedPrecondition, "access token is not bound to a session")
+		}
+		keep = claims.SessionID
+	}
+
+	revoked, err := s.AuthService.RevokeAllSessions(ctx, claims.UserID, keep)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions: %v", err)
+	}
+	return &RevokeAllSessionsResponse{
+		Message: "sessions revoked",
+		Revoked: int32(revoked),
+	}, nil
+}
+
+func suspensionToProto(suspension *models.Suspension) *Suspension {
+	result := &Suspension{
+		UserId:    int64(suspension.UserID),
+		Reason:    suspension.Reason,
+		Permanent: suspension.Permanent(),
+	}
+	if suspension.Until != nil {
+		result.Until = suspension.Until.Unix()
+	}
+	if !suspension.CreatedAt.IsZero() {
+		result.CreatedAt = suspension.CreatedAt.Unix()
+	}
+	return result
+}
+
+func suspensionError(err error) error {
+	var invalid *domain.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError("invalid suspension request", invalid)
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	case errors.Is(err, domain.SuspensionNotFound):
+		return status.Errorf(codes.NotFound, "user is not suspended")
+	default:
+		return status.Errorf(codes.Internal, "failed to update suspension: %v", err)
+	}
+}
+
+// suspendedError возвращает PermissionDenied с ErrorInfo, по которому
+// клиент может отличить блокировку от прочих отказов.
+func suspendedError(err *domain.SuspendedError) error {
+	st := status.New(codes.PermissionDenied, err.Error())
+
+	metadata := map[string]string{"reason": err.Reason}
+	if err.Until != nil {
+		metadata["until"] = err.Until.UTC().Format(time.RFC3339)
+	}
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "ACCOUNT_SUSPENDED",
+		Domain:   "auth-service",
+		Metadata: metadata,
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// retryError возвращает ResourceExhausted с RetryInfo, чтобы клиент
+// мог узнать, когда повторить попытку.
+func retryError(err *domain.RetryAfterError) error {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	st := status.New(codes.ResourceExhausted,
+		fmt.Sprintf("%s, retry after %ds", err.Err.Error(), seconds))
+
+	detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(err.RetryAfter),
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (s *Server) EnrollTOTP(ctx context.Context, req *EnrollTOTPRequest) (*EnrollTOTPResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.AuthService.EnrollTOTP(ctx, claims.UserID)
+	if err != nil {
+		return nil, secondFactorError(err)
+	}
+	return &EnrollTOTPResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.ProvisioningURI,
+	}, nil
+}
+
+func (s *Server) ConfirmTOTP(ctx context.Context, req *ConfirmTOTPRequest) (*ConfirmTOTPResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.AuthService.ConfirmTOTP(ctx, claims.UserID, req.Code)
+	if err != nil {
+		return nil, secondFactorError(err)
+	}
+	return &ConfirmTOTPResponse{
+		Message:       "two-factor authentication enabled",
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *Server) VerifySecondFactor(ctx context.Context, req *VerifySecondFactorRequest) (*VerifySecondFactorResponse, error) {
+	tokens, err := s.AuthService.VerifySecondFactor(ctx, req.ChallengeToken, req.Code, s.clientInfo(ctx))
+	if err != nil {
+		var retry *domain.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, retryError(retry)
+		}
+		var suspended *domain.SuspendedError
+		if errors.As(err, &suspended) {
+			return nil, suspendedError(suspended)
+		}
+		return nil, secondFactorError(err)
+	}
+
+	return &VerifySecondFactorResponse{
+		Message:      "login successful",
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func secondFactorError(err error) error {
+	switch {
+	case errors.Is(err, domain.InvalidToken):
+		return status.Errorf(codes.Unauthenticated, "invalid challenge token")
+	case errors.Is(err, domain.InvalidSecondFactor):
+		return status.Errorf(codes.Unauthenticated, "invalid second factor code")
+	case errors.Is(err, domain.SecondFactorNotFound):
+		return status.Errorf(codes.FailedPrecondition, "two-factor authentication is not enrolled")
+	case errors.Is(err, domain.SecondFactorAlreadyEnabled):
+		return status.Errorf(codes.AlreadyExists, "two-factor authentication is already enabled")
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		return status.Errorf(codes.Internal, "second factor failed: %v", err)
+	}
+}
+
+func (s *Server) ChangePassword(ctx context.Context, req *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.AuthService.ChangePassword(ctx, claims.UserID, req.CurrentPassword, req.NewPassword, s.clientInfo(ctx))
+	if err != nil {
+		var retry *domain.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, retryError(retry)
+		}
+		if errors.Is(err, domain.InvalidData) {
+			return nil, status.Errorf(codes.PermissionDenied, "invalid current password")
+		}
+		return nil, passwordError(err)
+	}
+
+	return &ChangePasswordResponse{
+		Message:      "password changed",
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *Server) RequestPasswordReset(ctx context.Context, req *RequestPasswordResetRequest) (*RequestPasswordResetResponse, error) {
+	if err := s.AuthService.RequestPasswordReset(ctx, req.Username, s.clientInfo(ctx)); err != nil {
+		var retry *domain.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, retryError(retry)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to request password reset: %v", err)
+	}
+	return &RequestPasswordResetResponse{Message: "if the account exists, reset instructions have been sent"}, nil
+}
+
+func (s *Server) ResetPassword(ctx context.Context, req *ResetPasswordRequest) (*ResetPasswordResponse, error) {
+	if err := s.AuthService.ResetPassword(ctx, req.Token, req.NewPassword, s.clientInfo(ctx)); err != nil {
+		if errors.Is(err, domain.InvalidToken) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired reset token")
+		}
+		return nil, passwordError(err)
+	}
+	return &ResetPasswordResponse{Message: "password reset successfully"}, nil
+}
+
+func (s *Server) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*VerifyEmailResponse, error) {
+	if err := s.AuthService.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, emailError(err)
+	}
+	return &VerifyEmailResponse{Message: "email verified"}, nil
+}
+
+func (s *Server) ResendVerificationEmail(ctx context.Context, req *ResendVerificationEmailRequest) (*ResendVerificationEmailResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AuthService.ResendVerificationEmail(ctx, claims.UserID); err != nil {
+		return nil, emailError(err)
+	}
+	return &ResendVerificationEmailResponse{Message: "verification email sent"}, nil
+}
+
+func emailError(err error) error {
+	switch {
+	case errors.Is(err, domain.InvalidToken):
+		return status.Errorf(codes.Unauthenticated, "invalid or expired verification token")
+	case errors.Is(err, domain.EmailNotSet):
+		return status.Errorf(codes.FailedPrecondition, "email address is not set")
+	case errors.Is(err, domain.EmailAlreadyVerified):
+		return status.Errorf(codes.FailedPrecondition, "email address already verified")
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		return status.Errorf(codes.Internal, "email verification failed: %v", err)
+	}
+}
+
+func (s *Server) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	if _, err := s.authenticateScope(ctx, models.ScopeProfileRead); err != nil {
+		return nil, err
+	}
+	if req.UserId == 0 && req.Username == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id or username is required")
+	}
+
+	profile, err := s.AuthService.GetUser(ctx, int(req.UserId), req.Username)
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return &GetUserResponse{User: profileToProto(profile)}, nil
+}
+
+func (s *Server) BatchGetUsers(ctx context.Context, req *BatchGetUsersRequest) (*BatchGetUsersResponse, error) {
+	if _, err := s.authenticateScope(ctx, models.ScopeProfileRead); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(req.UserIds))
+	for _, id := range req.UserIds {
+		ids = append(ids, int(id))
+	}
+
+	profiles, err := s.AuthService.BatchGetUsers(ctx, ids, req.Usernames)
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return &BatchGetUsersResponse{Users: profilesToProto(profiles)}, nil
+}
+
+func (s *Server) SearchUsers(ctx context.Context, req *SearchUsersRequest) (*SearchUsersResponse, error) {
+	if _, err := s.authenticateScope(ctx, models.ScopeProfileRead); err != nil {
+		return nil, err
+	}
+
+	profiles, next, err := s.AuthService.SearchUsers(ctx, req.Query, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return &SearchUsersResponse{Users: profilesToProto(profiles), NextPageToken: next}, nil
+}
+
+func (s *Server) UpdateProfile(ctx context.Context, req *UpdateProfileRequest) (*UpdateProfileResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.AuthService.UpdateProfile(ctx, claims.UserID, models.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		AvatarURL:   req.AvatarUrl,
+	})
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return &UpdateProfileResponse{User: profileToProto(profile)}, nil
+}
+
+func profileToProto(profile *models.Profile) *UserProfile {
+	user := &UserProfile{
+		UserId:      int64(profile.UserID),
+		Username:    profile.Username,
+		DisplayName: profile.DisplayName,
+		Bio:         profile.Bio,
+		AvatarUrl:   profile.AvatarURL,
+		CreatedAt:   profile.CreatedAt.Unix(),
+	}
+	if profile.LastSeen != nil {
+		user.LastSeen = profile.LastSeen.Unix()
+	}
+	return user
+}
+
+func profilesToProto(profiles []*models.Profile) []*UserProfile {
+	users := make([]*UserProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		users = append(users, profileToProto(profile))
+	}
+	return users
+}
+
+func profileError(err error) error {
+	var invalid *domain.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError("invalid profile request", invalid)
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		return status.Errorf(codes.Internal, "failed to load profile: %v", err)
+	}
+}
+
+func passwordError(err error) error {
+	var invalid *domain.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError("invalid password", invalid)
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		return status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+}
+
+// validationError возвращает InvalidArgument, перечисляя причины и в
+// тексте ("<msg>: reason1,reason2"), и в деталях BadRequest.
+func validationError(msg string, err *domain.ValidationError) error {
+	st := status.New(codes.InvalidArgument, msg+": "+strings.Join(err.Reasons, ","))
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(err.Reasons))
+	for _, reason := range err.Reasons {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       reasonField(reason),
+			Description: reason,
+		})
+	}
+
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// reasonField выделяет имя поля из кода причины ("display_name_too_long"
+// -> "display_name").
+func reasonField(reason string) string {
+	for _, field := range []string{"display_name", "avatar_url", "page_token", "redirect_uris", "redirect_uri", "expires_at", "event_types", "end_time"} {
+		if strings.HasPrefix(reason, field+"_") {
+			return field
+		}
+	}
+	field, _, _ := strings.Cut(reason, "_")
+	return field
+}
+
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, domain.InvalidRole):
+		return status.Errorf(codes.InvalidArgument, "invalid role")
+	case errors.Is(err, domain.UserNotFound):
+		return status.Errorf(codes.NotFound, "user not found")
+	default:
+		return status.Errorf(codes.Internal, "failed to update roles: %v", err)
+	}
+}
+
+func (s *Server) RegisterOAuthClient(ctx context.Context, req *RegisterOAuthClientRequest) (*RegisterOAuthClientResponse, error) {
+	admin, err := s.authorize(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	client, secret, err := s.AuthService.RegisterOAuthClient(ctx, admin.UserID, req.Name, req.RedirectUris, req.Scopes, req.Confidential)
+	if err != nil {
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError("invalid oauth client", invalid)
+		}
+		if errors.Is(err, domain.OAuthConsentDisabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, "OAuthConsentURL is not configured")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to register oauth client: %v", err)
+	}
+	return &RegisterOAuthClientResponse{
+		ClientId:     client.ID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		RedirectUris: client.RedirectURIs,
+		Scopes:       client.Scopes,
+	}, nil
+}
+
+func (s *Server) ListIdentityProviders(ctx context.Context, req *ListIdentityProvidersRequest) (*ListIdentityProvidersResponse, error) {
+	return &ListIdentityProvidersResponse{Providers: s.AuthService.ListExternalProviders(ctx)}, nil
+}
+
+func (s *Server) StartExternalLogin(ctx context.Context, req *StartExternalLoginRequest) (*StartExternalLoginResponse, error) {
+	start, err := s.AuthService.StartExternalLogin(ctx, req.Provider)
+	if err != nil {
+		return nil, externalLoginError(err)
+	}
+	return &StartExternalLoginResponse{
+		AuthorizationUrl: start.AuthorizationURL,
+		State:            start.State,
+	}, nil
+}
+
+func (s *Server) CompleteExternalLogin(ctx context.Context, req *CompleteExternalLoginRequest) (*CompleteExternalLoginResponse, error) {
+	if req.State == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "state and code are required")
+	}
+
+	result, err := s.AuthService.CompleteExternalLogin(ctx, req.Provider, req.State, req.Code, s.clientInfo(ctx))
+	if err != nil {
+		var suspended *domain.SuspendedError
+		if errors.As(err, &suspended) {
+			return nil, suspendedError(suspended)
+		}
+		return nil, externalLoginError(err)
+	}
+
+	if result.ChallengeToken != "" {
+		return &CompleteExternalLoginResponse{
+			Message:              "second factor required",
+			SecondFactorRequired: true,
+			ChallengeToken:       result.ChallengeToken,
+		}, nil
+	}
+	return &CompleteExternalLoginResponse{
+		Message:      "login successful",
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
 	}, nil
 }
+
+func externalLoginError(err error) error {
+	switch {
+	case errors.Is(err, domain.ExternalProviderNotFound):
+		return status.Errorf(codes.NotFound, "identity provider not found")
+	case errors.Is(err, domain.ExternalLoginStateNotFound):
+		return status.Errorf(codes.InvalidArgument, "invalid or expired login state")
+	case errors.Is(err, domain.ExternalLoginFailed):
+		return status.Errorf(codes.Unauthenticated, "identity provider did not confirm the login")
+	case errors.Is(err, oidc.ErrDiscovery):
+		return status.Errorf(codes.Unavailable, "identity provider is unavailable")
+	default:
+		return status.Errorf(codes.Internal, "external login failed: %v", err)
+	}
+}
+
+func (s *Server) CreatePersonalToken(ctx context.Context, req *CreatePersonalTokenRequest) (*CreatePersonalTokenResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != 0 {
+		expiresAt = time.Unix(req.ExpiresAt, 0)
+	}
+	token, raw, err := s.AuthService.CreatePersonalToken(ctx, claims.UserID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError("invalid personal access token", invalid)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create personal access token: %v", err)
+	}
+	return &CreatePersonalTokenResponse{
+		Token:         raw,
+		PersonalToken: personalTokenToProto(token),
+	}, nil
+}
+
+func (s *Server) ListPersonalTokens(ctx context.Context, req *ListPersonalTokensRequest) (*ListPersonalTokensResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.AuthService.ListPersonalTokens(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list personal access tokens: %v", err)
+	}
+
+	result := make([]*PersonalToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, personalTokenToProto(token))
+	}
+	return &ListPersonalTokensResponse{PersonalTokens: result}, nil
+}
+
+func (s *Server) RevokePersonalToken(ctx context.Context, req *RevokePersonalTokenRequest) (*RevokePersonalTokenResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.TokenId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token_id is required")
+	}
+
+	if err := s.AuthService.RevokePersonalToken(ctx, claims.UserID, req.TokenId); err != nil {
+		if errors.Is(err, domain.PersonalTokenNotFound) {
+			return nil, status.Errorf(codes.NotFound, "personal access token not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke personal access token: %v", err)
+	}
+	return &RevokePersonalTokenResponse{Message: "personal access token revoked"}, nil
+}
+
+func personalTokenToProto(token *models.PersonalAccessToken) *PersonalToken {
+	result := &PersonalToken{
+		Id:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.Unix(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+	}
+	if token.LastUsedAt != nil {
+		result.LastUsedAt = token.LastUsedAt.Unix()
+	}
+	return result
+}
+
+func (s *Server) ListAuditEvents(ctx context.Context, req *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	if _, err := s.authorize(ctx, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	filter := models.AuditFilter{UserID: int(req.UserId)}
+	for _, eventType := range req.EventTypes {
+		filter.Types = append(filter.Types, models.AuditEventType(eventType))
+	}
+	if req.StartTime != 0 {
+		filter.Since = time.Unix(req.StartTime, 0)
+	}
+	if req.EndTime != 0 {
+		filter.Until = time.Unix(req.EndTime, 0)
+	}
+
+	events, next, err := s.AuthService.ListAuditEvents(ctx, filter, int(req.PageSize), req.PageToken)
+	if err != nil {
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError("invalid audit query", invalid)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to list audit events: %v", err)
+	}
+
+	result := make([]*AuditEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, &AuditEvent{
+			Id:        event.ID,
+			EventType: string(event.Type),
+			UserId:    int64(event.UserID),
+			ActorId:   int64(event.ActorID),
+			Ip:        event.IP,
+			UserAgent: event.UserAgent,
+			RequestId: event.RequestID,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt.Unix(),
+		})
+	}
+	return &ListAuditEventsResponse{Events: result, NextPageToken: next}, nil
+}
+
+// WatchUserEvents доступен только администраторам: лента раскрывает имена
+// и изменения профилей всех пользователей. Сервисы подписываются с токеном
+// служебной учетной записи с ролью admin.
+func (s *Server) WatchUserEvents(req *WatchUserEventsRequest, stream grpc.ServerStreamingServer[UserEvent]) error {
+	if _, err := s.authorize(stream.Context(), models.RoleAdmin); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.Shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	types := make([]models.UserEventType, 0, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		types = append(types, models.UserEventType(eventType))
+	}
+
+	err := s.AuthService.WatchUserEvents(ctx, req.Cursor, types, func(event *models.UserEvent) error {
+		return stream.Send(&UserEvent{
+			Cursor:    strconv.FormatInt(event.ID, 10),
+			EventType: string(event.Type),
+			UserId:    int64(event.UserID),
+			Data:      event.Data,
+			CreatedAt: event.CreatedAt.Unix(),
+		})
+	})
+
+	var invalid *domain.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError("invalid watch request", invalid)
+	case stream.Context().Err() != nil:
+		return status.FromContextError(stream.Context().Err()).Err()
+	case ctx.Err() != nil:
+		// Подписчик переподключится к другому экземпляру с последним cursor.
+		return status.Errorf(codes.Unavailable, "server is shutting down")
+	case err != nil:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "failed to watch user events: %v", err)
+	}
+	return nil
+}
+
+// authenticate проверяет access токен из метаданных "authorization".
+func (s *Server) authenticate(ctx context.Context) (*models.TokenClaims, error) {
+	claims, err := s.verifyCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Токены клиентов OAuth и personal access токены действуют только в
+	// пределах своих scopes и не дают управлять учетной записью.
+	if claims.Scoped() {
+		return nil, status.Errorf(codes.PermissionDenied, "scoped tokens cannot manage the account")
+	}
+	return claims, nil
+}
+
+// authenticateScope пропускает токены первого лица и токены со scopes,
+// если среди них есть scope.
+func (s *Server) authenticateScope(ctx context.Context, scope models.Scope) (*models.TokenClaims, error) {
+	claims, err := s.verifyCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scoped() && !models.ContainsScopes(claims.Scopes, []string{string(scope)}) {
+		return nil, status.Errorf(codes.PermissionDenied, "token does not grant the %s scope", scope)
+	}
+	return claims, nil
+}
+
+// verifyCaller проверяет токен из метаданных authorization.
+func (s *Server) verifyCaller(ctx context.Context) (*models.TokenClaims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "authorization metadata is required")
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	claims, err := s.AuthService.VerifyToken(ctx, token)
+	if err != nil {
+		var suspended *domain.SuspendedError
+		if errors.As(err, &suspended) {
+			return nil, suspendedError(suspended)
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+	}
+	return claims, nil
+}
+
+// authorize дополнительно проверяет наличие у владельца токена указанной роли.
+func (s *Server) authorize(ctx context.Context, role models.Role) (*models.TokenClaims, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !models.HasRole(claims.Roles, role) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", role)
+	}
+	return claims, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/usecases"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenService принимает токены из tokens и возвращает один профиль.
+type tokenService struct {
+	usecases.AuthService
+	tokens map[string]*models.TokenClaims
+}
+
+func (f *tokenService) VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error) {
+	if claims, ok := f.tokens[token]; ok {
+		return claims, nil
+	}
+	return nil, domain.InvalidToken
+}
+
+func (f *tokenService) GetUser(ctx context.Context, userID int, username string) (*models.Profile, error) {
+	return &models.Profile{UserID: userID, Username: "alice"}, nil
+}
+
+func (f *tokenService) WatchUserEvents(ctx context.Context, cursor string, types []models.UserEventType, send func(*models.UserEvent) error) error {
+	return send(&models.UserEvent{ID: 1, Type: models.UserCreated, UserID: 2})
+}
+
+func (f *tokenService) GetSuspension(ctx context.Context, userID int) (*models.Suspension, error) {
+	return nil, domain.SuspensionNotFound
+}
+
+// failingIntrospection отвечает на Introspect ошибкой хранилища.
+type failingIntrospection struct {
+	usecases.AuthService
+}
+
+func (f failingIntrospection) Introspect(ctx context.Context, token string) (*models.Introspection, error) {
+	return nil, errors.New("connection reset")
+}
+
+// eventStream собирает события, отправленные WatchUserEvents.
+type eventStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*UserEvent
+}
+
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream) Send(event *UserEvent) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestGetUser_RequiresCredential(t *testing.T) {
+	s := &Server{AuthService: &tokenService{tokens: map[string]*models.TokenClaims{
+		"user":     {UserID: 2},
+		"bot":      {UserID: 3, ClientID: "bot", Scopes: []string{string(models.ScopeTopicsWrite)}},
+		"reader":   {UserID: 3, ClientID: "reader", Scopes: []string{string(models.ScopeProfileRead)}},
+		"personal": {UserID: 3, Credential: models.CredentialPersonalToken},
+	}}}
+	req := &GetUserRequest{UserId: 1}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{"no credential", context.Background(), codes.Unauthenticated},
+		{"invalid token", withToken("forged"), codes.Unauthenticated},
+		{"first party token", withToken("user"), codes.OK},
+		{"client token with profile:read", withToken("reader"), codes.OK},
+		{"client token without profile:read", withToken("bot"), codes.PermissionDenied},
+		{"personal token without scopes", withToken("personal"), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.GetUser(tt.ctx, req)
+			assert.Equal(t, tt.expected, status.Code(err))
+			if tt.expected == codes.OK {
+				require.NotNil(t, resp)
+				assert.Equal(t, "alice", resp.User.Username)
+			}
+		})
+	}
+
+	_, err := s.SearchUsers(context.Background(), &SearchUsersRequest{Query: "ali"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.BatchGetUsers(context.Background(), &BatchGetUsersRequest{UserIds: []int64{1}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGetSuspension_OwnerOrAdmin(t *testing.T) {
+	s := &Server{AuthService: &tokenService{tokens: map[string]*models.TokenClaims{
+		"owner": {UserID: 2, Roles: []string{string(models.RoleUser)}},
+		"other": {UserID: 3, Roles: []string{string(models.RoleUser)}},
+		"admin": {UserID: 1, Roles: []string{string(models.RoleAdmin)}},
+		"bot":   {UserID: 2, ClientID: "bot", Scopes: []string{string(models.ScopeProfileRead)}},
+	}}}
+	req := &GetSuspensionRequest{UserId: 2}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{"no credential", context.Background(), codes.Unauthenticated},
+		{"invalid token", withToken("forged"), codes.Unauthenticated},
+		{"owner", withToken("owner"), codes.OK},
+		{"another user", withToken("other"), codes.PermissionDenied},
+		{"admin", withToken("admin"), codes.OK},
+		{"client token of the owner", withToken("bot"), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.GetSuspension(tt.ctx, req)
+			assert.Equal(t, tt.expected, status.Code(err))
+			if tt.expected == codes.OK {
+				require.NotNil(t, resp)
+				assert.False(t, resp.Suspended)
+			}
+		})
+	}
+}
+
+func TestWatchUserEvents_RequiresAdmin(t *testing.T) {
+	s := &Server{AuthService: &tokenService{tokens: map[string]*models.TokenClaims{
+		"user":  {UserID: 2, Roles: []string{string(models.RoleUser)}},
+		"admin": {UserID: 1, Roles: []string{string(models.RoleAdmin)}},
+	}}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{"no credential", context.Background(), codes.Unauthenticated},
+		{"invalid token", withToken("forged"), codes.Unauthenticated},
+		{"user token", withToken("user"), codes.PermissionDenied},
+		{"admin token", withToken("admin"), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &eventStream{ctx: tt.ctx}
+			err := s.WatchUserEvents(&WatchUserEventsRequest{Cursor: "0"}, stream)
+			assert.Equal(t, tt.expected, status.Code(err))
+			if tt.expected == codes.OK {
+				require.Len(t, stream.sent, 1)
+				assert.Equal(t, "1", stream.sent[0].Cursor)
+			} else {
+				assert.Empty(t, stream.sent)
+			}
+		})
+	}
+}
+
+func TestVerifyToken_StorageErrorIsInvalidToken(t *testing.T) {
+	s := &Server{AuthService: failingIntrospection{}}
+
+	resp, err := s.VerifyToken(context.Background(), &VerifyTokenRequest{Token: "access"})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, "invalid_token", resp.ErrorReason)
+
+	_, err = s.Introspect(context.Background(), &IntrospectRequest{Token: "access"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+// Pinger - зависимость, от доступности которой зависит готовность
+// сервиса. *sql.DB подходит без обертки.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Checker периодически проверяет базу данных и выставляет статус
+// grpc.health.v1 для общего статуса ("") и перечисленных сервисов.
+type Checker struct {
+	server   *health.Server
+	db       Pinger
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+	logger   *zap.Logger
+}
+
+func NewChecker(server *health.Server, db Pinger, services []string, interval time.Duration, logger *zap.Logger) *Checker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	timeout := interval / 2
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &Checker{
+		server:   server,
+		db:       db,
+		services: append([]string{""}, services...),
+		interval: interval,
+		timeout:  timeout,
+		logger:   logger.With(zap.String("component", "health_checker")),
+	}
+}
+
+// Run проверяет базу сразу и затем каждые interval до отмены ctx.
+func (c *Checker) Run(ctx context.Context) {
+	c.Check(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check выполняет одну проверку и обновляет статус.
+func (c *Checker) Check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := c.db.PingContext(ctx); err != nil {
+		c.logger.Warn("database ping failed", zap.Error(err))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,43 @@
+package healthcheck_test
+
+import (
+	"AuthService/pkg/grpc/healthcheck"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestChecker_Check(t *testing.T) {
+	server := health.NewServer()
+	db := &fakePinger{}
+	checker := healthcheck.NewChecker(server, db, []string{"auth.AuthService"}, time.Second, nil)
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	checker.Check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status("auth.AuthService"))
+
+	db.err = errors.New("connection refused")
+	checker.Check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("auth.AuthService"))
+}
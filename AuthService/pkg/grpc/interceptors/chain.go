@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ServerOptions собирает цепочку интерцепторов сервера. Recovery стоит
+// внутри логирования, чтобы паника попала в access лог с кодом Internal.
+func ServerOptions(logger *zap.Logger) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors(logger)...),
+		grpc.ChainStreamInterceptor(
+			StreamRequestID(),
+			StreamLogging(logger),
+			StreamRecovery(logger),
+		),
+	}
+}
+
+// Unary объединяет ту же цепочку в один интерцептор для вызова
+// обработчиков в обход gRPC транспорта, например из HTTP шлюза.
+func Unary(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	chain := unaryInterceptors(logger)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(chain) - 1; i >= 0; i-- {
+			interceptor, inner := chain[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+func unaryInterceptors(logger *zap.Logger) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		UnaryRequestID(),
+		UnaryLogging(logger),
+		UnaryRecovery(logger),
+	}
+}
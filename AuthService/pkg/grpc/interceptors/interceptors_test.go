@@ -0,0 +1,93 @@
+package interceptors_test
+
+import (
+	"AuthService/pkg/grpc/interceptors"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var info = &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"}
+
+func TestUnaryRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "propagates client id", incoming: "req-123", wantSame: true},
+		{name: "generates missing id", incoming: ""},
+		{name: "replaces id with spaces", incoming: "bad id"},
+		{name: "replaces oversized id", incoming: strings.Repeat("a", 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(interceptors.RequestIDKey, tt.incoming))
+			}
+
+			var got string
+			_, err := interceptors.UnaryRequestID()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = interceptors.RequestID(ctx)
+				return nil, nil
+			})
+
+			require.NoError(t, err)
+			if tt.wantSame {
+				assert.Equal(t, tt.incoming, got)
+			} else {
+				assert.Len(t, got, 32)
+			}
+		})
+	}
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+
+	resp, err := interceptors.UnaryRecovery(zap.New(core))(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		var claims interface{} = "not a map"
+		_ = claims.(map[string]interface{})
+		return "unreachable", nil
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "panic in grpc handler", logs.All()[0].Message)
+}
+
+func TestUnaryLogging(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logging := interceptors.UnaryLogging(zap.New(core))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptors.RequestIDKey, "req-1"))
+	chain := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return logging(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		})
+	}
+	_, err := interceptors.UnaryRequestID()(ctx, nil, info, chain)
+	require.Error(t, err)
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	fields := entry.ContextMap()
+	assert.Equal(t, "/auth.AuthService/Login", fields["method"])
+	assert.Equal(t, "Unauthenticated", fields["code"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Contains(t, fields, "latency")
+}
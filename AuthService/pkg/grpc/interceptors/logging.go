@@ -0,0 +1,64 @@
+package interceptors
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// UnaryLogging пишет access лог каждого вызова: метод, код ответа,
+// длительность и идентификатор запроса.
+func UnaryLogging(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With(zap.String("component", "grpc_access"))
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		started := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, started, err)
+		return resp, err
+	}
+}
+
+func StreamLogging(logger *zap.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With(zap.String("component", "grpc_access"))
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		started := time.Now()
+		err := handler(srv, stream)
+		logCall(stream.Context(), logger, info.FullMethod, started, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, logger *zap.Logger, method string, started time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("latency", time.Since(started)),
+		zap.String("request_id", RequestID(ctx)),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, zap.String("error", status.Convert(err).Message()))
+	}
+
+	logger.Check(levelFor(code), "grpc call").Write(fields...)
+}
+
+// levelFor отделяет ошибки сервера от ожидаемых отказов клиенту.
+func levelFor(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK:
+		return zapcore.InfoLevel
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented, codes.Unavailable:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.WarnLevel
+	}
+}
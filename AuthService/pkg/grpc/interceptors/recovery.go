@@ -0,0 +1,45 @@
+package interceptors
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+)
+
+// UnaryRecovery перехватывает панику в обработчике и возвращает
+// клиенту Internal вместо падения процесса.
+func UnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With(zap.String("component", "grpc_recovery"))
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With(zap.String("component", "grpc_recovery"))
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(stream.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+func recovered(ctx context.Context, logger *zap.Logger, method string, r interface{}) error {
+	logger.Error("panic in grpc handler",
+		zap.String("method", method),
+		zap.String("request_id", RequestID(ctx)),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()))
+	return status.Errorf(codes.Internal, "internal server error")
+}
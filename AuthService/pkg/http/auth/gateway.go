@@ -0,0 +1,466 @@
+package auth
+
+import (
+	grpcAuth "AuthService/pkg/grpc/auth"
+	"AuthService/pkg/grpc/interceptors"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/v1/auth"
+	stateCookieName   = "external_login_state"
+	stateCookiePath   = "/v1/auth/providers"
+	maxBodySize       = 1 << 20
+)
+
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+// endpoint описывает отображение одного HTTP маршрута на RPC.
+type endpoint struct {
+	pattern string
+	rpc     string
+	// status - код ответа при успехе, по умолчанию 200.
+	status int
+	// prepare дополняет запрос данными, которых нет в теле и параметрах.
+	prepare func(r *http.Request, req proto.Message)
+	// query - поля запроса, которые можно передать в строке запроса.
+	// Остальные поля принимаются только из тела и пути: токены в URL
+	// попадают в журналы прокси и историю браузера.
+	query  []string
+	newReq func() proto.Message
+	call   func(ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// unary связывает метод gRPC сервера с endpoint. Тип запроса выводится
+// из сигнатуры метода.
+func unary[Req any, PReq interface {
+	*Req
+	proto.Message
+}, Resp proto.Message](pattern, rpc string, method func(context.Context, PReq) (Resp, error)) endpoint {
+	return endpoint{
+		pattern: pattern,
+		rpc:     rpc,
+		newReq:  func() proto.Message { return PReq(new(Req)) },
+		call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return method(ctx, req.(PReq))
+		},
+	}
+}
+
+func (e endpoint) withStatus(code int) endpoint {
+	e.status = code
+	return e
+}
+
+func (e endpoint) withQuery(fields ...string) endpoint {
+	e.query = fields
+	return e
+}
+
+func (e endpoint) withPrepare(prepare func(r *http.Request, req proto.Message)) endpoint {
+	e.prepare = prepare
+	return e
+}
+
+// gatewayEndpoints перечисляет REST маршруты. Каждому RPC соответствует
+// ровно один маршрут, кроме GetUser, доступного и по имени пользователя,
+// и потокового WatchUserEvents, который доступен только по gRPC.
+func (h *Handler) gatewayEndpoints() []endpoint {
+	s := h.Server
+	return []endpoint{
+		unary("POST /v1/auth/register", "Register", s.Register).withStatus(http.StatusCreated),
+		unary("POST /v1/auth/login", "Login", s.Login),
+		unary("POST /v1/auth/refresh", "Refresh", s.Refresh),
+		unary("POST /v1/auth/logout", "Logout", s.Logout),
+		unary("GET /v1/auth/verify", "VerifyToken", s.VerifyToken).withPrepare(bearerToken),
+		unary("POST /v1/auth/introspect", "Introspect", s.Introspect),
+		unary("POST /v1/auth/second-factor", "VerifySecondFactor", s.VerifySecondFactor),
+		unary("POST /v1/auth/totp/enroll", "EnrollTOTP", s.EnrollTOTP),
+		unary("POST /v1/auth/totp/confirm", "ConfirmTOTP", s.ConfirmTOTP),
+		unary("POST /v1/auth/password/change", "ChangePassword", s.ChangePassword),
+		unary("POST /v1/auth/password/reset-request", "RequestPasswordReset", s.RequestPasswordReset),
+		unary("POST /v1/auth/password/reset", "ResetPassword", s.ResetPassword),
+		unary("POST /v1/auth/email/verify", "VerifyEmail", s.VerifyEmail),
+		unary("POST /v1/auth/email/resend", "ResendVerificationEmail", s.ResendVerificationEmail),
+		unary("GET /v1/auth/providers", "ListIdentityProviders", s.ListIdentityProviders),
+		unary("POST /v1/auth/providers/{provider}/start", "StartExternalLogin", s.StartExternalLogin),
+		unary("POST /v1/auth/providers/{provider}/complete", "CompleteExternalLogin", s.CompleteExternalLogin),
+
+		unary("GET /v1/users", "SearchUsers", s.SearchUsers).withQuery("query", "page_size", "page_token"),
+		unary("GET /v1/users/batch", "BatchGetUsers", s.BatchGetUsers).withQuery("user_ids", "usernames"),
+		unary("GET /v1/users/{user_id}", "GetUser", s.GetUser),
+		unary("GET /v1/usernames/{username}", "GetUser", s.GetUser),
+		unary("PATCH /v1/users/me", "UpdateProfile", s.UpdateProfile),
+		unary("POST /v1/users/{user_id}/roles", "GrantRole", s.GrantRole),
+		unary("DELETE /v1/users/{user_id}/roles/{role}", "RevokeRole", s.RevokeRole),
+		unary("GET /v1/users/{user_id}/suspension", "GetSuspension", s.GetSuspension),
+		unary("PUT /v1/users/{user_id}/suspension", "SuspendUser", s.SuspendUser),
+		unary("DELETE /v1/users/{user_id}/suspension", "UnsuspendUser", s.UnsuspendUser),
+
+		unary("GET /v1/sessions", "ListSessions", s.ListSessions),
+		unary("DELETE /v1/sessions", "RevokeAllSessions", s.RevokeAllSessions),
+		unary("DELETE /v1/sessions/{session_id}", "RevokeSession", s.RevokeSession),
+
+		unary("POST /v1/tokens", "CreatePersonalToken", s.CreatePersonalToken).withStatus(http.StatusCreated),
+		unary("GET /v1/tokens", "ListPersonalTokens", s.ListPersonalTokens),
+		unary("DELETE /v1/tokens/{token_id}", "RevokePersonalToken", s.RevokePersonalToken),
+
+		unary("POST /v1/oauth/clients", "RegisterOAuthClient", s.RegisterOAuthClient).withStatus(http.StatusCreated),
+
+		unary("GET /v1/audit/events", "ListAuditEvents", s.ListAuditEvents).
+			withQuery("user_id", "event_types", "start_time", "end_time", "page_size", "page_token"),
+	}
+}
+
+func (h *Handler) registerGateway(mux *http.ServeMux) {
+	interceptor := interceptors.Unary(h.Logger)
+	for _, e := range h.gatewayEndpoints() {
+		mux.Handle(e.pattern, h.serveEndpoint(e, interceptor))
+	}
+}
+
+func (h *Handler) serveEndpoint(e endpoint, interceptor grpc.UnaryServerInterceptor) http.HandlerFunc {
+	info := &grpc.UnaryServerInfo{
+		Server:     h.Server,
+		FullMethod: "/" + grpcAuth.AuthService_ServiceDesc.ServiceName + "/" + e.rpc,
+	}
+	_, params, _ := strings.Cut(e.pattern, " ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := e.newReq()
+		if err := bindRequest(r, req, params, e.query); err != nil {
+			h.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		if e.prepare != nil {
+			e.prepare(r, req)
+		}
+		if cookie, err := r.Cookie(refreshCookieName); err == nil {
+			setStringIfEmpty(req, "refresh_token", cookie.Value)
+		}
+		if e.rpc == "CompleteExternalLogin" {
+			h.clearStateCookie(w)
+			if !stateCookieMatches(r, req.(*grpcAuth.CompleteExternalLoginRequest).State) {
+				h.writeError(w, status.Error(codes.InvalidArgument, "invalid or expired login state"))
+				return
+			}
+		}
+
+		var requestID string
+		resp, err := interceptor(h.incomingContext(r), req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			requestID = interceptors.RequestID(ctx)
+			return e.call(ctx, req.(proto.Message))
+		})
+		if requestID != "" {
+			w.Header().Set("X-Request-ID", requestID)
+		}
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+
+		message := resp.(proto.Message)
+		h.moveRefreshToken(w, message)
+		if e.rpc == "Logout" {
+			h.clearRefreshCookie(w)
+		}
+		if start, ok := message.(*grpcAuth.StartExternalLoginResponse); ok {
+			h.setStateCookie(w, start.State)
+		}
+
+		code := e.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		h.writeProto(w, code, message)
+	}
+}
+
+// incomingContext переносит заголовки HTTP в метаданные, которые
+// обработчики gRPC читают из входящего контекста, а адрес и user agent
+// клиента передает через grpcAuth.WithClientInfo.
+func (h *Handler) incomingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	if value := r.Header.Get("Authorization"); value != "" {
+		md.Set("authorization", value)
+	}
+	if value := r.Header.Get("X-Request-ID"); value != "" {
+		md.Set(interceptors.RequestIDKey, value)
+	}
+	ctx := grpcAuth.WithClientInfo(r.Context(), h.clientInfo(r))
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// bindRequest заполняет запрос из JSON тела, параметров пути и строки
+// запроса. Из строки запроса берутся только поля из query, параметры пути
+// имеют приоритет.
+func bindRequest(r *http.Request, req proto.Message, pattern string, query []string) error {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := unmarshalOptions.Unmarshal(body, req); err != nil {
+			return fmt.Errorf("invalid JSON body: %w", err)
+		}
+	}
+
+	values := r.URL.Query()
+	for _, name := range query {
+		for _, value := range values[name] {
+			if err := setField(req, name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, segment := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.Trim(segment, "{}")
+			if err := setField(req, name, r.PathValue(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setField присваивает полю name (имя из proto или JSON) значение из
+// строки. Для повторяющихся полей значение добавляется в конец.
+func setField(msg proto.Message, name, raw string) error {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+	if fd == nil {
+		return nil
+	}
+
+	value, err := parseScalar(fd.Kind(), raw)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %q", name, raw)
+	}
+	if fd.IsList() {
+		m.Mutable(fd).List().Append(value)
+		return nil
+	}
+	m.Set(fd, value)
+	return nil
+}
+
+func parseScalar(kind protoreflect.Kind, raw string) (protoreflect.Value, error) {
+	switch kind {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(raw), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(raw)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(raw, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(raw, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(raw, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	default:
+		return protoreflect.Value{}, errors.New("unsupported field kind")
+	}
+}
+
+func setStringIfEmpty(msg proto.Message, name, value string) {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil || fd.Kind() != protoreflect.StringKind || m.Get(fd).String() != "" {
+		return
+	}
+	m.Set(fd, protoreflect.ValueOfString(value))
+}
+
+// bearerToken подставляет в VerifyToken токен из заголовка
+// Authorization, если он не передан явно.
+func bearerToken(r *http.Request, req proto.Message) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token != "" {
+		setStringIfEmpty(req, "token", token)
+	}
+}
+
+// moveRefreshToken переносит refresh токен из тела ответа в HttpOnly
+// cookie, чтобы он не был доступен скриптам страницы.
+func (h *Handler) moveRefreshToken(w http.ResponseWriter, resp proto.Message) {
+	m := resp.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(refreshCookieName)
+	if fd == nil || m.Get(fd).String() == "" {
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    m.Get(fd).String(),
+		Path:     refreshCookiePath,
+		MaxAge:   int(h.RefreshTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
+	})
+	m.Clear(fd)
+}
+
+func (h *Handler) clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// setStateCookie привязывает state входа через внешнего провайдера к
+// браузеру, который начал вход. Без этого злоумышленник мог бы
+// подсунуть пользователю ссылку возврата от провайдера со своим state и
+// code и выполнить вход под своей учетной записью (login CSRF). Cookie
+// должна пережить переход от провайдера, поэтому SameSite=Lax.
+func (h *Handler) setStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    state,
+		Path:     stateCookiePath,
+		MaxAge:   int(h.ExternalStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Handler) clearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    "",
+		Path:     stateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// stateCookieMatches сообщает, совпадает ли state из запроса с cookie,
+// выставленной при StartExternalLogin.
+func stateCookieMatches(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || cookie.Value == "" || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1
+}
+
+func (h *Handler) writeProto(w http.ResponseWriter, code int, msg proto.Message) {
+	body, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		h.Logger.Error("failed to marshal response", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"}, h.Logger)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err := w.Write(body); err != nil {
+		h.Logger.Warn("failed to write response", zap.Error(err))
+	}
+}
+
+type errorResponse struct {
+	Error   string   `json:"error"`
+	Code    string   `json:"code"`
+	Reason  string   `json:"reason,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// writeError переводит статус gRPC в HTTP ответ. Детали статуса
+// раскрываются в полях reason/reasons и заголовке Retry-After. Текст
+// внутренних ошибок может содержать ошибки хранилища, поэтому он только
+// пишется в журнал.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	body := errorResponse{Error: st.Message(), Code: st.Code().String()}
+	if st.Code() == codes.Internal || st.Code() == codes.Unknown {
+		h.Logger.Error("rpc failed", zap.String("code", st.Code().String()), zap.String("error", st.Message()))
+		body.Error = "internal error"
+	}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, violation := range d.FieldViolations {
+				body.Reasons = append(body.Reasons, violation.Description)
+			}
+		case *errdetails.ErrorInfo:
+			body.Reason = d.Reason
+		case *errdetails.RetryInfo:
+			seconds := math.Ceil(d.RetryDelay.AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+
+	writeJSON(w, HTTPStatusFromCode(st.Code()), body, h.Logger)
+}
+
+// HTTPStatusFromCode соответствует отображению, принятому в grpc-gateway.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,327 @@
+package auth_test
+
+import (
+	"AuthService/internal/domain/models"
+	grpcAuth "AuthService/pkg/grpc/auth"
+	httpAuth "AuthService/pkg/http/auth"
+	"AuthService/pkg/proxy"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type fakeServer struct {
+	grpcAuth.UnimplementedAuthServiceServer
+
+	refreshToken string
+	query        string
+	md           metadata.MD
+	client       models.ClientInfo
+}
+
+func (s *fakeServer) Login(ctx context.Context, req *grpcAuth.LoginRequest) (*grpcAuth.LoginResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	s.client, _ = grpcAuth.ClientInfoFromContext(ctx)
+	if req.Password != "secret" {
+		st, _ := status.New(codes.ResourceExhausted, "too many login attempts, retry after 30s").
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(30 * time.Second)})
+		return nil, st.Err()
+	}
+	return &grpcAuth.LoginResponse{Message: "login successful", AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (s *fakeServer) Refresh(ctx context.Context, req *grpcAuth.RefreshRequest) (*grpcAuth.RefreshResponse, error) {
+	s.refreshToken = req.RefreshToken
+	return &grpcAuth.RefreshResponse{AccessToken: "access-2", RefreshToken: "refresh-2"}, nil
+}
+
+func (s *fakeServer) Logout(ctx context.Context, req *grpcAuth.LogoutRequest) (*grpcAuth.LogoutResponse, error) {
+	s.refreshToken = req.RefreshToken
+	return &grpcAuth.LogoutResponse{Message: "logout successful"}, nil
+}
+
+func (s *fakeServer) GetUser(ctx context.Context, req *grpcAuth.GetUserRequest) (*grpcAuth.GetUserResponse, error) {
+	if req.UserId == 9 {
+		return nil, status.Error(codes.Internal, "failed to get user: pq: connection refused")
+	}
+	if req.UserId != 7 {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &grpcAuth.GetUserResponse{User: &grpcAuth.UserProfile{UserId: 7, Username: "alice"}}, nil
+}
+
+func (s *fakeServer) SearchUsers(ctx context.Context, req *grpcAuth.SearchUsersRequest) (*grpcAuth.SearchUsersResponse, error) {
+	s.query = req.Query
+	return &grpcAuth.SearchUsersResponse{}, nil
+}
+
+func (s *fakeServer) StartExternalLogin(ctx context.Context, req *grpcAuth.StartExternalLoginRequest) (*grpcAuth.StartExternalLoginResponse, error) {
+	return &grpcAuth.StartExternalLoginResponse{AuthorizationUrl: "https://idp.example/authorize", State: "state-1"}, nil
+}
+
+func (s *fakeServer) CompleteExternalLogin(ctx context.Context, req *grpcAuth.CompleteExternalLoginRequest) (*grpcAuth.CompleteExternalLoginResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	return &grpcAuth.CompleteExternalLoginResponse{Message: "login successful", AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (s *fakeServer) Introspect(ctx context.Context, req *grpcAuth.IntrospectRequest) (*grpcAuth.IntrospectResponse, error) {
+	if req.Token != "access" {
+		return &grpcAuth.IntrospectResponse{Error: grpcAuth.IntrospectionError_INTROSPECTION_ERROR_REVOKED}, nil
+	}
+	return &grpcAuth.IntrospectResponse{Active: true, Claims: &grpcAuth.TokenClaims{UserId: 7, Username: "alice"}}, nil
+}
+
+func newGateway(server grpcAuth.AuthServiceServer) http.Handler {
+	return (&httpAuth.Handler{
+		Logger:     zap.NewNop(),
+		Server:     server,
+		RefreshTTL: time.Hour,
+	}).Routes()
+}
+
+func TestGateway_LoginSetsRefreshCookie(t *testing.T) {
+	server := &fakeServer{}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"alice","password":"secret"}`))
+	req.Header.Set("User-Agent", "Firefox")
+	req.Header.Set("X-Request-ID", "req-1")
+	resp := httptest.NewRecorder()
+	newGateway(server).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "req-1", resp.Header().Get("X-Request-ID"))
+	assert.Contains(t, resp.Body.String(), `"access_token":"access"`)
+	assert.Contains(t, resp.Body.String(), `"refresh_token":""`)
+
+	cookies := resp.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "refresh_token", cookies[0].Name)
+	assert.Equal(t, "refresh", cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+
+	assert.Equal(t, "Firefox", server.client.UserAgent)
+	assert.Equal(t, "192.0.2.1", server.client.IP)
+}
+
+func TestGateway_ForwardedForIsTrustedOnlyFromProxies(t *testing.T) {
+	trusted, err := proxy.ParseTrusted("192.0.2.0/24")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expectedIP string
+	}{
+		{name: "Untrusted peer", remoteAddr: "198.51.100.9:4000", expectedIP: "198.51.100.9"},
+		{name: "Trusted proxy", remoteAddr: "192.0.2.1:4000", expectedIP: "203.0.113.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{}
+			gateway := (&httpAuth.Handler{
+				Logger:         zap.NewNop(),
+				Server:         server,
+				RefreshTTL:     time.Hour,
+				TrustedProxies: trusted,
+			}).Routes()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"alice","password":"secret"}`))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "10.0.0.1, 203.0.113.5")
+			resp := httptest.NewRecorder()
+			gateway.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.expectedIP, server.client.IP)
+		})
+	}
+}
+
+func TestGateway_ExternalLoginStateIsBoundToBrowser(t *testing.T) {
+	gateway := (&httpAuth.Handler{
+		Logger:           zap.NewNop(),
+		Server:           &fakeServer{},
+		RefreshTTL:       time.Hour,
+		ExternalStateTTL: 10 * time.Minute,
+	}).Routes()
+
+	resp := httptest.NewRecorder()
+	gateway.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/v1/auth/providers/corp/start", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	cookies := resp.Result().Cookies()
+	require.Len(t, cookies, 1)
+	state := cookies[0]
+	assert.Equal(t, "external_login_state", state.Name)
+	assert.Equal(t, "state-1", state.Value)
+	assert.Equal(t, "/v1/auth/providers", state.Path)
+	assert.Equal(t, 600, state.MaxAge)
+	assert.True(t, state.HttpOnly)
+
+	tests := []struct {
+		name         string
+		cookie       string
+		expectedCode int
+	}{
+		{name: "No cookie", expectedCode: http.StatusBadRequest},
+		{name: "Another browser", cookie: "state-2", expectedCode: http.StatusBadRequest},
+		{name: "Same browser", cookie: "state-1", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{}
+			gateway := newGateway(server)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/providers/corp/complete",
+				strings.NewReader(`{"state":"state-1","code":"code"}`))
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "external_login_state", Value: tt.cookie})
+			}
+			resp := httptest.NewRecorder()
+			gateway.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, server.md != nil)
+
+			cookies := map[string]*http.Cookie{}
+			for _, cookie := range resp.Result().Cookies() {
+				cookies[cookie.Name] = cookie
+			}
+			require.Contains(t, cookies, "external_login_state")
+			assert.Equal(t, -1, cookies["external_login_state"].MaxAge)
+		})
+	}
+}
+
+func TestGateway_RefreshAndLogoutUseCookie(t *testing.T) {
+	server := &fakeServer{}
+	gateway := newGateway(server)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "from-cookie"})
+	resp := httptest.NewRecorder()
+	gateway.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "from-cookie", server.refreshToken)
+	assert.Equal(t, "refresh-2", resp.Result().Cookies()[0].Value)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-2"})
+	resp = httptest.NewRecorder()
+	gateway.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "refresh-2", server.refreshToken)
+	cookies := resp.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+}
+
+func TestGateway_QueryBindsOnlyAllowedFields(t *testing.T) {
+	server := &fakeServer{}
+	gateway := newGateway(server)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/refresh?refresh_token=from-url", nil)
+	resp := httptest.NewRecorder()
+	gateway.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, server.refreshToken)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/users?query=ali", nil)
+	resp = httptest.NewRecorder()
+	gateway.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "ali", server.query)
+}
+
+func TestGateway_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		retryAfter   string
+		// expectedBody проверяется, только если задан.
+		expectedBody string
+	}{
+		{name: "retry info", method: http.MethodPost, path: "/v1/auth/login", body: `{"username":"alice","password":"x"}`,
+			expectedCode: http.StatusTooManyRequests, retryAfter: "30"},
+		{name: "not found", method: http.MethodGet, path: "/v1/users/8", expectedCode: http.StatusNotFound},
+		{name: "invalid path parameter", method: http.MethodGet, path: "/v1/users/abc", expectedCode: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPost, path: "/v1/auth/login", body: `{"username":`, expectedCode: http.StatusBadRequest},
+		{name: "unimplemented rpc", method: http.MethodGet, path: "/v1/sessions", expectedCode: http.StatusNotImplemented},
+		{name: "internal error", method: http.MethodGet, path: "/v1/users/9", expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"internal error","code":"Internal"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			resp := httptest.NewRecorder()
+			newGateway(&fakeServer{}).ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.retryAfter, resp.Header().Get("Retry-After"))
+			assert.Contains(t, resp.Body.String(), `"error"`)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestGateway_Introspect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/introspect", strings.NewReader(`{"token":"access"}`))
+	resp := httptest.NewRecorder()
+	newGateway(&fakeServer{}).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"active":true`)
+	assert.Contains(t, resp.Body.String(), `"username":"alice"`)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/auth/introspect", strings.NewReader(`{"token":"revoked"}`))
+	resp = httptest.NewRecorder()
+	newGateway(&fakeServer{}).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"active":false`)
+	assert.Contains(t, resp.Body.String(), `"error":"INTROSPECTION_ERROR_REVOKED"`)
+}
+
+func TestGateway_PathParameters(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/7", nil)
+	resp := httptest.NewRecorder()
+	newGateway(&fakeServer{}).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"username":"alice"`)
+}
+
+func TestGateway_ResetPasswordPage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/reset-password?token=abc", nil)
+	resp := httptest.NewRecorder()
+	newGateway(&fakeServer{}).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "no-referrer", resp.Header().Get("Referrer-Policy"))
+	assert.Contains(t, resp.Body.String(), "/v1/auth/password/reset")
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/usecases"
+	grpcAuth "AuthService/pkg/grpc/auth"
+	"AuthService/pkg/jwt"
+	"AuthService/pkg/proxy"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	Keys        *jwt.KeySet
+	AuthService usecases.AuthService
+	Logger      *zap.Logger
+
+	// Server - обработчики gRPC, которые REST шлюз вызывает напрямую.
+	// Если не задан, шлюз не регистрируется.
+	Server        grpcAuth.AuthServiceServer
+	RefreshTTL    time.Duration
+	SecureCookies bool
+
+	// ExternalStateTTL - срок жизни cookie со state входа через внешнего
+	// провайдера, совпадает со сроком хранения самого state.
+	ExternalStateTTL time.Duration
+
+	// OAuthConsentURL - страница согласия OAuth на фронтенде форума, на
+	// которую Authorize перенаправляет браузер пользователя.
+	OAuthConsentURL string
+
+	// TrustedProxies - прокси, от которых принимается X-Forwarded-For.
+	// У остальных запросов адресом клиента считается RemoteAddr.
+	TrustedProxies proxy.Trusted
+}
+
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", h.JWKS)
+	mux.HandleFunc("GET /verify-email", h.VerifyEmail)
+	mux.HandleFunc("GET /oauth/authorize", h.Authorize)
+	mux.HandleFunc("POST /oauth/authorize", h.Authorize)
+	mux.HandleFunc("POST /oauth/token", h.Token)
+	mux.HandleFunc("POST /oauth/revoke", h.Revoke)
+	if h.Server != nil {
+		// Страница сброса пароля отправляет форму в REST шлюз.
+		mux.HandleFunc("GET /reset-password", h.ResetPasswordPage)
+		h.registerGateway(mux)
+	}
+	return mux
+}
+
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.Keys.JWKS(), h.Logger)
+}
+
+// VerifyEmail обрабатывает ссылку из письма подтверждения адреса.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	err := h.AuthService.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]string{"message": "email verified"}, h.Logger)
+	case errors.Is(err, domain.InvalidToken):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid or expired verification token"}, h.Logger)
+	default:
+		h.Logger.Error("failed to verify email", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"}, h.Logger)
+	}
+}
+
+// resetPasswordPage - страница из ссылки в письме сброса пароля
+// (PasswordResetURL). Токен она берет из адреса страницы.
+//
+//go:embed reset_password.html
+var resetPasswordPage []byte
+
+// ResetPasswordPage отдает страницу ввода нового пароля. Токен сброса
+// передается в адресе, поэтому Referer запрещен, а страница не кешируется.
+func (h *Handler) ResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if _, err := w.Write(resetPasswordPage); err != nil {
+		h.Logger.Warn("failed to write response", zap.Error(err))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}, logger *zap.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Warn("failed to write response", zap.Error(err))
+	}
+}
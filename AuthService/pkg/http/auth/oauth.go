@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Authorize - authorize endpoint OAuth2 (RFC 6749, раздел 4.1.1).
+//
+// Браузер, который клиент OAuth перенаправил сюда, приходит без токена:
+// такой GET перенаправляется на страницу согласия OAuthConsentURL с теми
+// же параметрами. Страница согласия - фронтенд форума: он при
+// необходимости просит пользователя войти и вызывает этот же endpoint
+// с access токеном. Такой GET проверяет запрос и либо сообщает, что
+// нужно согласие (consent_required), либо сразу возвращает redirect_to с
+// кодом, если согласие уже было дано. POST с decision=approve или
+// decision=deny фиксирует ответ пользователя. Ошибки, которые можно
+// вернуть клиенту, тоже приходят в виде redirect_to, по которому
+// фронтенд переводит браузер обратно к клиенту.
+func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.Header.Get("Authorization") == "" && h.OAuthConsentURL != "" {
+		http.Redirect(w, r, redirectWith(h.OAuthConsentURL, r.URL.Query(), ""), http.StatusFound)
+		return
+	}
+
+	claims, err := h.AuthService.VerifyToken(r.Context(), strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil || claims.Scoped() {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login_required"}, h.Logger)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "malformed request"}, h.Logger)
+		return
+	}
+
+	req := models.AuthorizeRequest{
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		ResponseType:        r.Form.Get("response_type"),
+		Scopes:              models.ParseScopes(r.Form.Get("scope")),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}
+	decision := models.ConsentPending
+	if r.Method == http.MethodPost {
+		switch r.Form.Get("decision") {
+		case "approve":
+			decision = models.ConsentApproved
+		case "deny":
+			decision = models.ConsentDenied
+		default:
+			writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "decision must be approve or deny"}, h.Logger)
+			return
+		}
+	}
+
+	result, err := h.AuthService.Authorize(r.Context(), claims.UserID, req, decision)
+	if err != nil {
+		var oauthErr *domain.OAuthError
+		var suspended *domain.SuspendedError
+		switch {
+		case errors.As(err, &oauthErr) && oauthErr.Redirect:
+			writeJSON(w, http.StatusOK, map[string]string{"redirect_to": redirectWith(req.RedirectURI, url.Values{
+				"error":             {oauthErr.Code},
+				"error_description": {oauthErr.Description},
+			}, req.State)}, h.Logger)
+		case errors.As(err, &oauthErr):
+			writeOAuthError(w, oauthErr, h.Logger)
+		case errors.As(err, &suspended):
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": suspended.Error()}, h.Logger)
+		default:
+			h.Logger.Error("failed to authorize oauth request", zap.Error(err))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"}, h.Logger)
+		}
+		return
+	}
+
+	if result.ConsentRequired {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"consent_required": true,
+			"client": map[string]string{
+				"client_id": result.Client.ID,
+				"name":      result.Client.Name,
+			},
+			"scopes": result.Scopes,
+		}, h.Logger)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"redirect_to": redirectWith(req.RedirectURI, url.Values{"code": {result.Code}}, req.State),
+	}, h.Logger)
+}
+
+// Token - token endpoint OAuth2 (RFC 6749, разделы 4.1.3 и 6).
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "malformed request"}, h.Logger)
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	tokens, err := h.AuthService.ExchangeToken(r.Context(), models.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+	}, h.clientInfo(r))
+	if err != nil {
+		h.writeTokenError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  tokens.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
+		"refresh_token": tokens.RefreshToken,
+		"scope":         strings.Join(tokens.Scopes, " "),
+	}, h.Logger)
+}
+
+// Revoke - endpoint отзыва токенов (RFC 7009). Отвечает 200 и на
+// неизвестные токены.
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "malformed request"}, h.Logger)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "token is required"}, h.Logger)
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	if err := h.AuthService.RevokeOAuthToken(r.Context(), clientID, clientSecret, token); err != nil {
+		h.writeTokenError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) writeTokenError(w http.ResponseWriter, err error) {
+	var oauthErr *domain.OAuthError
+	var suspended *domain.SuspendedError
+	switch {
+	case errors.As(err, &oauthErr):
+		writeOAuthError(w, oauthErr, h.Logger)
+	case errors.As(err, &suspended):
+		writeOAuthError(w, &domain.OAuthError{Code: domain.OAuthInvalidGrant, Description: suspended.Error()}, h.Logger)
+	default:
+		h.Logger.Error("oauth token request failed", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"}, h.Logger)
+	}
+}
+
+// writeOAuthError отвечает в формате RFC 6749, раздел 5.2: неуспешная
+// аутентификация клиента - 401, остальные ошибки - 400.
+func writeOAuthError(w http.ResponseWriter, err *domain.OAuthError, logger *zap.Logger) {
+	code := http.StatusBadRequest
+	if err.Code == domain.OAuthInvalidClient {
+		code = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	writeJSON(w, code, map[string]string{
+		"error":             err.Code,
+		"error_description": err.Description,
+	}, logger)
+}
+
+// clientCredentials читает учетные данные клиента из HTTP Basic или, если
+// их нет, из параметров client_id и client_secret.
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// clientInfo определяет адрес и user agent клиента. X-Forwarded-For
+// учитывается, только если запрос пришел от доверенного прокси.
+func (h *Handler) clientInfo(r *http.Request) models.ClientInfo {
+	forwardedFor := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	return models.ClientInfo{
+		IP:        h.TrustedProxies.ClientIP(r.RemoteAddr, forwardedFor),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// redirectWith добавляет params и state к redirect_uri клиента, сохраняя
+// его собственные параметры.
+func redirectWith(redirectURI string, params url.Values, state string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,210 @@
+package auth_test
+
+import (
+	"AuthService/internal/domain"
+	"AuthService/internal/domain/models"
+	"AuthService/internal/usecases"
+	httpAuth "AuthService/pkg/http/auth"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeOAuthService struct {
+	usecases.AuthService
+
+	tokenRequest models.TokenRequest
+	decision     models.ConsentDecision
+	authorizeErr error
+}
+
+func (s *fakeOAuthService) VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error) {
+	switch token {
+	case "user-token":
+		return &models.TokenClaims{UserID: 1}, nil
+	case "client-token":
+		return &models.TokenClaims{UserID: 1, ClientID: "forum-bot"}, nil
+	default:
+		return nil, domain.InvalidToken
+	}
+}
+
+func (s *fakeOAuthService) Authorize(ctx context.Context, userID int, req models.AuthorizeRequest, decision models.ConsentDecision) (*models.AuthorizeResult, error) {
+	s.decision = decision
+	if s.authorizeErr != nil {
+		return nil, s.authorizeErr
+	}
+	client := &models.OAuthClient{ID: req.ClientID, Name: "Forum bot"}
+	if decision == models.ConsentPending {
+		return &models.AuthorizeResult{Client: client, Scopes: req.Scopes, ConsentRequired: true}, nil
+	}
+	return &models.AuthorizeResult{Client: client, Scopes: req.Scopes, Code: "the-code"}, nil
+}
+
+func (s *fakeOAuthService) ExchangeToken(ctx context.Context, req models.TokenRequest, client models.ClientInfo) (*models.OAuthTokens, error) {
+	s.tokenRequest = req
+	if req.ClientSecret != "secret" {
+		return nil, &domain.OAuthError{Code: domain.OAuthInvalidClient, Description: "client authentication failed"}
+	}
+	return &models.OAuthTokens{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    15 * time.Minute,
+		Scopes:       []string{"topics:write", "profile:read"},
+	}, nil
+}
+
+func newOAuthHandler(service usecases.AuthService) http.Handler {
+	return (&httpAuth.Handler{AuthService: service, Logger: zap.NewNop()}).Routes()
+}
+
+func decodeBody(t *testing.T, resp *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	return body
+}
+
+func TestOAuth_Token(t *testing.T) {
+	service := &fakeOAuthService{}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"the-code"},
+		"redirect_uri":  {"https://bot.example/callback"},
+		"code_verifier": {"verifier"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("forum-bot", "secret")
+	resp := httptest.NewRecorder()
+	newOAuthHandler(service).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+	assert.Equal(t, "forum-bot", service.tokenRequest.ClientID)
+	assert.Equal(t, "verifier", service.tokenRequest.CodeVerifier)
+
+	body := decodeBody(t, resp)
+	assert.Equal(t, "Bearer", body["token_type"])
+	assert.Equal(t, float64(900), body["expires_in"])
+	assert.Equal(t, "topics:write profile:read", body["scope"])
+
+	req = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("forum-bot", "wrong")
+	resp = httptest.NewRecorder()
+	newOAuthHandler(service).ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, domain.OAuthInvalidClient, decodeBody(t, resp)["error"])
+}
+
+func TestOAuth_Authorize(t *testing.T) {
+	query := url.Values{
+		"client_id":             {"forum-bot"},
+		"redirect_uri":          {"https://bot.example/callback?source=forum"},
+		"response_type":         {"code"},
+		"scope":                 {"topics:write profile:read"},
+		"state":                 {"xyz"},
+		"code_challenge":        {"challenge"},
+		"code_challenge_method": {"S256"},
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		token        string
+		decision     string
+		authorizeErr error
+		expectedCode int
+		check        func(t *testing.T, body map[string]interface{})
+	}{
+		{name: "not logged in", method: http.MethodGet, expectedCode: http.StatusUnauthorized},
+		{name: "delegated token", method: http.MethodGet, token: "client-token", expectedCode: http.StatusUnauthorized},
+		{name: "consent required", method: http.MethodGet, token: "user-token", expectedCode: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, true, body["consent_required"])
+				assert.Equal(t, []interface{}{"topics:write", "profile:read"}, body["scopes"])
+			}},
+		{name: "approved", method: http.MethodPost, token: "user-token", decision: "approve", expectedCode: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "https://bot.example/callback?code=the-code&source=forum&state=xyz", body["redirect_to"])
+			}},
+		{name: "error returned through redirect", method: http.MethodPost, token: "user-token", decision: "deny",
+			authorizeErr: &domain.OAuthError{Code: domain.OAuthAccessDenied, Description: "denied", Redirect: true},
+			expectedCode: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "https://bot.example/callback?error=access_denied&error_description=denied&source=forum&state=xyz", body["redirect_to"])
+			}},
+		{name: "error without redirect", method: http.MethodGet, token: "user-token",
+			authorizeErr: &domain.OAuthError{Code: domain.OAuthInvalidRequest, Description: "redirect_uri is not registered"},
+			expectedCode: http.StatusBadRequest,
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, domain.OAuthInvalidRequest, body["error"])
+				assert.Nil(t, body["redirect_to"])
+			}},
+		{name: "unknown decision", method: http.MethodPost, token: "user-token", decision: "maybe", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOAuthService{authorizeErr: tt.authorizeErr}
+
+			target := "/oauth/authorize?" + query.Encode()
+			var body *strings.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader(url.Values{"decision": {tt.decision}}.Encode())
+			} else {
+				body = strings.NewReader("")
+			}
+			req := httptest.NewRequest(tt.method, target, body)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp := httptest.NewRecorder()
+			newOAuthHandler(service).ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			if tt.check != nil {
+				tt.check(t, decodeBody(t, resp))
+			}
+		})
+	}
+}
+
+func TestOAuth_Authorize_BrowserIsRedirectedToConsentPage(t *testing.T) {
+	handler := (&httpAuth.Handler{
+		AuthService:     &fakeOAuthService{},
+		Logger:          zap.NewNop(),
+		OAuthConsentURL: "https://forum.example/oauth/consent?lang=ru",
+	}).Routes()
+
+	query := url.Values{
+		"client_id":    {"forum-bot"},
+		"redirect_uri": {"https://bot.example/callback"},
+		"state":        {"xyz"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+query.Encode(), nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusFound, resp.Code)
+	location, err := url.Parse(resp.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "forum.example", location.Host)
+	assert.Equal(t, "/oauth/consent", location.Path)
+	assert.Equal(t, "ru", location.Query().Get("lang"))
+	assert.Equal(t, "forum-bot", location.Query().Get("client_id"))
+	assert.Equal(t, "https://bot.example/callback", location.Query().Get("redirect_uri"))
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+}
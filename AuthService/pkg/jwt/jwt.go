@@ -2,39 +2,229 @@ package jwt
 
 import (
 	"AuthService/internal/domain/models"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+	"strings"
 	"time"
 )
 
-var ErrInvalidToken = errors.New("invalid token")
+const (
+	TypeAccess    = "access"
+	TypeRefresh   = "refresh"
+	TypeChallenge = "mfa_challenge"
+	TypeEmail     = "email_verification"
+)
 
-func GenerateToken(claims models.TokenClaims, secretKey string, ttl time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": claims.Username,
-		"userID":   claims.UserID,
-		"exp":      time.Now().Add(ttl).Unix(),
-		"iat":      time.Now().Unix(),
-	})
+var (
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrWrongTokenType = errors.New("wrong token type")
+	// ErrTokenExpired входит в ошибку Validate, если истек срок токена.
+	ErrTokenExpired = jwt.ErrTokenExpired
+)
+
+// InvalidClaimError возвращается, когда claim отсутствует или имеет
+// неожиданный тип.
+type InvalidClaimError struct {
+	Claim string
+}
+
+func (e *InvalidClaimError) Error() string {
+	return fmt.Sprintf("invalid token claim %q", e.Claim)
+}
+
+// Policy задает значения iss и aud, которые выставляются при подписи
+// и строго проверяются при валидации.
+type Policy struct {
+	Issuer   string
+	Audience string
+}
+
+// HMAC подписывает и проверяет токены алгоритмом HS256. Используется
+// для refresh токенов, которые проверяет только сам AuthService.
+type HMAC struct {
+	secret []byte
+	policy Policy
+}
+
+func NewHMAC(secretKey string, policy Policy) *HMAC {
+	return &HMAC{secret: []byte(secretKey), policy: policy}
+}
+
+func (h *HMAC) Sign(claims models.TokenClaims, ttl time.Duration) (string, error) {
+	mapClaims, err := h.policy.mapClaims(claims, ttl)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
 
-	return token.SignedString([]byte(secretKey))
+	return token.SignedString(h.secret)
 }
-func ValidateToken(tokenString string, secretKey string) (*models.TokenClaims, error) {
+
+func (h *HMAC) Validate(tokenString string, tokenType string) (*models.TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+		return h.secret, nil
+	}, h.policy.parserOptions(jwt.SigningMethodHS256)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimsFromToken(token, tokenType)
+}
+
+func (p Policy) mapClaims(claims models.TokenClaims, ttl time.Duration) (jwt.MapClaims, error) {
+	id := claims.ID
+	if id == "" {
+		var err error
+		if id, err = NewID(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	mapClaims := jwt.MapClaims{
+		"sub":      strconv.Itoa(claims.UserID),
+		"username": claims.Username,
+		"typ":      claims.Type,
+		"jti":      id,
+		"iss":      p.Issuer,
+		"aud":      p.Audience,
+		"exp":      now.Add(ttl).Unix(),
+		"nbf":      now.Unix(),
+		"iat":      now.Unix(),
+	}
+	if len(claims.Roles) > 0 {
+		mapClaims["roles"] = claims.Roles
+	}
+	if claims.Email != "" {
+		mapClaims["email"] = claims.Email
+	}
+	if claims.SessionID != "" {
+		mapClaims["sid"] = claims.SessionID
+	}
+	// Токены клиентов OAuth несут client_id и scope по RFC 9068.
+	if claims.ClientID != "" {
+		mapClaims["client_id"] = claims.ClientID
+	}
+	if len(claims.Scopes) > 0 {
+		mapClaims["scope"] = strings.Join(claims.Scopes, " ")
+	}
+	if claims.Type == TypeAccess {
+		mapClaims["email_verified"] = claims.EmailVerified
+	}
+	return mapClaims, nil
+}
+
+func (p Policy) parserOptions(method jwt.SigningMethod) []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithValidMethods([]string{method.Alg()}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+}
+
+func claimsFromToken(token *jwt.Token, tokenType string) (*models.TokenClaims, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	typ, ok := claims["typ"].(string)
+	if !ok {
+		return nil, &InvalidClaimError{Claim: "typ"}
+	}
+	if typ != tokenType {
+		return nil, ErrWrongTokenType
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return nil, &InvalidClaimError{Claim: "sub"}
+	}
+	userID, err := strconv.Atoi(sub)
+	if err != nil {
+		return nil, &InvalidClaimError{Claim: "sub"}
+	}
+
+	username, ok := claims["username"].(string)
+	if !ok {
+		return nil, &InvalidClaimError{Claim: "username"}
+	}
+
+	id, ok := claims["jti"].(string)
+	if !ok || id == "" {
+		return nil, &InvalidClaimError{Claim: "jti"}
+	}
+
+	roles, err := rolesFromClaims(claims)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := claims["userID"].(float64)
+	// email, email_verified, sid, client_id и scope необязательны: токены,
+	// выпущенные до их появления, остаются действительными.
+	email, _ := claims["email"].(string)
+	emailVerified, _ := claims["email_verified"].(bool)
+	sessionID, _ := claims["sid"].(string)
+	clientID, _ := claims["client_id"].(string)
+	scope, _ := claims["scope"].(string)
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, &InvalidClaimError{Claim: "exp"}
+	}
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return nil, &InvalidClaimError{Claim: "iat"}
+	}
+
+	return &models.TokenClaims{
+		ID:            id,
+		Type:          typ,
+		UserID:        userID,
+		Username:      username,
+		Roles:         roles,
+		Email:         email,
+		EmailVerified: emailVerified,
+		SessionID:     sessionID,
+		ClientID:      clientID,
+		Scopes:        strings.Fields(scope),
+		ExpiresAt:     exp.Time,
+		IssuedAt:      iat.Time,
+	}, nil
+}
+
+func rolesFromClaims(claims jwt.MapClaims) ([]string, error) {
+	raw, ok := claims["roles"]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, &InvalidClaimError{Claim: "roles"}
+	}
+	roles := make([]string, 0, len(list))
+	for _, item := range list {
+		role, ok := item.(string)
+		if !ok {
+			return nil, &InvalidClaimError{Claim: "roles"}
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
 
-		username := claims["username"].(string)
-		return &models.TokenClaims{
-			UserID:   int(userID),
-			Username: username,
-		}, nil
+// NewID возвращает случайный идентификатор для claim jti.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	return nil, ErrInvalidToken
+	return hex.EncodeToString(b), nil
 }
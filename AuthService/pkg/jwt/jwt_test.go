@@ -0,0 +1,136 @@
+package jwt_test
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMAC_SignAndValidate(t *testing.T) {
+	h := jwt.NewHMAC("secret", policy)
+
+	token, err := h.Sign(models.TokenClaims{ID: "abc", Type: jwt.TypeRefresh, UserID: 3, Username: "carol"}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := h.Validate(token, jwt.TypeRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", claims.ID)
+	assert.Equal(t, jwt.TypeRefresh, claims.Type)
+	assert.Equal(t, 3, claims.UserID)
+	assert.Equal(t, "carol", claims.Username)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt, 2*time.Second)
+}
+
+func TestHMAC_EmailClaims(t *testing.T) {
+	h := jwt.NewHMAC("secret", policy)
+
+	token, err := h.Sign(models.TokenClaims{Type: jwt.TypeEmail, UserID: 3, Username: "carol", Email: "carol@example.com"}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := h.Validate(token, jwt.TypeEmail)
+	require.NoError(t, err)
+	assert.Equal(t, "carol@example.com", claims.Email)
+
+	access, err := h.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 3, Username: "carol", EmailVerified: true}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err = h.Validate(access, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.True(t, claims.EmailVerified)
+	assert.Empty(t, claims.Email)
+}
+
+func TestHMAC_SessionClaim(t *testing.T) {
+	h := jwt.NewHMAC("secret", policy)
+
+	token, err := h.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 3, Username: "carol", SessionID: "session-1"}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := h.Validate(token, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", claims.SessionID)
+
+	// Токены без sid по-прежнему принимаются.
+	token, err = h.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 3, Username: "carol"}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err = h.Validate(token, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Empty(t, claims.SessionID)
+}
+
+func TestHMAC_ScopeClaims(t *testing.T) {
+	h := jwt.NewHMAC("secret", policy)
+
+	token, err := h.Sign(models.TokenClaims{
+		Type:     jwt.TypeAccess,
+		UserID:   3,
+		Username: "carol",
+		ClientID: "forum-bot",
+		Scopes:   []string{"topics:write", "profile:read"},
+	}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := h.Validate(token, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "forum-bot", claims.ClientID)
+	assert.Equal(t, []string{"topics:write", "profile:read"}, claims.Scopes)
+}
+
+func TestHMAC_Validate_Rejects(t *testing.T) {
+	h := jwt.NewHMAC("secret", policy)
+	refresh, err := h.Sign(models.TokenClaims{Type: jwt.TypeRefresh, UserID: 3, Username: "carol"}, time.Hour)
+	require.NoError(t, err)
+
+	t.Run("Wrong Type", func(t *testing.T) {
+		_, err := h.Validate(refresh, jwt.TypeAccess)
+		assert.ErrorIs(t, err, jwt.ErrWrongTokenType)
+	})
+
+	t.Run("Wrong Issuer", func(t *testing.T) {
+		other := jwt.NewHMAC("secret", jwt.Policy{Issuer: "someone-else", Audience: policy.Audience})
+		_, err := other.Validate(refresh, jwt.TypeRefresh)
+		assert.ErrorIs(t, err, gojwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("Wrong Audience", func(t *testing.T) {
+		other := jwt.NewHMAC("secret", jwt.Policy{Issuer: policy.Issuer, Audience: "chat"})
+		_, err := other.Validate(refresh, jwt.TypeRefresh)
+		assert.ErrorIs(t, err, gojwt.ErrTokenInvalidAudience)
+	})
+
+	t.Run("Wrong Algorithm", func(t *testing.T) {
+		ks, err := jwt.GenerateKeySet("k1", policy)
+		require.NoError(t, err)
+		access, err := ks.Sign(models.TokenClaims{Type: jwt.TypeRefresh, UserID: 3, Username: "carol"}, time.Hour)
+		require.NoError(t, err)
+
+		_, err = h.Validate(access, jwt.TypeRefresh)
+		assert.ErrorIs(t, err, gojwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("Malformed Claim", func(t *testing.T) {
+		raw, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, gojwt.MapClaims{
+			"sub":      3,
+			"username": "carol",
+			"typ":      jwt.TypeRefresh,
+			"jti":      "abc",
+			"iss":      policy.Issuer,
+			"aud":      policy.Audience,
+			"exp":      time.Now().Add(time.Hour).Unix(),
+			"iat":      time.Now().Unix(),
+		}).SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = h.Validate(raw, jwt.TypeRefresh)
+		var claimErr *jwt.InvalidClaimError
+		require.ErrorAs(t, err, &claimErr)
+		assert.Equal(t, "sub", claimErr.Claim)
+	})
+}
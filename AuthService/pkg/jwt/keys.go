@@ -0,0 +1,193 @@
+package jwt
+
+import (
+	"AuthService/internal/domain/models"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrNoSigningKey   = errors.New("signing key not found")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrMissingKeyID   = errors.New("token has no kid header")
+)
+
+// KeySet хранит ключи RS256: один активный ключ для подписи и
+// все известные публичные ключи для проверки, что позволяет
+// ротировать ключи без простоя.
+type KeySet struct {
+	policy    Policy
+	activeKID string
+	signer    *rsa.PrivateKey
+	public    map[string]*rsa.PublicKey
+}
+
+// JWK — публичный RSA-ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeySet читает все *.pem файлы из каталога dir. Имя файла без
+// расширения используется как kid. Файлы с приватным ключом годятся
+// и для подписи, и для проверки; файлы с публичным ключом — только
+// для проверки токенов, подписанных выведенным из оборота ключом.
+func LoadKeySet(dir, activeKID string, policy Policy) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{policy: policy, activeKID: activeKID, public: make(map[string]*rsa.PublicKey)}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		private, public, err := parsePEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		ks.public[kid] = public
+		if kid == activeKID {
+			if private == nil {
+				return nil, fmt.Errorf("key %q: active key must contain a private key", kid)
+			}
+			ks.signer = private
+		}
+	}
+
+	if ks.signer == nil {
+		return nil, fmt.Errorf("%w: %q in %s", ErrNoSigningKey, activeKID, dir)
+	}
+	return ks, nil
+}
+
+// GenerateKeySet создает набор из одного случайного ключа. Подходит
+// только для локальной разработки: ключ живет до перезапуска процесса.
+func GenerateKeySet(kid string, policy Policy) (*KeySet, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{
+		policy:    policy,
+		activeKID: kid,
+		signer:    key,
+		public:    map[string]*rsa.PublicKey{kid: &key.PublicKey},
+	}, nil
+}
+
+func (ks *KeySet) Sign(claims models.TokenClaims, ttl time.Duration) (string, error) {
+	mapClaims, err := ks.policy.mapClaims(claims, ttl)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mapClaims)
+	token.Header["kid"] = ks.activeKID
+
+	return token.SignedString(ks.signer)
+}
+
+func (ks *KeySet) Validate(tokenString string, tokenType string) (*models.TokenClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, ErrMissingKeyID
+		}
+		key, ok := ks.public[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	}, ks.policy.parserOptions(jwt.SigningMethodRS256)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claimsFromToken(token, tokenType)
+}
+
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.public))
+	for kid := range ks.public {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := ks.public[kid]
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+func parsePEM(data []byte) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, ErrUnsupportedKey
+		}
+		return key, &key.PublicKey, nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, nil, ErrUnsupportedKey
+		}
+		return nil, key, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, block.Type)
+	}
+}
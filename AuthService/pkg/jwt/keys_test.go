@@ -0,0 +1,82 @@
+package jwt_test
+
+import (
+	"AuthService/internal/domain/models"
+	"AuthService/pkg/jwt"
+
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var policy = jwt.Policy{Issuer: "auth-service", Audience: "go-forum"}
+
+func writeKey(t *testing.T, dir, kid string, private bool) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if !private {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+func TestKeySet_SignAndValidate(t *testing.T) {
+	ks, err := jwt.GenerateKeySet("k1", policy)
+	require.NoError(t, err)
+
+	token, err := ks.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 7, Username: "alice", Roles: []string{"user", "admin"}}, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := ks.Validate(token, jwt.TypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, "alice", claims.Username)
+	assert.Equal(t, []string{"user", "admin"}, claims.Roles)
+
+	other, err := jwt.GenerateKeySet("k2", policy)
+	require.NoError(t, err)
+	_, err = other.Validate(token, jwt.TypeAccess)
+	assert.ErrorIs(t, err, jwt.ErrUnknownKey)
+}
+
+func TestLoadKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "old", true)
+	writeKey(t, dir, "new", true)
+
+	before, err := jwt.LoadKeySet(dir, "old", policy)
+	require.NoError(t, err)
+	token, err := before.Sign(models.TokenClaims{Type: jwt.TypeAccess, UserID: 1, Username: "bob"}, time.Minute)
+	require.NoError(t, err)
+
+	after, err := jwt.LoadKeySet(dir, "new", policy)
+	require.NoError(t, err)
+	_, err = after.Validate(token, jwt.TypeAccess)
+	assert.NoError(t, err)
+
+	jwks := after.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "new", jwks.Keys[0].Kid)
+	assert.Equal(t, "old", jwks.Keys[1].Kid)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+}
+
+func TestLoadKeySet_PublicOnlyActiveKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "retired", false)
+
+	_, err := jwt.LoadKeySet(dir, "retired", policy)
+	assert.Error(t, err)
+}
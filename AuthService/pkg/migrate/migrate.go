@@ -0,0 +1,352 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrSchemaBehind   = errors.New("database schema is behind the application")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownScript   = errors.New("migration has no down script")
+)
+
+// lockID - ключ advisory блокировки, чтобы несколько экземпляров сервиса
+// не применяли миграции одновременно.
+const lockID = 7346215901
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration - одна версия схемы.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load читает миграции из fsys. Файл с именем не по шаблону
+// NNN_name.up.sql / NNN_name.down.sql считается ошибкой, как и версия
+// без up скрипта или две миграции с одной версией.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if len(name) < 4 || name[len(name)-4:] != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", name, err)
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции, записывая примененные версии
+// в таблицу schema_migrations. Каждая миграция выполняется в отдельной
+// транзакции вместе с записью о ней.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *zap.Logger
+}
+
+func New(db *sql.DB, fsys fs.FS, logger *zap.Logger) (*Migrator, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger.With(zap.String("component", "migrator")),
+	}, nil
+}
+
+// Latest возвращает версию последней известной миграции, то есть версию
+// схемы, которую ожидает код.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version возвращает текущую версию схемы, 0 - если миграции не применялись.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return 0, err
+	}
+	return currentVersion(ctx, m.db)
+}
+
+// Check возвращает ErrSchemaBehind, если в базе применены не все
+// миграции. Схема новее кода допускается: так работает откат релиза.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaBehind, version, m.Latest())
+	}
+	if version > m.Latest() {
+		m.logger.Warn("database schema is ahead of the application",
+			zap.Int("version", version),
+			zap.Int("expected", m.Latest()))
+	}
+	return nil
+}
+
+// Up применяет все неприменённые миграции.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down откатывает steps последних миграций.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		target := 0
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if m.migrations[i].Version > version {
+				continue
+			}
+			if steps == 0 {
+				target = m.migrations[i].Version
+				break
+			}
+			steps--
+		}
+		return m.migrate(ctx, conn, version, target)
+	})
+}
+
+// To приводит схему к указанной версии, применяя или откатывая миграции.
+// Версия 0 означает откат всех миграций.
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target != 0 && m.find(target) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, version, target)
+	})
+}
+
+// Force записывает version как текущую версию без выполнения скриптов.
+// Нужен для баз, в которых миграции раньше применялись вручную.
+func (m *Migrator) Force(ctx context.Context, target int) error {
+	if target != 0 && m.find(target) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			query := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+			if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		m.logger.Warn("schema version forced", zap.Int("version", target))
+		return nil
+	})
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, version, target int) error {
+	if target >= version {
+		for _, migration := range m.migrations {
+			if migration.Version <= version || migration.Version > target {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version || migration.Version <= target {
+			continue
+		}
+		if err := m.apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, record := migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	direction := "up"
+	if !up {
+		if migration.Down == "" {
+			return fmt.Errorf("%w: %d_%s", ErrNoDownScript, migration.Version, migration.Name)
+		}
+		script, record = migration.Down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`
+		direction = "down"
+	}
+
+	m.logger.Info("applying migration",
+		zap.Int("version", migration.Version),
+		zap.String("name", migration.Name),
+		zap.String("direction", direction))
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		m.logger.Error("migration failed",
+			zap.Int("version", migration.Version),
+			zap.String("name", migration.Name),
+			zap.String("direction", direction),
+			zap.Error(err))
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, migration.Version, migration.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock выполняет fn на одном соединении под advisory блокировкой.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			m.logger.Warn("failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) find(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execQuerier) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func currentVersion(ctx context.Context, db execQuerier) (int, error) {
+	var version int
+	query := `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	if err := db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,213 @@
+package migrate_test
+
+import (
+	"AuthService/migrations"
+	"AuthService/pkg/migrate"
+
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFS = fstest.MapFS{
+	"001_init.up.sql":        {Data: []byte("CREATE TABLE users (id SERIAL)")},
+	"001_init.down.sql":      {Data: []byte("DROP TABLE users")},
+	"002_add_email.up.sql":   {Data: []byte("ALTER TABLE users ADD email TEXT")},
+	"002_add_email.down.sql": {Data: []byte("ALTER TABLE users DROP email")},
+	"003_add_index.up.sql":   {Data: []byte("CREATE INDEX idx_users_email ON users(email)")},
+	"003_add_index.down.sql": {Data: []byte("DROP INDEX idx_users_email")},
+	"migrations.go":          {Data: []byte("package migrations")},
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{name: "valid", fsys: testFS},
+		{name: "invalid name", fsys: fstest.MapFS{"001_init_down.sql": {Data: []byte("DROP TABLE users")}},
+			wantErr: "invalid migration file name"},
+		{name: "missing up script", fsys: fstest.MapFS{"001_init.down.sql": {Data: []byte("DROP TABLE users")}},
+			wantErr: "has no up script"},
+		{name: "conflicting names", fsys: fstest.MapFS{
+			"001_init.up.sql":  {Data: []byte("CREATE TABLE users (id SERIAL)")},
+			"001_users.up.sql": {Data: []byte("CREATE TABLE users (id SERIAL)")},
+		}, wantErr: "conflicting names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := migrate.Load(tt.fsys)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, loaded, 3)
+			assert.Equal(t, 1, loaded[0].Version)
+			assert.Equal(t, "add_index", loaded[2].Name)
+		})
+	}
+}
+
+func TestLoad_EmbeddedMigrations(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, migration := range loaded {
+		assert.Equal(t, i+1, migration.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, migration.Down, "migration %d_%s has no down script", migration.Version, migration.Name)
+	}
+}
+
+func expectLock(mock sqlmock.Sqlmock, version int) {
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLock(mock, 1)
+	for _, m := range []struct {
+		version int
+		name    string
+		script  string
+	}{
+		{2, "add_email", "ALTER TABLE users ADD email TEXT"},
+		{3, "add_index", "CREATE INDEX idx_users_email"},
+	} {
+		mock.ExpectBegin()
+		mock.ExpectExec(m.script).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_migrations \\(version, name\\) VALUES \\(\\$1, \\$2\\)").
+			WithArgs(m.version, m.name).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrator, err := migrate.New(db, testFS, nil)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(context.Background()))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigrator_UpFailureRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLock(mock, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE INDEX idx_users_email").WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrator, err := migrate.New(db, testFS, nil)
+	require.NoError(t, err)
+	err = migrator.Up(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 3_add_index up")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLock(mock, 3)
+	for _, m := range []struct {
+		version int
+		name    string
+		script  string
+	}{
+		{3, "add_index", "DROP INDEX idx_users_email"},
+		{2, "add_email", "ALTER TABLE users DROP email"},
+	} {
+		mock.ExpectBegin()
+		mock.ExpectExec(m.script).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations WHERE version = \\$1 AND name = \\$2").
+			WithArgs(m.version, m.name).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrator, err := migrate.New(db, testFS, nil)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Down(context.Background(), 2))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigrator_ToUnknownVersion(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrate.New(db, testFS, nil)
+	require.NoError(t, err)
+
+	err = migrator.To(context.Background(), 7)
+	assert.ErrorIs(t, err, migrate.ErrUnknownVersion)
+}
+
+func TestMigrator_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		wantErr error
+	}{
+		{name: "up to date", version: 3},
+		{name: "behind", version: 2, wantErr: migrate.ErrSchemaBehind},
+		{name: "empty database", version: 0, wantErr: migrate.ErrSchemaBehind},
+		{name: "ahead", version: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_migrations").
+				WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(tt.version))
+
+			migrator, err := migrate.New(db, testFS, nil)
+			require.NoError(t, err)
+			err = migrator.Check(context.Background())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
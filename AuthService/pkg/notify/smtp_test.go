@@ -0,0 +1,130 @@
+package notify_test
+
+import (
+	"AuthService/pkg/notify"
+
+	"context"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer принимает одно письмо и отдает его в канал. Поддержан
+// минимальный набор команд, которого достаточно для net/smtp.
+func fakeSMTPServer(t *testing.T) (string, <-chan receivedMail) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	mails := make(chan receivedMail, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var mail receivedMail
+		_ = tp.PrintfLine("220 localhost ESMTP fake")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case "MAIL":
+				mail.from = strings.TrimSuffix(strings.TrimPrefix(line, "MAIL FROM:<"), ">")
+				_ = tp.PrintfLine("250 OK")
+			case "RCPT":
+				mail.to = append(mail.to, strings.TrimSuffix(strings.TrimPrefix(line, "RCPT TO:<"), ">"))
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				mail.data = string(data)
+				_ = tp.PrintfLine("250 OK")
+			case "QUIT":
+				_ = tp.PrintfLine("221 Bye")
+				mails <- mail
+				return
+			default:
+				_ = tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return lis.Addr().String(), mails
+}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	addr, mails := fakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	notifier := notify.NewSMTPNotifier(notify.SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "noreply@go-forum.local",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = notifier.Send(ctx, notify.Message{
+		To:      "alice@example.com",
+		Subject: "Password reset",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	select {
+	case mail := <-mails:
+		assert.Equal(t, "noreply@go-forum.local", mail.from)
+		assert.Equal(t, []string{"alice@example.com"}, mail.to)
+		assert.Contains(t, mail.data, "Subject: Password reset\n")
+		assert.Contains(t, mail.data, "To: alice@example.com\n")
+		assert.Contains(t, mail.data, "line one\nline two")
+	case <-ctx.Done():
+		t.Fatal("fake SMTP server did not receive a message")
+	}
+}
+
+func TestSMTPNotifier_EmptyRecipient(t *testing.T) {
+	notifier := notify.NewSMTPNotifier(notify.SMTPConfig{Host: "127.0.0.1", Port: "1"})
+	assert.Error(t, notifier.Send(context.Background(), notify.Message{Subject: "x"}))
+}
+
+func TestFileNotifier_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	notifier := notify.NewFileNotifier(path)
+
+	require.NoError(t, notifier.Send(context.Background(), notify.Message{To: "alice", Subject: "first", Body: "one"}))
+	require.NoError(t, notifier.Send(context.Background(), notify.Message{To: "bob", Subject: "second", Body: "two"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: alice\nSubject: first\n\none\n")
+	assert.Contains(t, string(data), "To: bob\nSubject: second\n\ntwo\n")
+}
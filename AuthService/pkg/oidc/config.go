@@ -0,0 +1,73 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Config описывает одного OpenID Connect провайдера.
+type Config struct {
+	// Name - идентификатор провайдера в API, например "corp".
+	Name   string `json:"name"`
+	Issuer string `json:"issuer"`
+
+	ClientID string `json:"client_id"`
+	// ClientSecret может ссылаться на переменные окружения: ${CORP_OIDC_SECRET}.
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL - страница фронтенда, которая получает code и state и
+	// передает их в CompleteExternalLogin.
+	RedirectURL string `json:"redirect_url"`
+	// Scopes запрашиваются помимо обязательного openid.
+	Scopes []string `json:"scopes"`
+
+	// LinkByEmail разрешает привязать первый вход к существующему
+	// пользователю с тем же подтвержденным адресом. Включать только для
+	// провайдеров, которые сами проверяют владение адресом.
+	LinkByEmail bool `json:"link_by_email"`
+}
+
+// LoadConfig читает список провайдеров из JSON файла.
+func LoadConfig(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	seen := make(map[string]struct{}, len(configs))
+	for i := range configs {
+		cfg := &configs[i]
+		cfg.ClientSecret = os.ExpandEnv(cfg.ClientSecret)
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("provider %q: %w", cfg.Name, err)
+		}
+		if _, ok := seen[cfg.Name]; ok {
+			return nil, fmt.Errorf("provider %q: duplicate name", cfg.Name)
+		}
+		seen[cfg.Name] = struct{}{}
+	}
+	return configs, nil
+}
+
+func (c *Config) validate() error {
+	switch {
+	case c.Name == "":
+		return errors.New("name is required")
+	case c.ClientID == "":
+		return errors.New("client_id is required")
+	}
+	for field, value := range map[string]string{"issuer": c.Issuer, "redirect_url": c.RedirectURL} {
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s must be an absolute URL", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package oidctest содержит локальный OpenID Connect провайдер для тестов,
+// по аналогии с net/http/httptest.
+package oidctest
+
+import (
+	"AuthService/pkg/oidc"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IdP отвечает на discovery, JWKS и token endpoint. Authorization
+// endpoint не реализован как страница: вход пользователя имитирует Login.
+type IdP struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Claims, если задан, вызывается перед подписью каждого ID токена и
+	// позволяет испортить его для негативных тестов.
+	Claims func(claims jwt.MapClaims)
+
+	server *httptest.Server
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	kid    int
+	grants map[string]*grant
+}
+
+type grant struct {
+	identity      oidc.Identity
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewIdP запускает провайдера. Пустой clientSecret означает публичного
+// клиента.
+func NewIdP(clientID, clientSecret string) *IdP {
+	idp := &IdP{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		grants:       make(map[string]*grant),
+	}
+	idp.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	mux.HandleFunc("POST /token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	idp.Issuer = idp.server.URL
+	return idp
+}
+
+func (idp *IdP) Close() {
+	idp.server.Close()
+}
+
+// RotateKey заменяет ключ подписи новым ключом с новым kid.
+func (idp *IdP) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("oidctest: " + err.Error())
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key = key
+	idp.kid++
+}
+
+// Login имитирует вход пользователя identity по адресу authURL,
+// полученному от Provider.AuthCodeURL, и возвращает code и state, с
+// которыми провайдер перенаправил бы браузер на redirect_uri.
+func (idp *IdP) Login(authURL string, identity oidc.Identity) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := u.Query()
+	switch {
+	case query.Get("response_type") != "code":
+		return "", "", errors.New("unsupported response_type")
+	case query.Get("client_id") != idp.ClientID:
+		return "", "", errors.New("unknown client_id")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", "", errors.New("pkce is required")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	code = hex.EncodeToString(b)
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.grants[code] = &grant{
+		identity:      identity,
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	return code, query.Get("state"), nil
+}
+
+// IDToken подписывает ID токен для identity текущим ключом.
+func (idp *IdP) IDToken(identity oidc.Identity, nonce string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   idp.Issuer,
+		"sub":   identity.Subject,
+		"aud":   idp.ClientID,
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"iat":   now.Unix(),
+		"nonce": nonce,
+	}
+	if identity.Email != "" {
+		claims["email"] = identity.Email
+		claims["email_verified"] = identity.EmailVerified
+	}
+	if identity.PreferredUsername != "" {
+		claims["preferred_username"] = identity.PreferredUsername
+	}
+	if identity.Name != "" {
+		claims["name"] = identity.Name
+	}
+	if idp.Claims != nil {
+		idp.Claims(claims)
+	}
+
+	idp.mu.Lock()
+	key, kid := idp.key, idp.kid
+	idp.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID(kid)
+	return token.SignedString(key)
+}
+
+func (idp *IdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                idp.Issuer,
+		"authorization_endpoint":                idp.Issuer + "/authorize",
+		"token_endpoint":                        idp.Issuer + "/token",
+		"jwks_uri":                              idp.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (idp *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	key, kid := idp.key, idp.kid
+	idp.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID(kid),
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func (idp *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != idp.ClientID || secret != idp.ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	idp.mu.Lock()
+	g, ok := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	idToken, err := idp.IDToken(g.identity, g.nonce)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func keyID(n int) string {
+	return "key-" + strconv.Itoa(n)
+}
+
+func tokenError(w http.ResponseWriter, code int, reason string) {
+	writeJSON(w, code, map[string]string{"error": reason})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,191 @@
+package oidc_test
+
+import (
+	"AuthService/pkg/oidc"
+	"AuthService/pkg/oidc/oidctest"
+
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	redirectURL = "https://forum.example/login/corp/callback"
+	verifier    = "dBjftJeZ4CVP-mJ92K3gqOQKYrJ9r6xjUKQ4SBvSEfc"
+)
+
+var alice = oidc.Identity{
+	Subject:           "alice-123",
+	Email:             "alice@corp.example",
+	EmailVerified:     true,
+	PreferredUsername: "alice",
+	Name:              "Alice",
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newProvider(idp *oidctest.IdP) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Name:         "corp",
+		Issuer:       idp.Issuer,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email", "profile"},
+	}, nil)
+}
+
+func TestProvider_CodeFlow(t *testing.T) {
+	for _, secret := range []string{"secret", ""} {
+		idp := oidctest.NewIdP("forum", secret)
+		defer idp.Close()
+		provider := newProvider(idp)
+		ctx := context.Background()
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge(verifier))
+		require.NoError(t, err)
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, idp.Issuer+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+		assert.Equal(t, redirectURL, u.Query().Get("redirect_uri"))
+
+		code, state, err := idp.Login(authURL, alice)
+		require.NoError(t, err)
+		assert.Equal(t, "state-1", state)
+
+		identity, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, alice, *identity)
+
+		// Код одноразовый.
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+		assert.ErrorIs(t, err, oidc.ErrTokenExchange)
+	}
+}
+
+func TestProvider_Exchange_WrongVerifier(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	provider := newProvider(idp)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", challenge(verifier))
+	require.NoError(t, err)
+	code, _, err := idp.Login(authURL, alice)
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(context.Background(), code, verifier[:42]+"x", "nonce")
+	assert.ErrorIs(t, err, oidc.ErrTokenExchange)
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		nonce  string
+		modify func(claims jwt.MapClaims)
+		valid  bool
+	}{
+		{name: "valid", nonce: "nonce", valid: true},
+		{name: "nonce mismatch", nonce: "other"},
+		{name: "wrong audience", nonce: "nonce", modify: func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{name: "wrong issuer", nonce: "nonce", modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "expired", nonce: "nonce", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "no expiry", nonce: "nonce", modify: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "issued in future", nonce: "nonce", modify: func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+		{name: "no subject", nonce: "nonce", modify: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{name: "multiple audiences without azp", nonce: "nonce", modify: func(c jwt.MapClaims) { c["aud"] = []string{"forum", "other"} }},
+		{name: "multiple audiences with azp", nonce: "nonce", valid: true, modify: func(c jwt.MapClaims) {
+			c["aud"] = []string{"forum", "other"}
+			c["azp"] = "forum"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewIdP("forum", "secret")
+			defer idp.Close()
+			idp.Claims = tt.modify
+
+			raw, err := idp.IDToken(alice, "nonce")
+			require.NoError(t, err)
+
+			identity, err := newProvider(idp).VerifyIDToken(context.Background(), raw, tt.nonce)
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Equal(t, "alice-123", identity.Subject)
+			} else {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestProvider_VerifyIDToken_ForeignKey(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+	other := oidctest.NewIdP("forum", "secret")
+	defer other.Close()
+	other.Claims = func(c jwt.MapClaims) { c["iss"] = idp.Issuer }
+
+	raw, err := other.IDToken(alice, "nonce")
+	require.NoError(t, err)
+
+	_, err = newProvider(idp).VerifyIDToken(context.Background(), raw, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewIdP("forum", "secret")
+	defer idp.Close()
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:        "corp",
+		Issuer:      idp.Issuer + "/",
+		ClientID:    "forum",
+		RedirectURL: redirectURL,
+	}, nil)
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", challenge(verifier))
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "providers.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	t.Setenv("CORP_OIDC_SECRET", "s3cret")
+
+	configs, err := oidc.LoadConfig(write(`[
+		{"name": "corp", "issuer": "https://idp.corp.example", "client_id": "forum",
+		 "client_secret": "${CORP_OIDC_SECRET}", "redirect_url": "https://forum.example/cb", "link_by_email": true},
+		{"name": "partner", "issuer": "https://idp.partner.example", "client_id": "forum",
+		 "redirect_url": "https://forum.example/cb"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "s3cret", configs[0].ClientSecret)
+	assert.True(t, configs[0].LinkByEmail)
+
+	_, err = oidc.LoadConfig(write(`[{"name": "corp", "issuer": "idp.corp.example", "client_id": "forum", "redirect_url": "https://forum.example/cb"}]`))
+	assert.Error(t, err)
+
+	_, err = oidc.LoadConfig(write(`[
+		{"name": "corp", "issuer": "https://a.example", "client_id": "forum", "redirect_url": "https://forum.example/cb"},
+		{"name": "corp", "issuer": "https://b.example", "client_id": "forum", "redirect_url": "https://forum.example/cb"}
+	]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+const argon2Prefix = "$argon2id$"
+
+// Границы параметров Argon2id. Верхние границы защищают от хеша с
+// параметрами, на проверку которого уйдет вся память или минуты
+// процессорного времени, нижние - от ошибок конфигурации: при p=0
+// argon2.IDKey паникует, а t=0 сводит хеширование к одному проходу.
+const (
+	maxArgon2Memory      = 4 * 1024 * 1024 // 4 ГиБ
+	maxArgon2Iterations  = 64
+	maxArgon2Parallelism = 64
+	minArgon2SaltLength  = 8
+	maxArgon2SaltLength  = 64
+	minArgon2KeyLength   = 16
+	maxArgon2KeyLength   = 64
+)
+
+var ErrInvalidParams = errors.New("invalid argon2 parameters")
+
+// Argon2Params задает параметры Argon2id. Memory указывается в КиБ.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params соответствуют рекомендации OWASP для Argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2id хранит хеши в формате PHC:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>.
+type Argon2id struct {
+	Params Argon2Params
+}
+
+// Validate проверяет, что параметры лежат в допустимых границах.
+// Argon2id требует не меньше 8 КиБ памяти на поток.
+func (p Argon2Params) Validate() error {
+	switch {
+	case p.Parallelism == 0 || p.Parallelism > maxArgon2Parallelism:
+		return fmt.Errorf("%w: parallelism must be between 1 and %d, got %d", ErrInvalidParams, maxArgon2Parallelism, p.Parallelism)
+	case p.Iterations == 0 || p.Iterations > maxArgon2Iterations:
+		return fmt.Errorf("%w: iterations must be between 1 and %d, got %d", ErrInvalidParams, maxArgon2Iterations, p.Iterations)
+	case p.Memory < 8*uint32(p.Parallelism) || p.Memory > maxArgon2Memory:
+		return fmt.Errorf("%w: memory must be between %d and %d KiB, got %d", ErrInvalidParams, 8*uint32(p.Parallelism), maxArgon2Memory, p.Memory)
+	case p.SaltLength < minArgon2SaltLength || p.SaltLength > maxArgon2SaltLength:
+		return fmt.Errorf("%w: salt length must be between %d and %d, got %d", ErrInvalidParams, minArgon2SaltLength, maxArgon2SaltLength, p.SaltLength)
+	case p.KeyLength < minArgon2KeyLength || p.KeyLength > maxArgon2KeyLength:
+		return fmt.Errorf("%w: key length must be between %d and %d, got %d", ErrInvalidParams, minArgon2KeyLength, maxArgon2KeyLength, p.KeyLength)
+	}
+	return nil
+}
+
+// NewArgon2id подставляет длины соли и ключа по умолчанию, если они не
+// заданы, и проверяет параметры.
+func NewArgon2id(params Argon2Params) (*Argon2id, error) {
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &Argon2id{Params: params}, nil
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := a.Params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(hash, password string) error {
+	params, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (a *Argon2id) Match(hash string) bool {
+	return strings.HasPrefix(hash, argon2Prefix)
+}
+
+func (a *Argon2id) Outdated(hash string) bool {
+	params, salt, _, err := decodeArgon2(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.Params.Memory ||
+		params.Iterations != a.Params.Iterations ||
+		params.Parallelism != a.Params.Parallelism ||
+		params.KeyLength != a.Params.KeyLength ||
+		uint32(len(salt)) != a.Params.SaltLength
+}
+
+func decodeArgon2(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	if err := params.Validate(); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,55 @@
+package password
+
+import "errors"
+
+var (
+	ErrMismatch    = errors.New("password does not match")
+	ErrUnknownHash = errors.New("unknown password hash format")
+	ErrInvalidHash = errors.New("malformed password hash")
+)
+
+// Algorithm реализует один алгоритм хеширования паролей.
+type Algorithm interface {
+	Hash(password string) (string, error)
+	// Verify возвращает ErrMismatch, если пароль не подходит.
+	Verify(hash, password string) error
+	// Match сообщает, создан ли hash этим алгоритмом.
+	Match(hash string) bool
+	// Outdated сообщает, что hash создан этим алгоритмом, но с
+	// параметрами, отличными от текущих.
+	Outdated(hash string) bool
+}
+
+// Hasher хеширует пароли текущим алгоритмом и проверяет хеши, созданные
+// любым из известных, чтобы старые хеши можно было обновить при входе.
+type Hasher struct {
+	current Algorithm
+	known   []Algorithm
+}
+
+func NewHasher(current Algorithm, legacy ...Algorithm) *Hasher {
+	return &Hasher{
+		current: current,
+		known:   append([]Algorithm{current}, legacy...),
+	}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify проверяет пароль и сообщает, нужно ли пересчитать хеш
+// текущим алгоритмом с текущими параметрами.
+func (h *Hasher) Verify(hash, password string) (bool, error) {
+	for _, algorithm := range h.known {
+		if !algorithm.Match(hash) {
+			continue
+		}
+		if err := algorithm.Verify(hash, password); err != nil {
+			return false, err
+		}
+		rehash := algorithm != h.current || algorithm.Outdated(hash)
+		return rehash, nil
+	}
+	return false, ErrUnknownHash
+}
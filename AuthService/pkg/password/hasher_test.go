@@ -0,0 +1,137 @@
+package password_test
+
+import (
+	"AuthService/pkg/password"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Параметры с малым расходом памяти, чтобы тесты шли быстро.
+var testArgon2Params = password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func newTestArgon2id(t *testing.T, params password.Argon2Params) *password.Argon2id {
+	argon, err := password.NewArgon2id(params)
+	require.NoError(t, err)
+	return argon
+}
+
+func TestArgon2id_HashAndVerify(t *testing.T) {
+	argon := newTestArgon2id(t, testArgon2Params)
+
+	hash, err := argon.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, argon.Match(hash))
+	assert.False(t, argon.Outdated(hash))
+
+	assert.NoError(t, argon.Verify(hash, "correct horse"))
+	assert.ErrorIs(t, argon.Verify(hash, "wrong horse"), password.ErrMismatch)
+	assert.ErrorIs(t, argon.Verify("$argon2id$v=19$broken", "correct horse"), password.ErrInvalidHash)
+
+	stronger := newTestArgon2id(t, password.Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1})
+	assert.True(t, stronger.Outdated(hash))
+}
+
+func TestNewArgon2id_RejectsInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params password.Argon2Params
+	}{
+		{name: "zero parallelism", params: password.Argon2Params{Memory: 1024, Iterations: 1}},
+		{name: "zero iterations", params: password.Argon2Params{Memory: 1024, Parallelism: 1}},
+		{name: "zero memory", params: password.Argon2Params{Iterations: 1, Parallelism: 1}},
+		{name: "memory below 8 KiB per lane", params: password.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 16}},
+		{name: "absurd memory", params: password.Argon2Params{Memory: 1 << 30, Iterations: 1, Parallelism: 1}},
+		{name: "absurd iterations", params: password.Argon2Params{Memory: 1024, Iterations: 1000, Parallelism: 1}},
+		{name: "short key", params: password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, KeyLength: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := password.NewArgon2id(tt.params)
+			assert.ErrorIs(t, err, password.ErrInvalidParams)
+		})
+	}
+}
+
+func TestArgon2id_Verify_RejectsInvalidParamsInHash(t *testing.T) {
+	argon := newTestArgon2id(t, testArgon2Params)
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$",
+	} {
+		assert.ErrorIs(t, argon.Verify(hash, "correct horse"), password.ErrInvalidHash, hash)
+		assert.True(t, argon.Outdated(hash), hash)
+	}
+}
+
+func TestHasher_Verify(t *testing.T) {
+	argon := newTestArgon2id(t, testArgon2Params)
+	bcrypt := password.NewBcrypt(4)
+	hasher := password.NewHasher(argon, bcrypt)
+
+	legacy, err := bcrypt.Hash("correct horse")
+	require.NoError(t, err)
+	current, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		hash       string
+		password   string
+		wantRehash bool
+		wantErr    error
+	}{
+		{name: "current algorithm", hash: current, password: "correct horse"},
+		{name: "legacy bcrypt hash", hash: legacy, password: "correct horse", wantRehash: true},
+		{name: "wrong password on legacy hash", hash: legacy, password: "wrong horse", wantErr: password.ErrMismatch},
+		{name: "unknown format", hash: "plaintext", password: "plaintext", wantErr: password.ErrUnknownHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rehash, err := hasher.Verify(tt.hash, tt.password)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRehash, rehash)
+		})
+	}
+}
+
+func TestHasher_Verify_OutdatedBcryptCost(t *testing.T) {
+	hash, err := password.NewBcrypt(4).Hash("correct horse")
+	require.NoError(t, err)
+
+	rehash, err := password.NewHasher(password.NewBcrypt(5)).Verify(hash, "correct horse")
+	require.NoError(t, err)
+	assert.True(t, rehash)
+}
+
+func TestTuneArgon2_ShrinksMemoryForTinyTarget(t *testing.T) {
+	params, err := password.TuneArgon2(time.Nanosecond, 16*1024, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(8*1024), params.Memory)
+	assert.Equal(t, uint32(1), params.Iterations)
+	assert.Equal(t, uint8(1), params.Parallelism)
+}
+
+func TestTuneArgon2_RejectsInvalidInput(t *testing.T) {
+	_, err := password.TuneArgon2(time.Millisecond, 0, 1)
+	assert.ErrorIs(t, err, password.ErrInvalidParams)
+	_, err = password.TuneArgon2(time.Millisecond, 1<<30, 1)
+	assert.ErrorIs(t, err, password.ErrInvalidParams)
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+)
+
+// commonPasswords - встроенный список распространенных паролей. Он
+// используется, если в конфигурации не указан свой файл, поэтому
+// запуск не зависит от рабочего каталога.
+//
+//go:embed common-passwords.txt
+var commonPasswords string
+
+// bcrypt учитывает только первые 72 байта пароля.
+const maxLength = 72
+
+const (
+	ReasonTooShort       = "password_too_short"
+	ReasonTooLong        = "password_too_long"
+	ReasonCommon         = "password_common"
+	ReasonEqualsUsername = "password_equals_username"
+)
+
+type Policy struct {
+	MinLength int
+	banned    map[string]struct{}
+}
+
+// NewPolicy создает политику паролей со списком запрещенных паролей из
+// файла bannedListPath: по одному на строку, пустые строки и строки с #
+// пропускаются. Если путь пуст, используется встроенный список.
+func NewPolicy(minLength int, bannedListPath string) (*Policy, error) {
+	if bannedListPath == "" {
+		return newPolicy(minLength, strings.NewReader(commonPasswords))
+	}
+
+	file, err := os.Open(bannedListPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return newPolicy(minLength, file)
+}
+
+func newPolicy(minLength int, banned io.Reader) (*Policy, error) {
+	policy := &Policy{MinLength: minLength, banned: make(map[string]struct{})}
+	scanner := bufio.NewScanner(banned)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.banned[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Check возвращает список нарушенных правил или nil.
+func (p *Policy) Check(password, username string) []string {
+	var reasons []string
+	if len([]rune(password)) < p.MinLength {
+		reasons = append(reasons, ReasonTooShort)
+	}
+	if len(password) > maxLength {
+		reasons = append(reasons, ReasonTooLong)
+	}
+	if _, ok := p.banned[strings.ToLower(password)]; ok {
+		reasons = append(reasons, ReasonCommon)
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		reasons = append(reasons, ReasonEqualsUsername)
+	}
+	return reasons
+}
@@ -0,0 +1,83 @@
+package password_test
+
+import (
+	"AuthService/pkg/password"
+
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "banned.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# комментарий\n\nqwerty123\nPassword1\n"), 0o600))
+
+	policy, err := password.NewPolicy(8, list)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		expected []string
+	}{
+		{
+			name:     "Valid password",
+			password: "correct-horse-battery",
+			username: "alice",
+		},
+		{
+			name:     "Empty password",
+			password: "",
+			username: "alice",
+			expected: []string{password.ReasonTooShort},
+		},
+		{
+			name:     "Too long",
+			password: strings.Repeat("a", 73),
+			username: "alice",
+			expected: []string{password.ReasonTooLong},
+		},
+		{
+			name:     "Banned case-insensitive",
+			password: "PASSWORD1",
+			username: "alice",
+			expected: []string{password.ReasonCommon},
+		},
+		{
+			name:     "Equals username",
+			password: "Alice.Smith",
+			username: "alice.smith",
+			expected: []string{password.ReasonEqualsUsername},
+		},
+		{
+			name:     "Several violations",
+			password: "bob",
+			username: "bob",
+			expected: []string{password.ReasonTooShort, password.ReasonEqualsUsername},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.Check(tt.password, tt.username))
+		})
+	}
+}
+
+func TestNewPolicy_MissingList(t *testing.T) {
+	_, err := password.NewPolicy(8, filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestNewPolicy_EmbeddedList(t *testing.T) {
+	policy, err := password.NewPolicy(8, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{password.ReasonCommon}, policy.Check("Password123", "alice"))
+	assert.Nil(t, policy.Check("correct-horse-battery", "alice"))
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"golang.org/x/crypto/argon2"
+	"time"
+)
+
+// minArgon2Memory ограничивает снижение памяти при подборе: меньшие
+// значения уже не дают заметной защиты от перебора на GPU.
+const minArgon2Memory = 8 * 1024
+
+// maxTuneDuration ограничивает общее время подбора: при недостижимо
+// большом target подбор останавливается на лучших найденных параметрах.
+const maxTuneDuration = time.Minute
+
+// TuneArgon2 подбирает параметры Argon2id, при которых хеширование на
+// текущей машине занимает не меньше target. Память берется максимальной
+// (maxMemory, КиБ) и уменьшается вдвое, пока одна итерация не уложится в
+// target; затем число итераций растет, пока время не достигнет target.
+// Число итераций не превышает допустимого Validate, а подбор целиком -
+// maxTuneDuration.
+func TuneArgon2(target time.Duration, maxMemory uint32, parallelism uint8) (Argon2Params, error) {
+	params := DefaultArgon2Params
+	params.Memory = maxMemory
+	params.Iterations = 1
+	if parallelism > 0 {
+		params.Parallelism = parallelism
+	}
+	if err := params.Validate(); err != nil {
+		return params, err
+	}
+
+	deadline := time.Now().Add(maxTuneDuration)
+	for params.Memory > minArgon2Memory && time.Now().Before(deadline) && measureArgon2(params) > target {
+		params.Memory /= 2
+	}
+	if params.Memory < minArgon2Memory {
+		params.Memory = minArgon2Memory
+	}
+
+	for params.Iterations < maxArgon2Iterations && time.Now().Before(deadline) && measureArgon2(params) < target {
+		params.Iterations++
+	}
+	return params, nil
+}
+
+func measureArgon2(params Argon2Params) time.Duration {
+	password := []byte("benchmark password")
+	salt := make([]byte, params.SaltLength)
+
+	started := time.Now()
+	argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return time.Since(started)
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Trusted - адреса обратных прокси (TopicService, балансировщик), которым
+// разрешено передавать адрес конечного клиента в X-Forwarded-For.
+// Заголовок от остальных собеседников игнорируется: иначе любой клиент
+// мог бы подменить адрес, по которому считаются попытки входа и сессии.
+type Trusted []netip.Prefix
+
+// ParseTrusted разбирает список адресов и сетей в нотации CIDR через
+// запятую, например "127.0.0.1,10.0.0.0/8".
+func ParseTrusted(list string) (Trusted, error) {
+	var trusted Trusted
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+			}
+			trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	return trusted, nil
+}
+
+// Contains сообщает, принадлежит ли addr (адрес или адрес с портом)
+// доверенному прокси.
+func (t Trusted) Contains(addr string) bool {
+	ip, ok := parseHost(addr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range t {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP определяет адрес клиента по адресу собеседника remoteAddr и
+// значению X-Forwarded-For. Если собеседник не доверенный прокси,
+// возвращается его собственный адрес. Иначе цепочка просматривается
+// справа налево, и результатом становится первый адрес, не
+// принадлежащий доверенным прокси: левее него значения мог дописать сам
+// клиент.
+func (t Trusted) ClientIP(remoteAddr, forwardedFor string) string {
+	ip := host(remoteAddr)
+	if forwardedFor == "" || !t.Contains(remoteAddr) {
+		return ip
+	}
+
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, ok := parseHost(hop); !ok {
+			break
+		}
+		ip = host(hop)
+		if !t.Contains(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// host отбрасывает порт, если он указан.
+func host(addr string) string {
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+	return addr
+}
+
+func parseHost(addr string) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(host(addr))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
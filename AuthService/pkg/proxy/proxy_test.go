@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrusted(t *testing.T) {
+	trusted, err := ParseTrusted("127.0.0.1, 10.1.2.3/8,,::1")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1/32", trusted[0].String())
+	assert.Equal(t, "10.0.0.0/8", trusted[1].String())
+	assert.Equal(t, "::1/128", trusted[2].String())
+
+	_, err = ParseTrusted("localhost")
+	assert.Error(t, err)
+	_, err = ParseTrusted("10.0.0.0/33")
+	assert.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrusted("127.0.0.1,10.0.0.0/8")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expected     string
+	}{
+		{"direct client", "203.0.113.7:51000", "", "203.0.113.7"},
+		{"untrusted peer cannot spoof", "203.0.113.7:51000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "127.0.0.1:40000", "198.51.100.1", "198.51.100.1"},
+		{"chain of trusted proxies", "127.0.0.1:40000", "198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"client prefix is ignored", "127.0.0.1:40000", "1.1.1.1, 198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"garbage stops the walk", "127.0.0.1:40000", "1.1.1.1, not-an-ip", "127.0.0.1"},
+		{"ipv4-mapped peer", "[::ffff:127.0.0.1]:40000", "198.51.100.1", "198.51.100.1"},
+		{"proxy in trusted network", "10.0.0.5:40000", "198.51.100.1", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, trusted.ClientIP(tt.remoteAddr, tt.forwardedFor))
+		})
+	}
+
+	assert.Equal(t, "10.0.0.5", Trusted(nil).ClientIP("10.0.0.5:40000", "198.51.100.1"))
+}
@@ -8,12 +8,12 @@ import (
 	auth "TopicService/internal/interfaces/api/middleware"
 	"TopicService/internal/interfaces/api/persistence/postgres"
 	"TopicService/internal/usecases"
+	"TopicService/pkg/authclient"
 	pkgLogger "TopicService/pkg/logger"
 	"TopicService/pkg/pg"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"github.com/x-t4m-cx/common-grpc-auth/client"
 	"os"
 )
 
@@ -55,7 +55,7 @@ func main() {
 	logger.Info("Use cases инициализированы")
 
 	// 7. Инициализация auth клиента
-	authClient, err := client.New(cfg.AuthService)
+	authClient, err := authclient.New(cfg.AuthService)
 	if err != nil {
 		logger.Error("Ошибка создания auth клиента",
 			"error", err,
@@ -74,6 +74,10 @@ func main() {
 	// 9. Настройка роутера
 	router := gin.Default()
 
+	// Идентификатор запроса передается в AuthService, поэтому ставится
+	// до логирования и до любых обращений к auth клиенту
+	router.Use(auth.RequestID())
+
 	// Middleware для логирования запросов
 	router.Use(pkgLogger.HTTPLogMiddleware(logger))
 
@@ -87,7 +91,8 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API endpoints
-	api.SetupTopicRoutes(router, topicHandler, commentHandler, authHandler, middleware.Auth())
+	api.SetupTopicRoutes(router, topicHandler, commentHandler, authHandler, middleware.Auth(),
+		middleware.VerifiedEmail(cfg.RequireVerifiedEmail), middleware.RequireScope)
 
 	// 10. Запуск сервера
 	logger.Info("Сервер запускается", "порт", cfg.ServerPort)
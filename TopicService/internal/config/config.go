@@ -22,6 +22,10 @@ type Config struct {
 	LogLevel  string `mapstructure:"LOG_LEVEL"`
 	LogFormat string `mapstructure:"LOG_FORMAT"`
 	LogOutput string `mapstructure:"LOG_OUTPUT"`
+
+	// RequireVerifiedEmail запрещает создавать и редактировать темы и
+	// комментарии пользователям без подтвержденной почты.
+	RequireVerifiedEmail bool
 }
 
 func Load() (*Config, error) {
@@ -42,6 +46,8 @@ func Load() (*Config, error) {
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
 		LogFormat:   getEnv("LOG_FORMAT", "text"),
 		LogOutput:   getEnv("LOG_OUTPUT", ""),
+
+		RequireVerifiedEmail: getEnv("REQUIRE_VERIFIED_EMAIL", "false") == "true",
 	}, nil
 }
 
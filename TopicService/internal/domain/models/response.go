@@ -13,7 +13,8 @@ type MessageResponse struct {
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string   `json:"error"`
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 type TopicResponse struct {
@@ -3,12 +3,16 @@ package http
 import (
 	"TopicService/internal/domain/models"
 	"TopicService/internal/usecases"
+	"TopicService/pkg/authclient"
 	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type AuthHandler struct {
@@ -22,14 +26,15 @@ func NewAuthHandler(client usecases.GRPCClientInterface, logger slog.Logger) *Au
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticates user and returns JWT tokens
+// @Description Authenticates user and returns JWT tokens. If the user has a second factor enabled, returns second_factor_required and a challenge_token for /auth/second-factor instead
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body models.LoginRequest true "Login credentials"
-// @Success 200 {object} map[string]interface{} "Returns access and refresh tokens"
+// @Success 200 {object} map[string]interface{} "Returns access and refresh tokens or a second factor challenge"
 // @Failure 400 {object} models.ErrorResponse "Invalid request format"
 // @Failure 401 {object} models.ErrorResponse "Invalid credentials"
+// @Failure 429 {object} models.ErrorResponse "Too many failed login attempts"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -40,8 +45,41 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.client.Login(c.Request.Context(), request.Username, request.Password)
+	resp, err := h.client.Login(forwardClient(c), request.Username, request.Password)
+	if err != nil {
+		setRetryAfter(c, err)
+		c.JSON(httpStatusCodeFromError(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	copyHeadersAndCookies(c, resp)
+	copyResponseBody(c, resp)
+}
+
+// SecondFactor completes login with a second factor
+// @Summary Verify second factor
+// @Description Exchanges the challenge token returned by login and a TOTP or recovery code for JWT tokens. A challenge token can be used only once
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.SecondFactorRequest true "Challenge token and code"
+// @Success 200 {object} map[string]interface{} "Returns access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid request format"
+// @Failure 401 {object} models.ErrorResponse "Invalid code or challenge token"
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/second-factor [post]
+func (h *AuthHandler) SecondFactor(c *gin.Context) {
+	var request models.SecondFactorRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.client.VerifySecondFactor(forwardClient(c), request.ChallengeToken, request.Code)
 	if err != nil {
+		setRetryAfter(c, err)
 		c.JSON(httpStatusCodeFromError(err), models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -83,8 +121,8 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // @Produce json
 // @Param request body models.RegisterRequest true "Registration data"
 // @Success 201 {object} map[string]interface{} "Returns access and refresh tokens"
-// @Failure 400 {object} models.ErrorResponse "Invalid request format"
-// @Failure 409 {object} models.ErrorResponse "User already exists"
+// @Failure 400 {object} models.ErrorResponse "Invalid request format or credentials rejected by policy"
+// @Failure 409 {object} models.ErrorResponse "User or email already exists"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -95,9 +133,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.client.Register(c.Request.Context(), request.Username, request.Password)
+	resp, err := h.client.Register(c.Request.Context(), request.Username, request.Password, request.Email)
 	if err != nil {
-		c.JSON(httpStatusCodeFromError(err), models.ErrorResponse{Error: err.Error()})
+		response := models.ErrorResponse{Error: err.Error()}
+		var invalid *authclient.ValidationError
+		if errors.As(err, &invalid) {
+			response.Reasons = invalid.Reasons
+		}
+		c.JSON(httpStatusCodeFromError(err), response)
 		return
 	}
 	copyHeadersAndCookies(c, resp)
@@ -121,7 +164,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.client.Refresh(c.Request.Context(), refreshToken)
+	resp, err := h.client.Refresh(forwardClient(c), refreshToken)
 	if err != nil {
 		c.JSON(httpStatusCodeFromError(err), models.ErrorResponse{Error: err.Error()})
 		return
@@ -163,14 +206,39 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 }
 
 func httpStatusCodeFromError(err error) int {
+	var retry *authclient.RetryAfterError
+	var denied *authclient.PermissionError
 	switch {
 	case errors.Is(err, context.DeadlineExceeded):
 		return http.StatusGatewayTimeout
+	case errors.As(err, &retry):
+		return http.StatusTooManyRequests
+	case errors.As(err, &denied):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "authentication failed:"):
+		return http.StatusUnauthorized
+	case strings.HasPrefix(err.Error(), "invalid argument:"):
+		return http.StatusBadRequest
+	case strings.HasPrefix(err.Error(), "resource already exists:"):
+		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
+// setRetryAfter передает клиенту время до следующей попытки, если
+// AuthService отказал из-за слишком частых попыток.
+func setRetryAfter(c *gin.Context, err error) {
+	var retry *authclient.RetryAfterError
+	if errors.As(err, &retry) && retry.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter.Seconds()))))
+	}
+}
+
+func forwardClient(c *gin.Context) context.Context {
+	return authclient.WithClientInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent())
+}
+
 func copyHeadersAndCookies(c *gin.Context, resp *http.Response) {
 	for name, values := range resp.Header {
 		c.Header(name, values[0])
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"TopicService/pkg/authclient"
 	"bytes"
 	"context"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 type MockAuthClient struct {
@@ -34,8 +36,8 @@ func (m *MockAuthClient) Logout(ctx context.Context) (*http.Response, error) {
 	return args.Get(0).(*http.Response), args.Error(1)
 }
 
-func (m *MockAuthClient) Register(ctx context.Context, username, password string) (*http.Response, error) {
-	args := m.Called(ctx, username, password)
+func (m *MockAuthClient) Register(ctx context.Context, username, password, email string) (*http.Response, error) {
+	args := m.Called(ctx, username, password, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -55,6 +57,22 @@ func (m *MockAuthClient) VerifyToken(ctx context.Context, token string) (string,
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthClient) VerifyTokenClaims(ctx context.Context, token string) (*authclient.Claims, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*authclient.Claims), args.Error(1)
+}
+
+func (m *MockAuthClient) VerifySecondFactor(ctx context.Context, challengeToken, code string) (*http.Response, error) {
+	args := m.Called(ctx, challengeToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
 func (m *MockAuthClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -64,11 +82,12 @@ func TestAuthHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name          string
-		requestBody   string
-		mockSetup     func(*MockAuthClient)
-		expectedCode  int
-		expectedError string
+		name               string
+		requestBody        string
+		mockSetup          func(*MockAuthClient)
+		expectedCode       int
+		expectedError      string
+		expectedRetryAfter string
 	}{
 		{
 			name:        "Success",
@@ -103,6 +122,27 @@ func TestAuthHandler_Login(t *testing.T) {
 			expectedCode:  http.StatusInternalServerError,
 			expectedError: "service error",
 		},
+		{
+			name:        "Too many attempts",
+			requestBody: `{"username":"test","password":"pass"}`,
+			mockSetup: func(m *MockAuthClient) {
+				m.On("Login", mock.Anything, "test", "pass").
+					Return(nil, &authclient.RetryAfterError{Message: "too many login attempts, retry after 30s", RetryAfter: 30 * time.Second})
+			},
+			expectedCode:       http.StatusTooManyRequests,
+			expectedError:      "too many login attempts",
+			expectedRetryAfter: "30",
+		},
+		{
+			name:        "Suspended account",
+			requestBody: `{"username":"test","password":"pass"}`,
+			mockSetup: func(m *MockAuthClient) {
+				m.On("Login", mock.Anything, "test", "pass").
+					Return(nil, &authclient.PermissionError{Message: "account banned: spam", Reason: authclient.ErrorInfoAccountSuspended})
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: "account banned: spam",
+		},
 	}
 
 	for _, tt := range tests {
@@ -125,6 +165,79 @@ func TestAuthHandler_Login(t *testing.T) {
 			if tt.expectedError != "" {
 				assert.Contains(t, w.Body.String(), tt.expectedError)
 			}
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_SecondFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		requestBody   string
+		mockSetup     func(*MockAuthClient)
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *MockAuthClient) {
+				m.On("VerifySecondFactor", mock.Anything, "challenge", "123456").
+					Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Header: http.Header{
+							"Authorization": []string{"Bearer access"},
+							"Set-Cookie":    []string{"refresh_token=refresh; HttpOnly; Path=/"},
+						},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid request",
+			requestBody:  `invalid`,
+			mockSetup:    func(m *MockAuthClient) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Replayed challenge",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *MockAuthClient) {
+				m.On("VerifySecondFactor", mock.Anything, "challenge", "123456").
+					Return(nil, errors.New("authentication failed: invalid challenge token"))
+			},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "invalid challenge token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockAuthClient)
+			tt.mockSetup(mockClient)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+			handler := NewAuthHandler(mockClient, *logger)
+
+			router := gin.New()
+			router.POST("/second-factor", handler.SecondFactor)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/second-factor", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+			}
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, "Bearer access", w.Header().Get("Authorization"))
+			}
 
 			mockClient.AssertExpectations(t)
 		})
@@ -223,9 +336,9 @@ func TestAuthHandler_Register(t *testing.T) {
 	}{
 		{
 			name:        "Success",
-			requestBody: `{"username":"test","password":"pass"}`,
+			requestBody: `{"username":"test","password":"pass","email":"test@example.com"}`,
 			mockSetup: func(m *MockAuthClient) {
-				m.On("Register", mock.Anything, "test", "pass").
+				m.On("Register", mock.Anything, "test", "pass", "test@example.com").
 					Return(&http.Response{
 						StatusCode: http.StatusCreated,
 						Header:     http.Header{"Content-Type": []string{"application/json"}},
@@ -244,7 +357,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			name:        "Service error",
 			requestBody: `{"username":"test","password":"pass"}`,
 			mockSetup: func(m *MockAuthClient) {
-				m.On("Register", mock.Anything, "test", "pass").
+				m.On("Register", mock.Anything, "test", "pass", "").
 					Return(&http.Response{
 						StatusCode: http.StatusInternalServerError,
 						Header:     http.Header{"Content-Type": []string{"application/json"}},
@@ -254,6 +367,19 @@ func TestAuthHandler_Register(t *testing.T) {
 			expectedCode:  http.StatusInternalServerError,
 			expectedError: "service error",
 		},
+		{
+			name:        "Rejected by policy",
+			requestBody: `{"username":"test","password":"pass"}`,
+			mockSetup: func(m *MockAuthClient) {
+				m.On("Register", mock.Anything, "test", "pass", "").
+					Return(nil, &authclient.ValidationError{
+						Message: "invalid registration data: password_too_short,password_common",
+						Reasons: []string{"password_too_short", "password_common"},
+					})
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: `"reasons":["password_too_short","password_common"]`,
+		},
 	}
 
 	for _, tt := range tests {
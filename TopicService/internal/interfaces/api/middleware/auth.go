@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"TopicService/internal/usecases"
+	"TopicService/pkg/authclient"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log/slog"
 	"net/http"
@@ -42,12 +44,17 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 			return
 		}
 
-		username, err := m.authClient.VerifyToken(c.Request.Context(), token)
+		claims, err := m.authClient.VerifyTokenClaims(c.Request.Context(), token)
 		if err == nil {
-			c.Set("username", username)
+			setTokenClaims(c, claims)
 			c.Next()
 			return
 		}
+		// Заблокированному пользователю обновление токена не поможет.
+		if msg, ok := accountSuspended(err); ok {
+			abortSuspended(c, msg)
+			return
+		}
 
 		refreshToken, err := c.Cookie("refresh_token")
 		if err != nil {
@@ -57,7 +64,13 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 			return
 		}
 
-		resp, err := m.authClient.Refresh(c.Request.Context(), refreshToken)
+		// Адрес и user agent нужны AuthService для учета сессий устройств.
+		refreshCtx := authclient.WithClientInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent())
+		resp, err := m.authClient.Refresh(refreshCtx, refreshToken)
+		if msg, ok := accountSuspended(err); ok {
+			abortSuspended(c, msg)
+			return
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Failed to refresh token: " + err.Error(),
@@ -82,7 +95,7 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 
 		c.Request.Header.Set("Authorization", newAccessToken)
 
-		username, err = m.authClient.VerifyToken(
+		claims, err = m.authClient.VerifyTokenClaims(
 			c.Request.Context(),
 			strings.TrimPrefix(newAccessToken, "Bearer "),
 		)
@@ -93,7 +106,87 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("username", username)
+		// Refresh токен одноразовый: браузер должен получить новый, иначе
+		// следующее обновление предъявит старый, AuthService сочтет это
+		// повторным использованием и отзовет всю сессию. Новый access токен
+		// frontend берет из заголовка Authorization ответа.
+		c.Header("Authorization", newAccessToken)
+		for _, cookie := range resp.Header.Values("Set-Cookie") {
+			c.Writer.Header().Add("Set-Cookie", cookie)
+		}
+
+		setTokenClaims(c, claims)
 		c.Next()
 	}
 }
+
+// VerifiedEmail пропускает только пользователей с подтвержденной почтой.
+// Должен стоять после Auth. Если required выключен, ничего не проверяет.
+func (m *AuthMiddleware) VerifiedEmail(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && !c.GetBool("email_verified") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Email address is not verified",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope ограничивает токены, выданные клиентам OAuth, и personal
+// access токены: они проходят, только если им выдан scope. Токены,
+// полученные входом самого пользователя, scopes не ограничены. Должен
+// стоять после Auth.
+func (m *AuthMiddleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("client_id") == "" && !c.GetBool("personal_token") {
+			c.Next()
+			return
+		}
+		for _, granted := range c.GetStringSlice("scopes") {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Token does not grant the " + scope + " scope",
+		})
+	}
+}
+
+// accountSuspended распознает отказ AuthService из-за блокировки аккаунта
+// и возвращает его текст с причиной и сроком блокировки. VerifyToken
+// сообщает о блокировке в error_reason, Refresh - в ErrorInfo статуса.
+func accountSuspended(err error) (string, bool) {
+	var tokenErr *authclient.TokenError
+	if errors.As(err, &tokenErr) && tokenErr.Reason == authclient.ReasonAccountSuspended {
+		return tokenErr.Message, true
+	}
+	var denied *authclient.PermissionError
+	if errors.As(err, &denied) && denied.Reason == authclient.ErrorInfoAccountSuspended {
+		return denied.Message, true
+	}
+	return "", false
+}
+
+func abortSuspended(c *gin.Context, msg string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error": msg,
+	})
+}
+
+// setTokenClaims сохраняет в контексте имя пользователя и
+// email_verified, а для токенов клиентов OAuth и personal access токенов -
+// client_id и scopes. Значения берутся из ответа VerifyToken, а не из
+// payload токена: payload personal access токена фиксируется при его
+// создании и не отражает, например, подтверждение почты после этого.
+func setTokenClaims(c *gin.Context, claims *authclient.Claims) {
+	c.Set("username", claims.Username)
+	c.Set("email_verified", claims.EmailVerified)
+	c.Set("client_id", claims.ClientID)
+	c.Set("scopes", claims.Scopes)
+	c.Set("personal_token", claims.PersonalToken)
+}
@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"TopicService/pkg/authclient"
 	"context"
 	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -34,8 +36,8 @@ func (m *MockAuthClient) Logout(ctx context.Context) (*http.Response, error) {
 	return args.Get(0).(*http.Response), args.Error(1)
 }
 
-func (m *MockAuthClient) Register(ctx context.Context, username, password string) (*http.Response, error) {
-	args := m.Called(ctx, username, password)
+func (m *MockAuthClient) Register(ctx context.Context, username, password, email string) (*http.Response, error) {
+	args := m.Called(ctx, username, password, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -55,6 +57,22 @@ func (m *MockAuthClient) VerifyToken(ctx context.Context, token string) (string,
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthClient) VerifyTokenClaims(ctx context.Context, token string) (*authclient.Claims, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*authclient.Claims), args.Error(1)
+}
+
+func (m *MockAuthClient) VerifySecondFactor(ctx context.Context, challengeToken, code string) (*http.Response, error) {
+	args := m.Called(ctx, challengeToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
 func (m *MockAuthClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -67,7 +85,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient := new(MockAuthClient)
 		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
 
-		mockClient.On("VerifyToken", mock.Anything, "valid-token").Return("testuser", nil)
+		mockClient.On("VerifyTokenClaims", mock.Anything, "valid-token").Return(&authclient.Claims{Username: "testuser"}, nil)
 
 		router := gin.New()
 		router.Use(middleware.Auth())
@@ -104,7 +122,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, resp.Code)
 		assert.Contains(t, resp.Body.String(), "Authorization header is required")
-		mockClient.AssertNotCalled(t, "VerifyToken")
+		mockClient.AssertNotCalled(t, "VerifyTokenClaims")
 	})
 
 	t.Run("invalid authorization header format", func(t *testing.T) {
@@ -125,7 +143,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, resp.Code)
 		assert.Contains(t, resp.Body.String(), "Invalid authorization header format")
-		mockClient.AssertNotCalled(t, "VerifyToken")
+		mockClient.AssertNotCalled(t, "VerifyTokenClaims")
 	})
 
 	t.Run("empty token", func(t *testing.T) {
@@ -146,7 +164,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, resp.Code)
 		assert.Contains(t, resp.Body.String(), "Empty token provided")
-		mockClient.AssertNotCalled(t, "VerifyToken")
+		mockClient.AssertNotCalled(t, "VerifyTokenClaims")
 	})
 
 	t.Run("invalid token but successful refresh", func(t *testing.T) {
@@ -154,7 +172,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
 
 		// First VerifyToken call fails
-		mockClient.On("VerifyToken", mock.Anything, "expired-token").Return("", errors.New("token expired"))
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, errors.New("token expired"))
 
 		// Mock refresh response
 		refreshResponse := &http.Response{
@@ -165,7 +183,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient.On("Refresh", mock.Anything, "refresh-token").Return(refreshResponse, nil)
 
 		// Second VerifyToken call succeeds with new token
-		mockClient.On("VerifyToken", mock.Anything, "new-token").Return("testuser", nil)
+		mockClient.On("VerifyTokenClaims", mock.Anything, "new-token").Return(&authclient.Claims{Username: "testuser"}, nil)
 
 		router := gin.New()
 		router.Use(middleware.Auth())
@@ -186,11 +204,51 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 
+	t.Run("rotated refresh token is passed to the browser", func(t *testing.T) {
+		mockClient := new(MockAuthClient)
+		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, errors.New("token expired"))
+		// Каждый refresh токен принимается только один раз, как в AuthService.
+		for i, next := range []string{"2", "3"} {
+			refreshResponse := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+			refreshResponse.Header.Set("Authorization", "Bearer access-"+next)
+			refreshResponse.Header.Set("Set-Cookie", "refresh_token=refresh-"+next+"; HttpOnly; Path=/")
+			mockClient.On("Refresh", mock.Anything, "refresh-"+strconv.Itoa(i+1)).Return(refreshResponse, nil).Once()
+			mockClient.On("VerifyTokenClaims", mock.Anything, "access-"+next).Return(&authclient.Claims{Username: "testuser"}, nil)
+		}
+
+		router := gin.New()
+		router.Use(middleware.Auth())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		cookie := &http.Cookie{Name: "refresh_token", Value: "refresh-1"}
+		for _, expected := range []string{"2", "3"} {
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer expired-token")
+			req.AddCookie(cookie)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "Bearer access-"+expected, resp.Header().Get("Authorization"))
+			cookies := resp.Result().Cookies()
+			if assert.Len(t, cookies, 1) {
+				assert.Equal(t, "refresh-"+expected, cookies[0].Value)
+				cookie = cookies[0]
+			}
+		}
+		mockClient.AssertExpectations(t)
+	})
+
 	t.Run("missing refresh token when access token is invalid", func(t *testing.T) {
 		mockClient := new(MockAuthClient)
 		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
 
-		mockClient.On("VerifyToken", mock.Anything, "expired-token").Return("", errors.New("token expired"))
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, errors.New("token expired"))
 
 		router := gin.New()
 		router.Use(middleware.Auth())
@@ -209,11 +267,91 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 
+	t.Run("suspended user is not refreshed", func(t *testing.T) {
+		mockClient := new(MockAuthClient)
+		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+		mockClient.On("VerifyTokenClaims", mock.Anything, "valid-token").Return(nil, &authclient.TokenError{
+			Message: "account banned: spam",
+			Reason:  authclient.ReasonAccountSuspended,
+		})
+
+		router := gin.New()
+		router.Use(middleware.Auth())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "account banned: spam")
+		mockClient.AssertNotCalled(t, "Refresh", mock.Anything, mock.Anything)
+	})
+
+	t.Run("refresh of a suspended user is forbidden", func(t *testing.T) {
+		mockClient := new(MockAuthClient)
+		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, &authclient.TokenError{
+			Message: "token expired",
+			Reason:  "invalid_token",
+		})
+		mockClient.On("Refresh", mock.Anything, "refresh-token").Return(nil, &authclient.PermissionError{
+			Message: "account suspended until 2030-01-01T00:00:00Z: spam",
+			Reason:  authclient.ErrorInfoAccountSuspended,
+		})
+
+		router := gin.New()
+		router.Use(middleware.Auth())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer expired-token")
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.JSONEq(t, `{"error":"account suspended until 2030-01-01T00:00:00Z: spam"}`, resp.Body.String())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("suspension text without a reason is not a suspension", func(t *testing.T) {
+		mockClient := new(MockAuthClient)
+		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+		mockClient.On("VerifyTokenClaims", mock.Anything, "valid-token").Return(nil, errors.New("rpc error: account suspended"))
+
+		router := gin.New()
+		router.Use(middleware.Auth())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Refresh token required")
+	})
+
 	t.Run("refresh token fails", func(t *testing.T) {
 		mockClient := new(MockAuthClient)
 		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
 
-		mockClient.On("VerifyToken", mock.Anything, "expired-token").Return("", errors.New("token expired"))
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, errors.New("token expired"))
 		mockClient.On("Refresh", mock.Anything, "invalid-refresh-token").Return(
 			&http.Response{StatusCode: http.StatusUnauthorized},
 			errors.New("refresh failed"),
@@ -242,7 +380,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
 
 		// First VerifyToken call fails
-		mockClient.On("VerifyToken", mock.Anything, "expired-token").Return("", errors.New("token expired"))
+		mockClient.On("VerifyTokenClaims", mock.Anything, "expired-token").Return(nil, errors.New("token expired"))
 
 		// Mock refresh response
 		refreshResponse := &http.Response{
@@ -253,7 +391,7 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient.On("Refresh", mock.Anything, "refresh-token").Return(refreshResponse, nil)
 
 		// Second VerifyToken call fails with new token
-		mockClient.On("VerifyToken", mock.Anything, "invalid-new-token").Return("", errors.New("invalid token"))
+		mockClient.On("VerifyTokenClaims", mock.Anything, "invalid-new-token").Return(nil, errors.New("invalid token"))
 
 		router := gin.New()
 		router.Use(middleware.Auth())
@@ -273,3 +411,87 @@ func TestAuthMiddleware_Auth(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 }
+
+func TestAuthMiddleware_VerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		required     bool
+		verified     bool
+		expectedCode int
+	}{
+		{name: "verified user", required: true, verified: true, expectedCode: http.StatusOK},
+		{name: "unverified user", required: true, verified: false, expectedCode: http.StatusForbidden},
+		{name: "check disabled", required: false, verified: false, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockAuthClient)
+			middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+			mockClient.On("VerifyTokenClaims", mock.Anything, "token").
+				Return(&authclient.Claims{Username: "testuser", EmailVerified: tt.verified}, nil)
+
+			router := gin.New()
+			router.Use(middleware.Auth(), middleware.VerifiedEmail(tt.required))
+			router.POST("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req, _ := http.NewRequest("POST", "/test", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_RequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		claims       authclient.Claims
+		expectedCode int
+	}{
+		{name: "first party token", claims: authclient.Claims{EmailVerified: true}, expectedCode: http.StatusOK},
+		{name: "client with scope", claims: authclient.Claims{ClientID: "forum-bot", Scopes: []string{"profile:read", "topics:write"}}, expectedCode: http.StatusOK},
+		{name: "client without scope", claims: authclient.Claims{ClientID: "forum-bot", Scopes: []string{"profile:read"}}, expectedCode: http.StatusForbidden},
+		{name: "client without scopes", claims: authclient.Claims{ClientID: "forum-bot"}, expectedCode: http.StatusForbidden},
+		{name: "personal token with scope", claims: authclient.Claims{PersonalToken: true, Scopes: []string{"topics:write"}}, expectedCode: http.StatusOK},
+		{name: "personal token without scope", claims: authclient.Claims{PersonalToken: true, Scopes: []string{"profile:read"}}, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockAuthClient)
+			middleware := NewAuthMiddleware(mockClient, *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})))
+
+			claims := tt.claims
+			claims.Username = "testuser"
+			mockClient.On("VerifyTokenClaims", mock.Anything, "token").Return(&claims, nil)
+
+			router := gin.New()
+			router.Use(middleware.Auth(), middleware.RequireScope("topics:write"))
+			router.POST("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req, _ := http.NewRequest("POST", "/test", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			if tt.expectedCode == http.StatusForbidden {
+				assert.Contains(t, resp.Header().Get("WWW-Authenticate"), "insufficient_scope")
+			}
+		})
+	}
+}
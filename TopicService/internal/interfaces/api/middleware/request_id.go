@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	// requestIDMetadataKey совпадает с ключом, который читает AuthService.
+	requestIDMetadataKey = "x-request-id"
+	maxRequestIDLength   = 128
+)
+
+// RequestID присваивает запросу идентификатор (из заголовка X-Request-ID
+// или новый), возвращает его клиенту и добавляет в исходящие gRPC
+// метаданные контекста запроса. Все вызовы AuthService используют этот
+// контекст, поэтому идентификатор попадает в каждый из них.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(
+			metadata.AppendToOutgoingContext(c.Request.Context(), requestIDMetadataKey, id),
+		)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "client id is kept", header: "req-42", wantSame: true},
+		{name: "missing id is generated", header: ""},
+		{name: "invalid id is replaced", header: "bad id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded []string
+
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/test", func(c *gin.Context) {
+				md, _ := metadata.FromOutgoingContext(c.Request.Context())
+				forwarded = md.Get("x-request-id")
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			id := resp.Header().Get(RequestIDHeader)
+			if tt.wantSame {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+			assert.Equal(t, []string{id}, forwarded)
+		})
+	}
+}
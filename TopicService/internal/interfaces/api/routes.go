@@ -26,13 +26,16 @@ func SetupTopicRoutes(router *gin.Engine,
 	th *http.TopicHandler,
 	ch *http.CommentHandler,
 	ah *http.AuthHandler,
-	authMiddleware gin.HandlerFunc) {
+	authMiddleware gin.HandlerFunc,
+	verifiedMiddleware gin.HandlerFunc,
+	scopeMiddleware func(scope string) gin.HandlerFunc) {
 
 	// Auth routes
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/register", ah.Register)
 		authGroup.POST("/login", ah.Login)
+		authGroup.POST("/second-factor", ah.SecondFactor)
 		authGroup.POST("/refresh", ah.Refresh)
 		authGroup.POST("/logout", ah.Logout)
 	}
@@ -48,9 +51,9 @@ func SetupTopicRoutes(router *gin.Engine,
 		// Protected routes
 		protected := topicGroup.Use(authMiddleware)
 		{
-			protected.POST("/", th.CreateTopic)
-			protected.PUT("/:id", th.UpdateTopic)
-			protected.DELETE("/:id", th.DeleteTopic)
+			protected.POST("/", scopeMiddleware("topics:write"), verifiedMiddleware, th.CreateTopic)
+			protected.PUT("/:id", scopeMiddleware("topics:write"), verifiedMiddleware, th.UpdateTopic)
+			protected.DELETE("/:id", scopeMiddleware("topics:write"), th.DeleteTopic)
 		}
 	}
 
@@ -61,9 +64,9 @@ func SetupTopicRoutes(router *gin.Engine,
 
 		protected := commentGroup.Use(authMiddleware)
 		{
-			protected.POST("/", ch.CreateComment)
-			protected.PUT("/:id", ch.UpdateComment)
-			protected.DELETE("/:id", ch.DeleteComment)
+			protected.POST("/", scopeMiddleware("comments:write"), verifiedMiddleware, ch.CreateComment)
+			protected.PUT("/:id", scopeMiddleware("comments:write"), verifiedMiddleware, ch.UpdateComment)
+			protected.DELETE("/:id", scopeMiddleware("comments:write"), ch.DeleteComment)
 		}
 	}
 
@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"TopicService/internal/domain/models"
+	"TopicService/pkg/authclient"
 	"context"
 	"net/http"
 )
@@ -23,8 +24,10 @@ type TopicUseCasesInterface interface {
 type GRPCClientInterface interface {
 	Login(ctx context.Context, username, password string) (*http.Response, error)
 	Logout(ctx context.Context) (*http.Response, error)
-	Register(ctx context.Context, username, password string) (*http.Response, error)
+	Register(ctx context.Context, username, password, email string) (*http.Response, error)
 	Refresh(ctx context.Context, refreshToken string) (*http.Response, error)
 	VerifyToken(ctx context.Context, token string) (string, error)
+	VerifyTokenClaims(ctx context.Context, token string) (*authclient.Claims, error)
+	VerifySecondFactor(ctx context.Context, challengeToken, code string) (*http.Response, error)
 	Close() error
 }
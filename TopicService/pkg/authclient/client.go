@@ -0,0 +1,284 @@
+// Package authclient дополняет общий gRPC клиент AuthService вызовами,
+// которых в нем нет: вход с вторым фактором, обмен challenge токена на
+// сессию и проверка токена с полным набором claims. Регистрация и Refresh
+// тоже идут через этот пакет: ошибки его вызовов сохраняют детали статуса
+// gRPC.
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/x-t4m-cx/common-grpc-auth/client"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	registerMethod           = "/auth.AuthService/Register"
+	loginMethod              = "/auth.AuthService/Login"
+	refreshMethod            = "/auth.AuthService/Refresh"
+	verifySecondFactorMethod = "/auth.AuthService/VerifySecondFactor"
+	verifyTokenMethod        = "/auth.AuthService/VerifyToken"
+)
+
+// Claims - данные о владельце токена, которые AuthService возвращает из
+// VerifyToken. Они отражают текущее состояние пользователя, а не момент
+// выдачи токена.
+type Claims struct {
+	Username      string
+	UserID        int64
+	Roles         []string
+	EmailVerified bool
+	// ClientID и Scopes заданы у токенов, выданных клиенту OAuth, Scopes -
+	// также у personal access токенов.
+	ClientID      string
+	Scopes        []string
+	PersonalToken bool
+}
+
+// RetryAfterError - отказ AuthService из-за слишком частых попыток
+// (ResourceExhausted). RetryAfter берется из RetryInfo статуса и равен
+// нулю, если его там нет.
+type RetryAfterError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return "too many requests: " + e.Message
+}
+
+// ValidationError - отказ InvalidArgument. Reasons - коды причин из
+// BadRequest статуса, например "password_too_short".
+type ValidationError struct {
+	Message string
+	Reasons []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid argument: " + e.Message
+}
+
+// Признаки блокировки аккаунта в отказах AuthService.
+const (
+	// ReasonAccountSuspended - error_reason отказа VerifyToken.
+	ReasonAccountSuspended = "account_suspended"
+	// ErrorInfoAccountSuspended - Reason в ErrorInfo отказа PermissionDenied.
+	ErrorInfoAccountSuspended = "ACCOUNT_SUSPENDED"
+)
+
+// TokenError - отказ VerifyToken. Reason - error_reason ответа:
+// invalid_token, session_revoked или account_suspended.
+type TokenError struct {
+	Message string
+	Reason  string
+}
+
+func (e *TokenError) Error() string {
+	return e.Message
+}
+
+// PermissionError - отказ PermissionDenied. Reason берется из ErrorInfo
+// статуса и пуст, если его там нет.
+type PermissionError struct {
+	Message string
+	Reason  string
+}
+
+func (e *PermissionError) Error() string {
+	return "permission denied: " + e.Message
+}
+
+type Client struct {
+	*client.GRPCClient
+	conn *grpc.ClientConn
+}
+
+func New(authServiceAddr string) (*Client, error) {
+	common, err := client.New(authServiceAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(authServiceAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		common.Close()
+		return nil, fmt.Errorf("failed to connect to auth service: %w", err)
+	}
+	return &Client{GRPCClient: common, conn: conn}, nil
+}
+
+// Register в отличие от общего клиента передает необязательный адрес почты
+// и возвращает причины отказа в ValidationError.
+func (c *Client) Register(ctx context.Context, username, password, email string) (*http.Response, error) {
+	req := &RegisterRequest{Username: username, Password: password, Email: email}
+	err := c.conn.Invoke(ctx, registerMethod, req, &RegisterResponse{})
+	if err != nil {
+		return nil, convertGRPCError(err)
+	}
+	return &http.Response{StatusCode: http.StatusCreated}, nil
+}
+
+// Login в отличие от общего клиента учитывает второй фактор: если он
+// включен, AuthService не выдает токены, и в теле ответа возвращается
+// challenge токен для VerifySecondFactor.
+func (c *Client) Login(ctx context.Context, username, password string) (*http.Response, error) {
+	resp := &LoginResponse{}
+	err := c.conn.Invoke(ctx, loginMethod, &LoginRequest{Username: username, Password: password}, resp)
+	if err != nil {
+		return nil, convertGRPCError(err)
+	}
+
+	if resp.SecondFactorRequired {
+		return jsonResponse(map[string]interface{}{
+			"message":                resp.Message,
+			"second_factor_required": true,
+			"challenge_token":        resp.ChallengeToken,
+		})
+	}
+	return tokenResponse(resp.AccessToken, resp.RefreshToken), nil
+}
+
+// Refresh в отличие от общего клиента возвращает блокировку аккаунта как
+// PermissionError с причиной из ErrorInfo.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*http.Response, error) {
+	resp := &RefreshResponse{}
+	err := c.conn.Invoke(ctx, refreshMethod, &RefreshRequest{RefreshToken: refreshToken}, resp)
+	if err != nil {
+		return nil, convertGRPCError(err)
+	}
+	return tokenResponse(resp.AccessToken, resp.RefreshToken), nil
+}
+
+// VerifySecondFactor обменивает challenge токен и код второго фактора на
+// пару токенов. Ответ устроен так же, как ответ Login без второго фактора.
+func (c *Client) VerifySecondFactor(ctx context.Context, challengeToken, code string) (*http.Response, error) {
+	resp := &VerifySecondFactorResponse{}
+	err := c.conn.Invoke(ctx, verifySecondFactorMethod,
+		&VerifySecondFactorRequest{ChallengeToken: challengeToken, Code: code}, resp)
+	if err != nil {
+		return nil, convertGRPCError(err)
+	}
+	return tokenResponse(resp.AccessToken, resp.RefreshToken), nil
+}
+
+// VerifyTokenClaims проверяет токен так же, как VerifyToken общего
+// клиента, но возвращает все claims. Отказ AuthService возвращается как
+// TokenError с текстом и причиной из ответа.
+func (c *Client) VerifyTokenClaims(ctx context.Context, token string) (*Claims, error) {
+	resp := &VerifyTokenResponse{}
+	err := c.conn.Invoke(ctx, verifyTokenMethod, &VerifyTokenRequest{Token: token}, resp)
+	if err != nil {
+		return nil, convertGRPCError(err)
+	}
+	if !resp.Valid {
+		return nil, &TokenError{Message: resp.Error, Reason: resp.ErrorReason}
+	}
+
+	return &Claims{
+		Username:      resp.Username,
+		UserID:        resp.UserId,
+		Roles:         resp.Roles,
+		EmailVerified: resp.EmailVerified,
+		ClientID:      resp.ClientId,
+		Scopes:        resp.Scopes,
+		PersonalToken: resp.CredentialKind == CredentialKind_CREDENTIAL_KIND_PERSONAL_ACCESS_TOKEN,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	if closeErr := c.GRPCClient.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WithClientInfo передает в AuthService адрес и user agent клиента, от
+// имени которого выполняется запрос. AuthService сохраняет их в сессии
+// устройства при входе и обновляет при каждом Refresh; метаданные
+// принимаются, только если TopicService указан в его TrustedProxies.
+func WithClientInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		"x-forwarded-for", ip,
+		"x-forwarded-user-agent", userAgent,
+	)
+}
+
+func tokenResponse(accessToken, refreshToken string) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+	}
+	resp.Header.Set("Authorization", "Bearer "+accessToken)
+	resp.Header.Set("Set-Cookie", "refresh_token="+refreshToken+"; HttpOnly; Path=/")
+	return resp
+}
+
+func jsonResponse(body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
+
+// convertGRPCError повторяет преобразование ошибок общего клиента:
+// обработчики TopicService различают ошибки по префиксу текста. Отказы с
+// деталями статуса (ResourceExhausted, InvalidArgument, PermissionDenied)
+// возвращаются типизированными ошибками.
+func convertGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return fmt.Errorf("authentication failed: %s", st.Message())
+	case codes.AlreadyExists:
+		return fmt.Errorf("resource already exists: %s", st.Message())
+	case codes.NotFound:
+		return fmt.Errorf("resource not found: %s", st.Message())
+	case codes.InvalidArgument:
+		invalid := &ValidationError{Message: st.Message()}
+		for _, detail := range st.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				for _, violation := range badRequest.FieldViolations {
+					invalid.Reasons = append(invalid.Reasons, violation.Description)
+				}
+			}
+		}
+		return invalid
+	case codes.ResourceExhausted:
+		retry := &RetryAfterError{Message: st.Message()}
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok {
+				retry.RetryAfter = info.RetryDelay.AsDuration()
+			}
+		}
+		return retry
+	case codes.PermissionDenied:
+		denied := &PermissionError{Message: st.Message()}
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				denied.Reason = info.Reason
+			}
+		}
+		return denied
+	default:
+		return fmt.Errorf("rpc error: %s", st.Message())
+	}
+}
@@ -0,0 +1,186 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// startAuthService запускает gRPC сервер, который отвечает на Login и
+// VerifySecondFactor как AuthService с включенным вторым фактором, на
+// VerifyToken - как на проверку personal access токена, на Refresh - как
+// на обновление сессии заблокированного пользователя, а Register
+// принимает только с адресом alice@example.com и отказывает с причинами в
+// деталях статуса.
+func startAuthService(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		switch method {
+		case registerMethod:
+			req := &RegisterRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			if req.Email == "alice@example.com" {
+				return stream.SendMsg(&RegisterResponse{Message: "user created successfully"})
+			}
+			st, _ := status.New(codes.InvalidArgument, "invalid registration data: password_too_short").
+				WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "password", Description: "password_too_short"},
+				}})
+			return st.Err()
+		case loginMethod:
+			req := &LoginRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			if req.Password == "locked" {
+				st, _ := status.New(codes.ResourceExhausted, "too many login attempts, retry after 30s").
+					WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(30 * time.Second)})
+				return st.Err()
+			}
+			return stream.SendMsg(&LoginResponse{
+				Message:              "second factor required",
+				SecondFactorRequired: true,
+				ChallengeToken:       "challenge-for-" + req.Username,
+			})
+		case refreshMethod:
+			req := &RefreshRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			st, _ := status.New(codes.PermissionDenied, "account banned: spam").
+				WithDetails(&errdetails.ErrorInfo{Reason: ErrorInfoAccountSuspended, Domain: "auth-service"})
+			return st.Err()
+		case verifySecondFactorMethod:
+			req := &VerifySecondFactorRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			if req.Code != "123456" {
+				return status.Error(codes.Unauthenticated, "invalid second factor code")
+			}
+			return stream.SendMsg(&VerifySecondFactorResponse{AccessToken: "access", RefreshToken: "refresh"})
+		case verifyTokenMethod:
+			req := &VerifyTokenRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			if req.Token != "personal-token" {
+				return stream.SendMsg(&VerifyTokenResponse{Error: "account suspended: spam", ErrorReason: "account_suspended"})
+			}
+			return stream.SendMsg(&VerifyTokenResponse{
+				Valid:          true,
+				Username:       "alice",
+				UserId:         7,
+				EmailVerified:  true,
+				Scopes:         []string{"topics:write"},
+				CredentialKind: CredentialKind_CREDENTIAL_KIND_PERSONAL_ACCESS_TOKEN,
+			})
+		}
+		return status.Error(codes.Unimplemented, method)
+	}))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestClient_SecondFactorLogin(t *testing.T) {
+	c, err := New(startAuthService(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	resp, err := c.Login(context.Background(), "alice", "password")
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Authorization"))
+	assert.Empty(t, resp.Header.Get("Set-Cookie"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var challenge struct {
+		SecondFactorRequired bool   `json:"second_factor_required"`
+		ChallengeToken       string `json:"challenge_token"`
+	}
+	require.NoError(t, json.Unmarshal(body, &challenge))
+	assert.True(t, challenge.SecondFactorRequired)
+	assert.Equal(t, "challenge-for-alice", challenge.ChallengeToken)
+
+	resp, err = c.VerifySecondFactor(context.Background(), challenge.ChallengeToken, "123456")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Bearer access", resp.Header.Get("Authorization"))
+	assert.Equal(t, "refresh_token=refresh; HttpOnly; Path=/", resp.Header.Get("Set-Cookie"))
+
+	_, err = c.VerifySecondFactor(context.Background(), challenge.ChallengeToken, "000000")
+	assert.EqualError(t, err, "authentication failed: invalid second factor code")
+}
+
+func TestClient_VerifyTokenClaims(t *testing.T) {
+	c, err := New(startAuthService(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	claims, err := c.VerifyTokenClaims(context.Background(), "personal-token")
+	require.NoError(t, err)
+	assert.Equal(t, &Claims{
+		Username:      "alice",
+		UserID:        7,
+		EmailVerified: true,
+		Scopes:        []string{"topics:write"},
+		PersonalToken: true,
+	}, claims)
+
+	_, err = c.VerifyTokenClaims(context.Background(), "other-token")
+	var tokenErr *TokenError
+	require.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, ReasonAccountSuspended, tokenErr.Reason)
+	assert.EqualError(t, err, "account suspended: spam")
+}
+
+func TestClient_ErrorsKeepStatusDetails(t *testing.T) {
+	c, err := New(startAuthService(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Login(context.Background(), "alice", "locked")
+	var retry *RetryAfterError
+	require.ErrorAs(t, err, &retry)
+	assert.Equal(t, 30*time.Second, retry.RetryAfter)
+
+	_, err = c.Register(context.Background(), "alice", "short", "")
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"password_too_short"}, invalid.Reasons)
+	assert.EqualError(t, err, "invalid argument: invalid registration data: password_too_short")
+
+	_, err = c.Refresh(context.Background(), "refresh")
+	var denied *PermissionError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, ErrorInfoAccountSuspended, denied.Reason)
+	assert.Equal(t, "account banned: spam", denied.Message)
+}
+
+func TestClient_RegisterSendsEmail(t *testing.T) {
+	c, err := New(startAuthService(t))
+	require.NoError(t, err)
+	defer c.Close()
+
+	resp, err := c.Register(context.Background(), "alice", "correct-horse-battery", "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
@@ -115,6 +115,9 @@ func HTTPLogMiddleware(logger slog.Logger) gin.HandlerFunc {
 			"latency", latency,
 			"client", c.ClientIP(),
 		}
+		if requestID := c.GetString("request_id"); requestID != "" {
+			logFields = append(logFields, "request_id", requestID)
+		}
 
 		// Логируем в зависимости от статуса
 		switch {
@@ -167,7 +167,8 @@ document.addEventListener('DOMContentLoaded', function() {
         e.preventDefault();
         const username = document.getElementById('registerUsername').value;
         const password = document.getElementById('registerPassword').value;
-        await register(username, password);
+        const email = document.getElementById('registerEmail').value;
+        await register(username, password, email);
     });
 
     topicForm.addEventListener('submit', (e) => {
@@ -238,7 +239,7 @@ document.addEventListener('DOMContentLoaded', function() {
 
     async function login(username, password) {
         try {
-            const response = await fetch('/auth/login', {
+            let response = await fetch('/auth/login', {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
@@ -254,11 +255,21 @@ document.addEventListener('DOMContentLoaded', function() {
                 throw new Error(errorData.error || 'Ошибка входа');
             }
 
+            // При включенном втором факторе токены выдаются только после
+            // ввода кода из приложения или кода восстановления.
+            if ((response.headers.get('Content-Type') || '').includes('application/json')) {
+                const data = await response.json();
+                if (data.second_factor_required) {
+                    response = await verifySecondFactor(data.challenge_token);
+                }
+            }
+
             // Получаем токен из заголовка Authorization
             const authHeader = response.headers.get('Authorization');
-            if (authHeader && authHeader.startsWith('Bearer ')) {
-                authToken = authHeader.substring(7);
+            if (!authHeader || !authHeader.startsWith('Bearer ')) {
+                throw new Error('сервер не вернул токен');
             }
+            authToken = authHeader.substring(7);
 
             // Сохраняем данные аутентификации
             currentUser = username;
@@ -275,7 +286,43 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
 
-    async function register(username, password) {
+    async function verifySecondFactor(challengeToken) {
+        const code = prompt('Введите код из приложения-аутентификатора или код восстановления');
+        if (!code) {
+            throw new Error('код второго фактора не введен');
+        }
+
+        const response = await fetch('/auth/second-factor', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                challenge_token: challengeToken,
+                code: code.trim()
+            })
+        });
+
+        if (!response.ok) {
+            const errorData = await response.json();
+            throw new Error(errorData.error || 'Неверный код');
+        }
+        return response;
+    }
+
+    const registrationReasons = {
+        password_too_short: 'пароль слишком короткий',
+        password_too_long: 'пароль слишком длинный',
+        password_common: 'пароль слишком распространенный',
+        password_equals_username: 'пароль совпадает с именем пользователя',
+        username_too_short: 'имя пользователя слишком короткое',
+        username_too_long: 'имя пользователя слишком длинное',
+        username_invalid_characters: 'имя пользователя содержит недопустимые символы',
+        username_reserved: 'имя пользователя зарезервировано',
+        email_invalid: 'некорректный адрес почты'
+    };
+
+    async function register(username, password, email) {
         try {
             const response = await makeRequest('/auth/register',{
                 method: 'POST',
@@ -284,12 +331,18 @@ document.addEventListener('DOMContentLoaded', function() {
                 },
                 body: JSON.stringify({
                     username: username,
-                    password: password
+                    password: password,
+                    email: email
                 })
             });
 
             if (!response.ok) {
                 const errorData = await response.json();
+                if (errorData.reasons) {
+                    throw new Error(errorData.reasons
+                        .map(reason => registrationReasons[reason] || reason)
+                        .join('; '));
+                }
                 throw new Error(errorData.error || 'Ошибка регистрации');
             }
 